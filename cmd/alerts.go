@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/types"
+)
+
+var (
+	alertsLat      float64
+	alertsLon      float64
+	alertsPlace    string
+	alertsZone     string
+	alertsSave     bool
+	alertsWatch    bool
+	alertsInterval time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(alerts)
+
+	// Add flags for coordinates
+	alerts.Flags().Float64VarP(&alertsLat, "lat", "a", 0.0, "Latitude to get active alerts for")
+	alerts.Flags().Float64VarP(&alertsLon, "lon", "o", 0.0, "Longitude to get active alerts for")
+	alerts.Flags().StringVarP(&alertsPlace, "location", "l", "", "Named location to get active alerts for (instead of --lat/--lon)")
+	alerts.Flags().StringVarP(&alertsZone, "zone", "z", "", "Forecast or county zone to get active alerts for, e.g. COZ039 (instead of coordinates)")
+	alerts.Flags().BoolVarP(&alertsSave, "save", "s", false, "Save alerts to database")
+	alerts.Flags().BoolVarP(&alertsWatch, "watch", "w", false, "Keep polling for alerts and print new ones as they are issued")
+	alerts.Flags().DurationVar(&alertsInterval, "interval", 5*time.Minute, "Polling interval for --watch")
+
+	// Bind flags to viper for configuration file support
+	viper.BindPFlag("alerts.latitude", alerts.Flags().Lookup("lat"))
+	viper.BindPFlag("alerts.longitude", alerts.Flags().Lookup("lon"))
+	viper.BindPFlag("alerts.location", alerts.Flags().Lookup("location"))
+	viper.BindPFlag("alerts.zone", alerts.Flags().Lookup("zone"))
+	viper.BindPFlag("alerts.save", alerts.Flags().Lookup("save"))
+	viper.BindPFlag("alerts.interval", alerts.Flags().Lookup("interval"))
+}
+
+var alerts = &cobra.Command{
+	Use:   "alerts",
+	Short: "Get active weather alerts for a location",
+	Long: `Get active watches, warnings and advisories from the National Weather Service for the
+specified coordinates or zone.
+
+With --save, alerts are stored in the database once per alert ID. Updates and cancellations
+mark the alerts they reference as superseded or cancelled.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Get location from flags or config, fallback to forecast config
+		lat := viper.GetFloat64("alerts.latitude")
+		lon := viper.GetFloat64("alerts.longitude")
+		zone := viper.GetString("alerts.zone")
+		save := viper.GetBool("alerts.save")
+		interval := viper.GetDuration("alerts.interval")
+
+		locationName := viper.GetString("alerts.location")
+
+		if zone == "" && locationName == "" && lat == 0.0 && lon == 0.0 {
+			locationName = viper.GetString("forecast.location")
+			lat = viper.GetFloat64("forecast.latitude")
+			lon = viper.GetFloat64("forecast.longitude")
+		}
+
+		if zone == "" {
+			// Resolve the named location or check the coordinates
+			var err error
+			if _, lat, lon, err = resolveCoordinates(cmd, locationName, lat, lon); err != nil {
+				return fmt.Errorf("%w, or use --zone", err)
+			}
+		}
+
+		if interval <= 0 {
+			interval = 5 * time.Minute
+		}
+
+		client, err := newWeatherClient()
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		fetch := func() ([]types.Alert, error) {
+			if zone != "" {
+				return client.GetActiveAlertsByZoneContext(ctx, zone)
+			}
+			return client.GetActiveAlertsByPointContext(ctx, lat, lon)
+		}
+
+		if zone != "" {
+			fmt.Fprintf(os.Stderr, "Getting active alerts for zone: %s\n\n", zone)
+		} else {
+			fmt.Fprintf(os.Stderr, "Getting active alerts for coordinates: %.4f, %.4f\n\n", lat, lon)
+		}
+
+		seen := make(map[string]bool)
+
+		for {
+			active, err := fetch()
+			if err != nil {
+				if !alertsWatch {
+					return fmt.Errorf("failed to get alerts: %w", err)
+				}
+				fmt.Fprintf(os.Stderr, "Error getting alerts: %v\n", err)
+			} else {
+				if save {
+					result, err := types.SaveAlertsToDBContext(ctx, active)
+					switch {
+					case err != nil && !alertsWatch:
+						return fmt.Errorf("failed to save alerts to database: %w", err)
+					case err != nil:
+						fmt.Fprintf(os.Stderr, "Error saving alerts to database: %v\n", err)
+					default:
+						fmt.Fprintf(os.Stderr, "📊 Database summary: %s\n", result)
+					}
+				}
+
+				if !alertsWatch && len(active) == 0 {
+					fmt.Printf("No active alerts\n")
+				}
+
+				for _, alert := range active {
+					if seen[alert.ID] {
+						continue
+					}
+					seen[alert.ID] = true
+
+					if alertsWatch {
+						fmt.Printf("[%s] ", time.Now().Format("2006-01-02 15:04:05"))
+					}
+					fmt.Print(alert.FormatAlert())
+					fmt.Printf("\n")
+				}
+			}
+
+			if !alertsWatch {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	},
+}
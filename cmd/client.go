@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"golang.org/x/time/rate"
+
+	"github.com/dwburke/weather/metrics"
+	"github.com/dwburke/weather/types"
+)
+
+var (
+	// limiter is shared by every client in the process so a multi-location run stays
+	// within the configured request rate
+	limiter     *rate.Limiter
+	limiterOnce sync.Once
+
+	// openMeteoLimiter is the same for Open-Meteo clients, which have their own rate limit
+	openMeteoLimiter     *rate.Limiter
+	openMeteoLimiterOnce sync.Once
+)
+
+func init() {
+	defaultRetry := types.DefaultRetryPolicy()
+
+	viper.SetDefault("nws.base_url", types.NewWeatherClient().BaseURL)
+	viper.SetDefault("nws.points_cache.enabled", true)
+	viper.SetDefault("nws.points_cache.ttl", types.DefaultPointsCacheTTL)
+	viper.SetDefault("nws.points_cache.file", defaultPointsCacheFile())
+	viper.SetDefault("nws.retry.max_attempts", defaultRetry.MaxAttempts)
+	viper.SetDefault("nws.retry.initial_backoff", defaultRetry.InitialBackoff)
+	viper.SetDefault("nws.retry.max_backoff", defaultRetry.MaxBackoff)
+	viper.SetDefault("nws.retry.retryable_status", defaultRetry.RetryableStatus)
+	viper.SetDefault("nws.rate_limit.requests_per_second", 5)
+	viper.SetDefault("nws.rate_limit.burst", 5)
+	viper.SetDefault("open_meteo.base_url", types.NewOpenMeteoClient().BaseURL)
+	viper.SetDefault("open_meteo.retry.max_attempts", defaultRetry.MaxAttempts)
+	viper.SetDefault("open_meteo.retry.initial_backoff", defaultRetry.InitialBackoff)
+	viper.SetDefault("open_meteo.retry.max_backoff", defaultRetry.MaxBackoff)
+	viper.SetDefault("open_meteo.retry.retryable_status", defaultRetry.RetryableStatus)
+	viper.SetDefault("open_meteo.rate_limit.requests_per_second", 5)
+	viper.SetDefault("open_meteo.rate_limit.burst", 5)
+}
+
+// retryPolicy returns the retry policy configured in the <section>.retry config section
+func retryPolicy(section string) types.RetryPolicy {
+	return types.RetryPolicy{
+		MaxAttempts:     viper.GetInt(section + ".retry.max_attempts"),
+		InitialBackoff:  viper.GetDuration(section + ".retry.initial_backoff"),
+		MaxBackoff:      viper.GetDuration(section + ".retry.max_backoff"),
+		RetryableStatus: viper.GetIntSlice(section + ".retry.retryable_status"),
+	}
+}
+
+// rateLimiter returns the limiter configured in the <section>.rate_limit config section
+func rateLimiter(section string) *rate.Limiter {
+	return types.NewRateLimiter(viper.GetFloat64(section+".rate_limit.requests_per_second"), viper.GetInt(section+".rate_limit.burst"))
+}
+
+// validateProvider checks that name is a known provider, an empty name meaning NWS
+func validateProvider(name string) error {
+	switch name {
+	case "", types.ProviderNWS, types.ProviderOpenMeteo:
+		return nil
+	}
+	return fmt.Errorf("unknown provider %q, must be %s or %s", name, types.ProviderNWS, types.ProviderOpenMeteo)
+}
+
+// newProvider creates the weather provider with the given name, NWS when the name is empty
+func newProvider(name string) (types.Provider, error) {
+	if err := validateProvider(name); err != nil {
+		return nil, err
+	}
+
+	if name == types.ProviderOpenMeteo {
+		transport, err := apiTransport()
+		if err != nil {
+			return nil, err
+		}
+
+		client := types.NewOpenMeteoClient()
+		client.BaseURL = viper.GetString("open_meteo.base_url")
+		client.HTTPClient.Transport = metrics.InstrumentTransport(types.ProviderOpenMeteo, transport)
+		client.RetryPolicy = retryPolicy("open_meteo")
+
+		openMeteoLimiterOnce.Do(func() {
+			openMeteoLimiter = rateLimiter("open_meteo")
+		})
+		client.Limiter = openMeteoLimiter
+
+		if viper.GetString("http.replay") != "" {
+			client.RetryPolicy = types.RetryPolicy{MaxAttempts: 1}
+			client.Limiter = nil
+		}
+		return client, nil
+	}
+
+	client, err := newWeatherClient()
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// selectProvider creates the provider for a command: the --provider flag if given, otherwise the
+// named location's provider, otherwise the <key>.provider config setting, defaulting to NWS
+func selectProvider(cmd *cobra.Command, key string, loc *types.Location) (types.Provider, error) {
+	name := viper.GetString(key + ".provider")
+	if loc != nil && loc.Provider != "" && !cmd.Flags().Changed("provider") {
+		name = loc.Provider
+	}
+	return newProvider(name)
+}
+
+// newWeatherClient creates a weather client configured from the nws config section
+func newWeatherClient() (*types.WeatherClient, error) {
+	units, err := unitSystem()
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := apiTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	client := types.NewWeatherClient()
+	defaultBaseURL := client.BaseURL
+	client.BaseURL = viper.GetString("nws.base_url")
+	client.HTTPClient.Transport = metrics.InstrumentTransport(types.ProviderNWS, transport)
+	client.Units = units
+
+	client.RetryPolicy = retryPolicy("nws")
+
+	limiterOnce.Do(func() {
+		limiter = rateLimiter("nws")
+	})
+	client.Limiter = limiter
+
+	// Replayed responses never change, so they are neither retried nor rate limited, and the
+	// points cache is kept in memory so recordings include the /points lookups they depend on.
+	// Another API, such as fake-nws, also keeps it in memory so cached URLs of one API are never
+	// used with the other.
+	if viper.GetString("http.replay") != "" {
+		client.RetryPolicy = types.RetryPolicy{MaxAttempts: 1}
+		client.Limiter = nil
+	}
+	if viper.GetString("http.replay") != "" || viper.GetString("http.record") != "" || client.BaseURL != defaultBaseURL {
+		return client, nil
+	}
+
+	if !viper.GetBool("nws.points_cache.enabled") {
+		client.PointsCache = nil
+		return client, nil
+	}
+
+	cacheFile, err := homedir.Expand(viper.GetString("nws.points_cache.file"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid points cache file: %w", err)
+	}
+
+	pointsCache, err := types.NewPointsCache(viper.GetDuration("nws.points_cache.ttl"), cacheFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load points cache: %w", err)
+	}
+	client.PointsCache = pointsCache
+
+	return client, nil
+}
+
+// apiTransport returns the HTTP transport for weather API clients: one recording responses into
+// the --record directory, one replaying them from the --replay directory, or the default one
+func apiTransport() (http.RoundTripper, error) {
+	record, replay := viper.GetString("http.record"), viper.GetString("http.replay")
+
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("--record and --replay cannot be used together")
+	case replay != "":
+		dir, err := homedir.Expand(replay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay directory: %w", err)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("replay directory %s does not exist", dir)
+		}
+		return &types.ReplayTransport{Dir: dir}, nil
+	case record != "":
+		dir, err := homedir.Expand(record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid record directory: %w", err)
+		}
+		return &types.RecordingTransport{Dir: dir}, nil
+	}
+
+	return http.DefaultTransport, nil
+}
+
+// defaultPointsCacheFile returns the points cache location in the user cache directory,
+// or an empty path (in-memory cache only) if there is none
+func defaultPointsCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "weather", "points.json")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/collector"
+	"github.com/dwburke/weather/metrics"
+	"github.com/dwburke/weather/server"
+	"github.com/dwburke/weather/types"
+)
+
+func init() {
+	rootCmd.AddCommand(daemon)
+
+	daemon.Flags().String("metrics-listen", "", "Address to serve Prometheus metrics on, e.g. :9274 (default: disabled)")
+	viper.BindPFlag("daemon.metrics_listen", daemon.Flags().Lookup("metrics-listen"))
+
+	daemon.Flags().Duration("shutdown-timeout", collector.DefaultShutdownTimeout, "How long in-flight runs get to finish on shutdown before they are cancelled")
+	viper.BindPFlag("daemon.shutdown_timeout", daemon.Flags().Lookup("shutdown-timeout"))
+}
+
+var daemon = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run the scheduled data collector",
+	Long: `Run a long-lived collector that fetches and saves weather data on a schedule.
+
+Jobs are read from the daemon.jobs section of the config file:
+
+  daemon:
+    jobs:
+      - name: denver-hourly
+        location: denver      # or: latitude/longitude
+        type: hourly          # daily, hourly, grid, alerts or observations
+        provider: nws         # optional, defaults to the location's provider or nws
+        interval: 2h          # or: cron: "*/30 6-18 * * 1-5"
+        seasons:              # optional, inclusive MM-DD windows
+          - from: "04-01"
+            to: "09-30"
+
+Interval jobs run immediately on startup and then every interval; cron jobs run at their
+scheduled times. A job is skipped if its previous run is still in progress. SIGINT or
+SIGTERM stops scheduling and waits up to --shutdown-timeout (daemon.shutdown_timeout) for
+in-flight runs to finish, then cancels the runs that are left.
+
+With --metrics-listen, the daemon serves Prometheus metrics at /metrics: API request counts,
+errors and latency, and the runs, duration and last success time of each job.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var jobs []*collector.Job
+		if err := viper.UnmarshalKey("daemon.jobs", &jobs); err != nil {
+			return fmt.Errorf("failed to read daemon jobs from config: %w", err)
+		}
+
+		if len(jobs) == 0 {
+			return fmt.Errorf("no collection jobs configured. Add jobs to the daemon.jobs section of the config file")
+		}
+
+		// Resolve named locations to their stored coordinates
+		for _, job := range jobs {
+			if job.Location == "" {
+				continue
+			}
+
+			loc, err := lookupLocation(cmd.Context(), job.Location)
+			if err != nil {
+				return err
+			}
+			if loc, err = storedLocation(cmd.Context(), loc); err != nil {
+				return err
+			}
+
+			job.Latitude = loc.Latitude
+			job.Longitude = loc.Longitude
+			job.LocationID = loc.ID
+			if job.Provider == "" {
+				job.Provider = loc.Provider
+			}
+		}
+
+		logger := log.New(os.Stdout, "", log.LstdFlags)
+
+		// One provider of each kind is shared by every job using it
+		providers := make(map[string]types.Provider)
+		for _, job := range jobs {
+			if job.Provider == "" {
+				job.Provider = types.ProviderNWS
+			}
+			if _, ok := providers[job.Provider]; ok {
+				continue
+			}
+			provider, err := newProvider(job.Provider)
+			if err != nil {
+				return fmt.Errorf("job %q: %w", job.Name, err)
+			}
+			providers[job.Provider] = provider
+		}
+
+		scheduler, err := collector.NewScheduler(jobs, providers, logger)
+		if err != nil {
+			return err
+		}
+		scheduler.ShutdownTimeout = viper.GetDuration("daemon.shutdown_timeout")
+		scheduler.OnRun = func(job *collector.Job, duration time.Duration, err error) {
+			metrics.ObserveCollection(job.Name, job.Type, duration, err)
+		}
+
+		if listen := viper.GetString("daemon.metrics_listen"); listen != "" {
+			mux := http.NewServeMux()
+			mux.Handle("GET /metrics", metrics.Handler())
+
+			logger.Printf("serving metrics on %s/metrics", listen)
+			go func() {
+				if err := server.Serve(cmd.Context(), listen, mux); err != nil {
+					logger.Printf("metrics server failed: %v", err)
+				}
+			}()
+		}
+
+		logger.Printf("starting collector with %d jobs", len(jobs))
+		scheduler.Run(cmd.Context())
+		logger.Printf("collector stopped")
+
+		return nil
+	},
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/db"
+	"github.com/dwburke/weather/types"
+)
+
+var (
+	migrateUpTo   int
+	migrateDownTo int
+)
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbMigrateCmd, dbPruneCmd, dbBackfillCmd)
+	dbMigrateCmd.AddCommand(dbMigrateUpCmd, dbMigrateDownCmd, dbMigrateStatusCmd)
+
+	dbMigrateUpCmd.Flags().IntVar(&migrateUpTo, "to", 0, "Stop after applying this version (default: apply all pending migrations)")
+	dbMigrateDownCmd.Flags().IntVar(&migrateDownTo, "to", -1, "Revert every migration newer than this version, 0 for all (default: revert the latest one)")
+
+	dbPruneCmd.Flags().Bool("dry-run", false, "Report how many rows would be deleted without deleting them")
+	dbPruneCmd.Flags().Int("batch-size", types.DefaultPruneBatchSize, "Rows removed by one DELETE statement")
+	viper.BindPFlag("prune.dry_run", dbPruneCmd.Flags().Lookup("dry-run"))
+	viper.BindPFlag("prune.batch_size", dbPruneCmd.Flags().Lookup("batch-size"))
+
+	dbBackfillCmd.Flags().Bool("all", false, "Re-parse every forecast period, not only those missing numeric values")
+	dbBackfillCmd.Flags().Int("batch-size", types.DefaultBackfillBatchSize, "Rows updated in one transaction")
+	viper.BindPFlag("backfill.all", dbBackfillCmd.Flags().Lookup("all"))
+	viper.BindPFlag("backfill.batch_size", dbBackfillCmd.Flags().Lookup("batch-size"))
+
+	// Retention periods in days, 0 keeps the data forever
+	for _, key := range []string{"hourly_forecasts", "daily_forecasts", "downsample_hourly_after", "observations", "grid_forecasts", "alerts"} {
+		viper.SetDefault("retention."+key, 0)
+	}
+}
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the database",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Usage()
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply or revert schema migrations",
+	Long: `Apply or revert the schema migrations built into this binary. Applied migrations are recorded
+in the schema_migrations table.
+
+Every other command refuses to use the database until all migrations have been applied, so run
+'weather db migrate up' after installing or upgrading. Databases created by earlier versions,
+which created their tables on first save, are adopted by the first migration.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Usage()
+	},
+}
+
+var dbMigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		applied, err := db.GetDB().MigrateUp(migrateUpTo)
+		for _, m := range applied {
+			fmt.Printf("✅ Applied migration %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Printf("Database schema is up to date.\n")
+		}
+
+		return nil
+	},
+}
+
+var dbMigrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert applied migrations",
+	Long: `Revert the latest applied migration, or with --to every migration newer than the given version.
+Reverting a migration may drop tables or columns along with the data in them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := migrateDownTo
+		if target < 0 {
+			statuses, err := db.GetDB().MigrationStatus()
+			if err != nil {
+				return err
+			}
+
+			var applied []int
+			for _, status := range statuses {
+				if status.AppliedAt != nil {
+					applied = append(applied, status.Version)
+				}
+			}
+
+			target = 0
+			if len(applied) > 1 {
+				target = applied[len(applied)-2]
+			}
+		}
+
+		reverted, err := db.GetDB().MigrateDown(target)
+		for _, m := range reverted {
+			fmt.Printf("↩️  Reverted migration %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(reverted) == 0 {
+			fmt.Printf("No migrations to revert.\n")
+		}
+
+		return nil
+	},
+}
+
+var dbMigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which migrations have been applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statuses, err := db.GetDB().MigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		pending := 0
+		for _, status := range statuses {
+			if status.AppliedAt == nil {
+				pending++
+				fmt.Printf("⏳ %3d  %-60s pending\n", status.Version, status.Description)
+				continue
+			}
+			fmt.Printf("✅ %3d  %-60s applied %s\n", status.Version, status.Description, status.AppliedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+
+		if pending > 0 {
+			fmt.Printf("\n%d pending migration(s), run 'weather db migrate up' to apply them.\n", pending)
+		} else {
+			fmt.Printf("\nDatabase schema is up to date (version %d).\n", db.LatestVersion())
+		}
+
+		return nil
+	},
+}
+
+var dbPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete saved data older than the configured retention",
+	Long: `Delete saved data older than the retention periods in the retention config section, in days.
+A period of 0, the default, keeps that data forever:
+
+  retention:
+    hourly_forecasts: 90          # hourly forecast runs
+    daily_forecasts: 730          # daily forecast runs
+    downsample_hourly_after: 14   # keep only the first hourly run of each day after this
+    observations: 0               # keep forever
+    grid_forecasts: 30
+    alerts: 365                   # by the last time the alert was active
+
+Rows are deleted in batches (--batch-size) so tables are never locked for long, and an
+interrupted prune can simply be run again. Use --dry-run to see how many rows would go.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		policy := types.RetentionPolicy{
+			HourlyForecasts:       retentionDays("hourly_forecasts"),
+			DailyForecasts:        retentionDays("daily_forecasts"),
+			DownsampleHourlyAfter: retentionDays("downsample_hourly_after"),
+			Observations:          retentionDays("observations"),
+			GridForecasts:         retentionDays("grid_forecasts"),
+			Alerts:                retentionDays("alerts"),
+		}
+		if policy.IsEmpty() {
+			fmt.Printf("No retention periods configured, nothing to prune. See 'weather db prune --help'.\n")
+			return nil
+		}
+
+		dryRun := viper.GetBool("prune.dry_run")
+		stats, err := types.PruneContext(cmd.Context(), policy, types.PruneOptions{
+			DryRun:    dryRun,
+			BatchSize: viper.GetInt("prune.batch_size"),
+		})
+
+		verb := "deleted"
+		if dryRun {
+			verb = "would be deleted"
+		}
+		for _, s := range stats {
+			fmt.Printf("🧹 %s: %d %s rows %s\n", s.Step, s.Rows, s.Table, verb)
+		}
+
+		return err
+	},
+}
+
+var dbBackfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Fill in numeric temperature and wind values of saved forecast periods",
+	Long: `Parse the temperature and wind text of saved forecast periods into their numeric columns
+(temperature_c, temperature_f, wind_speed_min_kmh, wind_speed_max_kmh, wind_gust_kmh and
+wind_direction_deg). New forecasts are saved with these values; this fills them in for periods
+saved before they existed. Use --all to re-parse every period after the parsing has improved.
+
+Rows are updated in batches (--batch-size), each committed on its own, so an interrupted backfill
+can simply be run again.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		updated, err := types.BackfillForecastValuesContext(cmd.Context(), viper.GetBool("backfill.all"), viper.GetInt("backfill.batch_size"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✅ Backfilled numeric values of %d forecast periods\n", updated)
+		return nil
+	},
+}
+
+// retentionDays returns the retention.<key> setting in days as a duration
+func retentionDays(key string) time.Duration {
+	return time.Duration(viper.GetInt("retention."+key)) * 24 * time.Hour
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/metrics"
+	"github.com/dwburke/weather/server"
+)
+
+func init() {
+	rootCmd.AddCommand(exporter)
+
+	exporter.Flags().String("listen", ":9273", "Address to serve /metrics on")
+	exporter.Flags().Duration("max-lead", metrics.DefaultMaxLeadTime, "How far ahead forecast temperatures are published")
+
+	viper.BindPFlag("exporter.listen", exporter.Flags().Lookup("listen"))
+	viper.BindPFlag("exporter.max_lead", exporter.Flags().Lookup("max-lead"))
+}
+
+var exporter = &cobra.Command{
+	Use:   "exporter",
+	Short: "Serve saved weather data as Prometheus metrics",
+	Long: `Serve Prometheus metrics at /metrics, read from the database on every scrape:
+
+  weather_forecast_temperature_celsius{location,forecast,lead_hours}
+  weather_observed_temperature_celsius{location,station}   (also dewpoint, humidity, wind speed)
+  weather_observed_timestamp_seconds{location,station}
+  weather_active_alerts{severity}
+  weather_last_forecast_save_timestamp_seconds{location,forecast}
+  weather_last_observation_save_timestamp_seconds{location}
+  weather_exporter_scrape_error
+
+Metrics are published for every named location. The serve command publishes the same metrics
+at /metrics, and the daemon publishes its request and collection metrics with --metrics-listen.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen := viper.GetString("exporter.listen")
+
+		logger := log.New(os.Stdout, "", log.LstdFlags)
+
+		collector := metrics.NewWeatherCollector(locationStore{}, viper.GetDuration("exporter.max_lead"))
+		collector.Logger = logger
+		prometheus.MustRegister(collector)
+
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler())
+
+		logger.Printf("serving metrics on %s/metrics", listen)
+		if err := server.Serve(cmd.Context(), listen, mux); err != nil {
+			return fmt.Errorf("exporter failed: %w", err)
+		}
+		logger.Printf("exporter stopped")
+
+		return nil
+	},
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/nwstest"
+	"github.com/dwburke/weather/server"
+)
+
+func init() {
+	rootCmd.AddCommand(fakeNWS)
+
+	fakeNWS.Flags().String("listen", "127.0.0.1:8089", "Address to serve the fake API on")
+	fakeNWS.Flags().StringArray("fault", nil, "Fault to inject, e.g. 500,path=/forecast,count=2 (repeatable)")
+
+	viper.BindPFlag("fake_nws.listen", fakeNWS.Flags().Lookup("listen"))
+	viper.BindPFlag("fake_nws.faults", fakeNWS.Flags().Lookup("fault"))
+}
+
+var fakeNWS = &cobra.Command{
+	Use:   "fake-nws",
+	Short: "Serve a fake NWS API for offline development and testing",
+	Long: `Serve a fake National Weather Service API with realistic /points, forecast, hourly forecast,
+gridpoint, station, observation and alert responses for any coordinates. Point the other
+commands at it with the nws.base_url setting:
+
+  weather fake-nws &
+  weather forecast 39.7456 -97.0892   # with nws.base_url: http://127.0.0.1:8089
+
+Faults make matching requests fail. A fault is a kind followed by options:
+
+  500, 503                 Server errors
+  429                      Rate limiting, with retry-after=<duration> (default 1s)
+  404                      Not found, e.g. 404,path=/forecast for a grid point that moved
+  slow                     Normal responses after delay=<duration> (default 5s)
+  malformed                Truncated JSON
+
+  path=<text>              Only requests whose path contains text
+  count=<n>                Only the first n matching requests
+
+For example: weather fake-nws --fault 500,count=1 --fault 429,path=/alerts,retry-after=2s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen := viper.GetString("fake_nws.listen")
+
+		handler := nwstest.NewHandler()
+		handler.Log = os.Stderr
+		for _, spec := range viper.GetStringSlice("fake_nws.faults") {
+			fault, err := nwstest.ParseFault(spec)
+			if err != nil {
+				return err
+			}
+			handler.AddFault(fault)
+			fmt.Fprintf(os.Stderr, "⚠️  Injecting fault %s\n", fault)
+		}
+
+		fmt.Fprintf(os.Stderr, "🧪 Serving fake NWS API on http://%s (set nws.base_url: http://%s to use it)\n", listen, listen)
+		if err := server.Serve(cmd.Context(), listen, handler); err != nil {
+			return fmt.Errorf("fake NWS API failed: %w", err)
+		}
+
+		return nil
+	},
+}
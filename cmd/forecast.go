@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/dwburke/weather/output"
 	"github.com/dwburke/weather/types"
 )
 
@@ -15,6 +17,8 @@ var (
 	longitude       float64
 	saveToDb        bool
 	hourlyForecast  bool
+	forecastPlace   string
+	forecastSource  string
 )
 
 func init() {
@@ -23,20 +27,27 @@ func init() {
 	// Add flags for coordinates
 	forecast.Flags().Float64VarP(&latitude, "lat", "a", 0.0, "Latitude for weather forecast")
 	forecast.Flags().Float64VarP(&longitude, "lon", "o", 0.0, "Longitude for weather forecast")
+	forecast.Flags().StringVarP(&forecastPlace, "location", "l", "", "Named location for weather forecast (instead of --lat/--lon)")
 	forecast.Flags().IntVarP(&forecastPeriods, "periods", "p", 7, "Number of forecast periods to show (each day has day/night periods)")
 	forecast.Flags().BoolVarP(&saveToDb, "save", "s", false, "Save forecast data to database")
 	forecast.Flags().BoolVarP(&hourlyForecast, "hourly", "H", false, "Get hourly forecast (up to 156 hours) instead of daily periods")
+	addPlaceFlags(forecast, "forecast")
+	forecast.Flags().StringVar(&forecastSource, "provider", "", "Weather provider: nws or open-meteo (default: the location's provider, or nws)")
 
 	// Keep the old --days flag for backward compatibility but mark it as deprecated
 	forecast.Flags().IntVarP(&forecastPeriods, "days", "d", 7, "Number of forecast periods to show (deprecated: use --periods)")
 	forecast.Flags().MarkDeprecated("days", "use --periods instead. Each day typically has 2 periods (day/night)")
 
+	addOutputFlag(forecast, "forecast")
+
 	// Bind flags to viper for configuration file support
 	viper.BindPFlag("forecast.latitude", forecast.Flags().Lookup("lat"))
 	viper.BindPFlag("forecast.longitude", forecast.Flags().Lookup("lon"))
+	viper.BindPFlag("forecast.location", forecast.Flags().Lookup("location"))
 	viper.BindPFlag("forecast.periods", forecast.Flags().Lookup("periods"))
 	viper.BindPFlag("forecast.save", forecast.Flags().Lookup("save"))
 	viper.BindPFlag("forecast.hourly", forecast.Flags().Lookup("hourly"))
+	viper.BindPFlag("forecast.provider", forecast.Flags().Lookup("provider"))
 }
 
 var forecast = &cobra.Command{
@@ -48,7 +59,16 @@ Note: The NWS API returns forecast "periods" rather than full days.
 Each day typically has 2 periods: daytime and nighttime.
 So requesting 6 periods gives you approximately 3 full days of forecast.
 
-Use --hourly flag to get hourly forecasts (up to 156 hours / 6.5 days).`,
+Use --hourly flag to get hourly forecasts (up to 156 hours / 6.5 days).
+
+Use --place "Boulder, CO" or --zip 80301 to look up the coordinates by name instead; see
+'weather geocode'. The resolved place name is saved with the forecast.
+
+Forecasts come from NWS, which only covers US territory, unless --provider, the named
+location or the forecast.provider config setting selects open-meteo, which covers the world.
+
+Use --output to print json, ndjson, csv, tsv, yaml or an aligned table instead of text.
+Status messages are written to stderr so stdout only holds the forecast.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get coordinates from flags or config
 		lat := viper.GetFloat64("forecast.latitude")
@@ -69,17 +89,27 @@ Use --hourly flag to get hourly forecasts (up to 156 hours / 6.5 days).`,
 			}
 		}
 
-		// Check if coordinates are provided
-		if lat == 0.0 && lon == 0.0 {
-			return fmt.Errorf("latitude and longitude must be provided. Use --lat and --lon flags or set them in config file")
+		format, err := outputFormat("forecast")
+		if err != nil {
+			return err
+		}
+		
+		units, err := unitSystem()
+		if err != nil {
+			return err
 		}
 
-		if lat < -90 || lat > 90 {
-			return fmt.Errorf("latitude must be between -90 and 90 degrees")
+		// Geocode the place, or resolve the named location or check the coordinates
+		place, err := resolvePlace(cmd, "forecast")
+		if err != nil {
+			return err
 		}
 
-		if lon < -180 || lon > 180 {
-			return fmt.Errorf("longitude must be between -180 and 180 degrees")
+		var loc *types.Location
+		if place != nil {
+			lat, lon = place.Latitude, place.Longitude
+		} else if loc, lat, lon, err = resolveCoordinates(cmd, viper.GetString("forecast.location"), lat, lon); err != nil {
+			return err
 		}
 
 		forecastType := "daily periods"
@@ -87,35 +117,60 @@ Use --hourly flag to get hourly forecasts (up to 156 hours / 6.5 days).`,
 			forecastType = "hourly periods"
 		}
 
-		fmt.Printf("Getting weather forecast for coordinates: %.4f, %.4f\n", lat, lon)
-		fmt.Printf("Showing %d %s\n\n", periods, forecastType)
-
-		// Create weather client and get forecast
-		client := types.NewWeatherClient()
-		var forecast *types.ForecastResponse
-		var err error
-
-		if hourly {
-			forecast, err = client.GetHourlyForecastByCoordinates(lat, lon)
+		if loc != nil {
+			fmt.Fprintf(os.Stderr, "Getting weather forecast for %s: %.4f, %.4f\n", loc.Name, lat, lon)
+		} else if place != nil {
+			fmt.Fprintf(os.Stderr, "Getting weather forecast for %s: %.4f, %.4f\n", place.DisplayName(), lat, lon)
 		} else {
-			forecast, err = client.GetForecastByCoordinates(lat, lon)
+			fmt.Fprintf(os.Stderr, "Getting weather forecast for coordinates: %.4f, %.4f\n", lat, lon)
 		}
+		fmt.Fprintf(os.Stderr, "Showing %d %s\n\n", periods, forecastType)
 
+		// Create the weather provider and get forecast
+		provider, err := selectProvider(cmd, "forecast", loc)
+		if err != nil {
+			return err
+		}
+		if provider.Name() != types.ProviderNWS {
+			fmt.Fprintf(os.Stderr, "Using forecast provider: %s\n\n", provider.Name())
+		}
+
+		forecast, err := types.GetProviderForecast(cmd.Context(), provider, lat, lon, hourly)
 		if err != nil {
 			return fmt.Errorf("failed to get weather forecast: %w", err)
 		}
 
 		// Save to database if requested
 		if save {
-			fmt.Printf("Saving forecast data to database...\n")
-			if err := types.SaveForecastToDB(forecast, lat, lon, hourly); err != nil {
+			fmt.Fprintf(os.Stderr, "Saving forecast data to database...\n")
+			var result *types.SaveResult
+			if loc != nil {
+				if loc, err = storedLocation(cmd.Context(), loc); err != nil {
+					return err
+				}
+				result, err = types.SaveForecastForLocationContext(cmd.Context(), forecast, loc, hourly)
+			} else if place != nil {
+				result, err = types.SaveForecastRunContext(cmd.Context(), &types.ForecastRun{
+					Latitude:  lat,
+					Longitude: lon,
+					PlaceName: place.DisplayName(),
+					IsHourly:  hourly,
+				}, forecast)
+			} else {
+				result, err = types.SaveForecastToDBContext(cmd.Context(), forecast, lat, lon, hourly)
+			}
+			if err != nil {
 				return fmt.Errorf("failed to save forecast to database: %w", err)
 			}
-			fmt.Printf("✅ Forecast data saved successfully!\n\n")
+			fmt.Fprintf(os.Stderr, "📊 Database summary: %s\n", result)
+			fmt.Fprintf(os.Stderr, "✅ Forecast data saved successfully!\n\n")
 		}
 
 		// Display the forecast
-		fmt.Print(forecast.FormatForecast(periods))
+		if format != output.Text {
+			return writeRecords(format, periodRecords(forecast, lat, lon, periods, units))
+		}
+		fmt.Print(forecast.FormatForecast(periods, units))
 
 		return nil
 	},
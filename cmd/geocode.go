@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/geocode"
+	"github.com/dwburke/weather/metrics"
+	"github.com/dwburke/weather/output"
+)
+
+var geocodeLimit int
+
+func init() {
+	rootCmd.AddCommand(geocodeCmd)
+
+	geocodeCmd.Flags().IntVarP(&geocodeLimit, "limit", "n", 5, "Maximum number of places to show")
+	addOutputFlag(geocodeCmd, "geocode")
+
+	viper.SetDefault("geocoder.sources", []string{geocode.SourceGazetteer})
+	viper.SetDefault("geocoder.gazetteer_file", "")
+	viper.SetDefault("geocoder.open_meteo_url", geocode.NewOpenMeteoGeocoder().BaseURL)
+}
+
+var geocodeCmd = &cobra.Command{
+	Use:   "geocode QUERY...",
+	Short: "Look up the coordinates of a place or ZIP code",
+	Long: `Look up the coordinates of a place name, address or US ZIP code, e.g.
+
+  weather geocode Boulder, CO
+  weather geocode 80301
+
+Places are looked up in the sources listed in the geocoder.sources config setting, in order:
+"gazetteer" is the offline gazetteer (or the file given by geocoder.gazetteer_file),
+"open-meteo" is the online Open-Meteo geocoding API. Only the gazetteer is used by default, so
+lookups never need the network unless open-meteo is added.
+
+The gazetteer built into the binary is a sample, not the full Census data: state capitals, the
+largest US cities and a few dozen ZIP codes. ZIP codes it does not have fail with an error
+naming the gazetteer; set geocoder.gazetteer_file to a complete gazetteer to look them up.
+
+The same lookup is used by the --place and --zip flags of the forecast and history commands.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat("geocode")
+		if err != nil {
+			return err
+		}
+
+		geocoder, err := newGeocoder()
+		if err != nil {
+			return err
+		}
+
+		query := strings.Join(args, " ")
+		places, err := geocoder.Geocode(cmd.Context(), query, geocodeLimit)
+		if err != nil {
+			return geocodeError(query, err)
+		}
+
+		if format != output.Text {
+			return writeRecords(format, placeRecords(places))
+		}
+
+		for _, place := range places {
+			fmt.Printf("📍 %s: %.4f, %.4f", place.DisplayName(), place.Latitude, place.Longitude)
+			if place.Population > 0 {
+				fmt.Printf("  (population %d)", place.Population)
+			}
+			fmt.Printf("  [%s]\n", place.Source)
+		}
+
+		return nil
+	},
+}
+
+// newGeocoder creates the chain of geocoders listed in the geocoder.sources config setting
+func newGeocoder() (geocode.Geocoder, error) {
+	var chain geocode.Chain
+
+	for _, source := range viper.GetStringSlice("geocoder.sources") {
+		switch source {
+		case geocode.SourceGazetteer:
+			gazetteer, err := loadGazetteer()
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, gazetteer)
+		case geocode.SourceOpenMeteo:
+			geocoder := geocode.NewOpenMeteoGeocoder()
+			geocoder.BaseURL = viper.GetString("geocoder.open_meteo_url")
+			geocoder.HTTPClient.Transport = metrics.InstrumentTransport("open-meteo-geocoding", geocoder.HTTPClient.Transport)
+			chain = append(chain, geocoder)
+		default:
+			return nil, fmt.Errorf("unknown geocoder source %q, must be %s or %s", source, geocode.SourceGazetteer, geocode.SourceOpenMeteo)
+		}
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no geocoder sources configured in geocoder.sources")
+	}
+
+	return chain, nil
+}
+
+// loadGazetteer loads the gazetteer file from config, or the embedded gazetteer if none is set
+func loadGazetteer() (*geocode.Gazetteer, error) {
+	file := viper.GetString("geocoder.gazetteer_file")
+	if file == "" {
+		return geocode.DefaultGazetteer()
+	}
+
+	path, err := homedir.Expand(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gazetteer file: %w", err)
+	}
+	return geocode.LoadGazetteer(path)
+}
+
+// addPlaceFlags adds the --place and --zip flags to a command and binds them to <key>.place and <key>.zip
+func addPlaceFlags(cmd *cobra.Command, key string) {
+	cmd.Flags().String("place", "", `Place name or address to geocode, e.g. "Boulder, CO" (instead of --lat/--lon)`)
+	cmd.Flags().String("zip", "", "US ZIP code to geocode (instead of --lat/--lon)")
+	viper.BindPFlag(key+".place", cmd.Flags().Lookup("place"))
+	viper.BindPFlag(key+".zip", cmd.Flags().Lookup("zip"))
+}
+
+// resolvePlace geocodes the <key>.place or <key>.zip setting, returning nil if neither is set.
+// Only one of --location, --place and --zip may be given on the command line.
+func resolvePlace(cmd *cobra.Command, key string) (*geocode.Place, error) {
+	placeQuery := viper.GetString(key + ".place")
+	zip := viper.GetString(key + ".zip")
+	if placeQuery == "" && zip == "" {
+		return nil, nil
+	}
+
+	given := 0
+	for _, flag := range []string{"location", "place", "zip"} {
+		if cmd.Flags().Changed(flag) {
+			given++
+		}
+	}
+	if given > 1 || (placeQuery != "" && zip != "") {
+		return nil, fmt.Errorf("only one of --location, --place and --zip may be given")
+	}
+
+	query := placeQuery
+	if zip != "" {
+		if !geocode.IsZIP(zip) {
+			return nil, fmt.Errorf("invalid ZIP code %q", zip)
+		}
+		query = zip
+	}
+
+	geocoder, err := newGeocoder()
+	if err != nil {
+		return nil, err
+	}
+
+	place, err := geocode.Lookup(cmd.Context(), geocoder, query)
+	if err != nil {
+		return nil, geocodeError(query, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "📍 Resolved %q to %s: %.4f, %.4f\n", query, place.DisplayName(), place.Latitude, place.Longitude)
+
+	return place, nil
+}
+
+// geocodeError describes a failed lookup. A ZIP code that is not found names the gazetteer, as
+// the embedded one only has a sample of ZIP codes and they are never looked up online by default.
+func geocodeError(query string, err error) error {
+	if errors.Is(err, geocode.ErrNotFound) && geocode.IsZIP(query) {
+		file := viper.GetString("geocoder.gazetteer_file")
+		if file == "" {
+			return fmt.Errorf("ZIP code %s is not in the built-in gazetteer, which only has a sample of ZIP codes; set geocoder.gazetteer_file to a complete gazetteer: %w", strings.TrimSpace(query), err)
+		}
+		return fmt.Errorf("ZIP code %s is not in the gazetteer %s: %w", strings.TrimSpace(query), file, err)
+	}
+	return fmt.Errorf("failed to geocode %q: %w", query, err)
+}
+
+// placeRecords converts geocoding results to output records
+func placeRecords(places []geocode.Place) []output.Record {
+	records := make([]output.Record, 0, len(places))
+	for _, p := range places {
+		records = append(records, output.Record{
+			{Name: "name", Value: p.Name},
+			{Name: "state", Value: p.State},
+			{Name: "country", Value: p.Country},
+			{Name: "zip", Value: p.ZIP},
+			{Name: "latitude", Value: p.Latitude},
+			{Name: "longitude", Value: p.Longitude},
+			{Name: "population", Value: p.Population},
+			{Name: "source", Value: p.Source},
+		})
+	}
+	return records
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/types"
+)
+
+var (
+	gridLat   float64
+	gridLon   float64
+	gridPlace string
+	gridHours int
+	gridSave  bool
+)
+
+func init() {
+	rootCmd.AddCommand(grid)
+
+	// Add flags for coordinates
+	grid.Flags().Float64VarP(&gridLat, "lat", "a", 0.0, "Latitude for gridpoint forecast")
+	grid.Flags().Float64VarP(&gridLon, "lon", "o", 0.0, "Longitude for gridpoint forecast")
+	grid.Flags().StringVarP(&gridPlace, "location", "l", "", "Named location for gridpoint forecast (instead of --lat/--lon)")
+	grid.Flags().IntVar(&gridHours, "hours", 24, "Number of hours to show")
+	grid.Flags().BoolVarP(&gridSave, "save", "s", false, "Save the hourly gridpoint forecast to database")
+
+	// Bind flags to viper for configuration file support
+	viper.BindPFlag("grid.latitude", grid.Flags().Lookup("lat"))
+	viper.BindPFlag("grid.longitude", grid.Flags().Lookup("lon"))
+	viper.BindPFlag("grid.location", grid.Flags().Lookup("location"))
+	viper.BindPFlag("grid.hours", grid.Flags().Lookup("hours"))
+	viper.BindPFlag("grid.save", grid.Flags().Lookup("save"))
+}
+
+var grid = &cobra.Command{
+	Use:   "grid",
+	Short: "Get the raw numeric gridpoint forecast for a location",
+	Long: `Get the raw gridpoint forecast data from the National Weather Service for specified coordinates.
+
+Unlike the text forecast periods, gridpoint data is numeric: temperature, dewpoint, relative
+humidity, sky cover, wind, probability of precipitation and precipitation, snowfall and ice
+amounts. The NWS series are expanded into an hourly series; accumulated amounts are split
+evenly across the hours of the interval they were forecast for.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Get coordinates from flags or config, fallback to forecast config
+		lat := viper.GetFloat64("grid.latitude")
+		lon := viper.GetFloat64("grid.longitude")
+		hours := viper.GetInt("grid.hours")
+		save := viper.GetBool("grid.save")
+
+		locationName := viper.GetString("grid.location")
+
+		if locationName == "" && lat == 0.0 && lon == 0.0 {
+			locationName = viper.GetString("forecast.location")
+			lat = viper.GetFloat64("forecast.latitude")
+			lon = viper.GetFloat64("forecast.longitude")
+		}
+
+		units, err := unitSystem()
+		if err != nil {
+			return err
+		}
+
+		// Resolve the named location or check the coordinates
+		loc, lat, lon, err := resolveCoordinates(cmd, locationName, lat, lon)
+		if err != nil {
+			return err
+		}
+
+		if loc != nil {
+			fmt.Fprintf(os.Stderr, "Getting gridpoint forecast for %s: %.4f, %.4f\n\n", loc.Name, lat, lon)
+		} else {
+			fmt.Fprintf(os.Stderr, "Getting gridpoint forecast for coordinates: %.4f, %.4f\n\n", lat, lon)
+		}
+
+		client, err := newWeatherClient()
+		if err != nil {
+			return err
+		}
+		gridData, err := client.GetGridDataContext(cmd.Context(), lat, lon)
+		if err != nil {
+			return fmt.Errorf("failed to get gridpoint forecast: %w", err)
+		}
+
+		// Save to database if requested
+		if save {
+			fmt.Fprintf(os.Stderr, "Saving gridpoint forecast data to database...\n")
+			result, err := types.SaveGridDataToDBContext(cmd.Context(), gridData, lat, lon)
+			if err != nil {
+				return fmt.Errorf("failed to save gridpoint forecast to database: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "📊 Database summary: %s\n", result)
+			fmt.Fprintf(os.Stderr, "✅ Gridpoint forecast data saved successfully!\n\n")
+		}
+
+		hourly, err := gridData.Properties.Hourly()
+		if err != nil {
+			return fmt.Errorf("failed to expand gridpoint forecast: %w", err)
+		}
+
+		fmt.Print(types.FormatGridHours(hourly, hours, units))
+
+		return nil
+	},
+}
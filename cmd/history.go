@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	
+	"github.com/dwburke/weather/output"
 	"github.com/dwburke/weather/types"
 )
 
@@ -14,6 +18,8 @@ var (
 	historyLat     float64
 	historyLon     float64
 	historyHourly  bool
+	historyAt      string
+	historyPlace   string
 )
 
 func init() {
@@ -22,12 +28,17 @@ func init() {
 	// Add flags for coordinates
 	history.Flags().Float64VarP(&historyLat, "lat", "a", 0.0, "Latitude for weather history")
 	history.Flags().Float64VarP(&historyLon, "lon", "o", 0.0, "Longitude for weather history")
+	history.Flags().StringVarP(&historyPlace, "location", "l", "", "Named location for weather history (instead of --lat/--lon)")
 	history.Flags().IntVarP(&historyPeriods, "periods", "p", 7, "Number of historical forecast periods to show")
 	history.Flags().BoolVarP(&historyHourly, "hourly", "H", false, "Get hourly historical forecast instead of daily periods")
+	addPlaceFlags(history, "history")
+	history.Flags().StringVar(&historyAt, "at", "", "Show every saved forecast for the period covering this time (RFC3339, e.g. 2024-06-01T15:00:00-06:00)")
+	addOutputFlag(history, "history")
 	
 	// Bind flags to viper for configuration file support
 	viper.BindPFlag("history.latitude", history.Flags().Lookup("lat"))
 	viper.BindPFlag("history.longitude", history.Flags().Lookup("lon"))
+	viper.BindPFlag("history.location", history.Flags().Lookup("location"))
 	viper.BindPFlag("history.periods", history.Flags().Lookup("periods"))
 	viper.BindPFlag("history.hourly", history.Flags().Lookup("hourly"))
 }
@@ -35,7 +46,10 @@ func init() {
 var history = &cobra.Command{
 	Use:   "history",
 	Short: "Get historical weather forecast data from database",
-	Long:  `Retrieve previously saved weather forecast data from the database for specified coordinates`,
+	Long: `Retrieve previously saved weather forecast data from the database for specified coordinates.
+
+Use --output to print json, ndjson, csv, tsv, yaml or an aligned table instead of text.
+Status messages are written to stderr so stdout only holds the forecast periods.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get coordinates from flags or config, fallback to forecast config
 		lat := viper.GetFloat64("history.latitude")
@@ -43,8 +57,11 @@ var history = &cobra.Command{
 		periods := viper.GetInt("history.periods")
 		hourly := viper.GetBool("history.hourly")
 		
-		// Fallback to forecast coordinates if history coordinates not set
-		if lat == 0.0 && lon == 0.0 {
+		locationName := viper.GetString("history.location")
+		
+		// Fallback to forecast location or coordinates if history ones are not set
+		if locationName == "" && lat == 0.0 && lon == 0.0 {
+			locationName = viper.GetString("forecast.location")
 			lat = viper.GetFloat64("forecast.latitude")
 			lon = viper.GetFloat64("forecast.longitude")
 		}
@@ -57,17 +74,27 @@ var history = &cobra.Command{
 			}
 		}
 		
-		// Check if coordinates are provided
-		if lat == 0.0 && lon == 0.0 {
-			return fmt.Errorf("latitude and longitude must be provided. Use --lat and --lon flags or set them in config file")
+		format, err := outputFormat("history")
+		if err != nil {
+			return err
+		}
+		
+		units, err := unitSystem()
+		if err != nil {
+			return err
 		}
 		
-		if lat < -90 || lat > 90 {
-			return fmt.Errorf("latitude must be between -90 and 90 degrees")
+		// Geocode the place, or resolve the named location or check the coordinates
+		place, err := resolvePlace(cmd, "history")
+		if err != nil {
+			return err
 		}
 		
-		if lon < -180 || lon > 180 {
-			return fmt.Errorf("longitude must be between -180 and 180 degrees")
+		var loc *types.Location
+		if place != nil {
+			lat, lon = place.Latitude, place.Longitude
+		} else if loc, lat, lon, err = resolveCoordinates(cmd, locationName, lat, lon); err != nil {
+			return err
 		}
 		
 		forecastType := "daily"
@@ -75,43 +102,128 @@ var history = &cobra.Command{
 			forecastType = "hourly"
 		}
 		
-		fmt.Printf("Getting historical weather forecast for coordinates: %.4f, %.4f\n", lat, lon)
-		fmt.Printf("Showing %d historical %s forecast periods\n\n", periods, forecastType)
+		if historyAt != "" {
+			target, err := time.Parse(time.RFC3339, historyAt)
+			if err != nil {
+				return fmt.Errorf("invalid --at time %q: %w", historyAt, err)
+			}
+			return showForecastEvolution(cmd.Context(), loc, lat, lon, target, hourly, format, units)
+		}
+		
+		if loc != nil {
+			fmt.Fprintf(os.Stderr, "Getting historical weather forecast for %s: %.4f, %.4f\n", loc.Name, lat, lon)
+		} else if place != nil {
+			fmt.Fprintf(os.Stderr, "Getting historical weather forecast for %s: %.4f, %.4f\n", place.DisplayName(), lat, lon)
+		} else {
+			fmt.Fprintf(os.Stderr, "Getting historical weather forecast for coordinates: %.4f, %.4f\n", lat, lon)
+		}
+		fmt.Fprintf(os.Stderr, "Showing %d historical %s forecast periods\n\n", periods, forecastType)
 		
-		// Get historical forecast data from database
-		forecasts, err := types.GetLatestForecast(lat, lon, periods, hourly)
+		// Get historical forecast data from database, by location ID for stored locations
+		var forecasts []types.WeatherForecast
+		if loc != nil && loc.ID != 0 {
+			forecasts, err = types.GetLatestForecastForLocationContext(cmd.Context(), loc.ID, periods, hourly)
+		} else {
+			forecasts, err = types.GetLatestForecastContext(cmd.Context(), lat, lon, periods, hourly)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to get historical forecast: %w", err)
 		}
 		
 		if len(forecasts) == 0 {
-			fmt.Printf("No historical %s forecast data found for coordinates %.4f, %.4f\n", forecastType, lat, lon)
-			fmt.Printf("Use 'weather forecast --save' to save forecast data to the database first.\n")
+			fmt.Fprintf(os.Stderr, "No historical %s forecast data found for coordinates %.4f, %.4f\n", forecastType, lat, lon)
+			fmt.Fprintf(os.Stderr, "Use 'weather forecast --save' to save forecast data to the database first.\n")
+		}
+		
+		if format != output.Text {
+			return writeRecords(format, storedForecastRecords(forecasts, units))
+		}
+		
+		if len(forecasts) == 0 {
 			return nil
 		}
 		
 		// Display the historical forecast
-		fmt.Printf("Historical Weather Forecast (%s, saved: %s):\n", forecastType, forecasts[0].ForecastDate.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Historical Weather Forecast (%s, saved: %s):\n", forecastType, forecasts[0].ForecastDate.Local().Format("2006-01-02 15:04:05"))
 		fmt.Printf("=========================================================\n\n")
+		if forecasts[0].PlaceName != "" {
+			fmt.Printf("📍 %s\n\n", forecasts[0].PlaceName)
+		}
 		
 		for _, forecast := range forecasts {
 			fmt.Printf("📅 %s\n", forecast.Name)
-			fmt.Printf("🌡️  Temperature: %d°%s", forecast.Temperature, forecast.TemperatureUnit)
+			fmt.Printf("🌡️  Temperature: %s", units.FormatForecastTemperature(forecast.Temperature, forecast.TemperatureUnit))
 			if forecast.TemperatureTrend != "" {
 				fmt.Printf(" (%s)", forecast.TemperatureTrend)
 			}
 			fmt.Printf("\n")
-			fmt.Printf("💨 Wind: %s %s\n", forecast.WindSpeed, forecast.WindDirection)
+			fmt.Printf("💨 Wind: %s %s\n", units.FormatWindSpeed(forecast.WindSpeed), forecast.WindDirection)
+			if forecast.PrecipitationProbability != nil {
+				fmt.Printf("☔ Chance of precipitation: %.0f%%\n", *forecast.PrecipitationProbability)
+			}
+			if forecast.DewpointC != nil || forecast.RelativeHumidity != nil {
+				dewpoint := "n/a"
+				if forecast.DewpointC != nil {
+					dewpoint = units.FormatTemperature(*forecast.DewpointC, 1)
+				}
+				fmt.Printf("💧 Dewpoint: %s, humidity: %s\n", dewpoint, formatOptional(forecast.RelativeHumidity, "%.0f%%"))
+			}
 			fmt.Printf("☁️  Conditions: %s\n", forecast.ShortForecast)
 			if forecast.DetailedForecast != "" {
 				fmt.Printf("📝 Details: %s\n", forecast.DetailedForecast)
 			}
 			fmt.Printf("⏰ Period: %s to %s\n", 
-				forecast.StartTime.Format("Jan 2 3:04 PM"), 
-				forecast.EndTime.Format("Jan 2 3:04 PM"))
+				forecast.StartTime.Local().Format("Jan 2 3:04 PM"), 
+				forecast.EndTime.Local().Format("Jan 2 3:04 PM"))
 			fmt.Printf("\n")
 		}
 		
 		return nil
 	},
 }
+
+// showForecastEvolution prints how the forecast for a single period changed across saved runs,
+// by location ID for stored locations
+func showForecastEvolution(ctx context.Context, loc *types.Location, lat, lon float64, target time.Time, hourly bool, format string, units types.UnitSystem) error {
+	var forecasts []types.WeatherForecast
+	var err error
+	if loc != nil && loc.ID != 0 {
+		fmt.Fprintf(os.Stderr, "Getting forecast evolution for %s: %.4f, %.4f at %s\n\n", loc.Name, lat, lon, target.Format("Jan 2 3:04 PM"))
+		forecasts, err = types.GetForecastEvolutionForLocationContext(ctx, loc.ID, target, hourly)
+	} else {
+		fmt.Fprintf(os.Stderr, "Getting forecast evolution for coordinates: %.4f, %.4f at %s\n\n", lat, lon, target.Format("Jan 2 3:04 PM"))
+		forecasts, err = types.GetForecastEvolutionContext(ctx, lat, lon, target, hourly)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get forecast evolution: %w", err)
+	}
+
+	if len(forecasts) == 0 {
+		fmt.Fprintf(os.Stderr, "No saved forecasts cover %s for coordinates %.4f, %.4f\n", target.Format(time.RFC3339), lat, lon)
+	}
+
+	if format != output.Text {
+		return writeRecords(format, storedForecastRecords(forecasts, units))
+	}
+
+	for _, forecast := range forecasts {
+		fmt.Printf("🕒 Retrieved %s (%.0fh ahead)\n", forecast.ForecastDate.Local().Format("Jan 2 3:04 PM"), forecast.LeadTime().Hours())
+		fmt.Printf("🌡️  Temperature: %s\n", units.FormatForecastTemperature(forecast.Temperature, forecast.TemperatureUnit))
+		fmt.Printf("💨 Wind: %s %s\n", units.FormatWindSpeed(forecast.WindSpeed), forecast.WindDirection)
+		if forecast.PrecipitationProbability != nil {
+			fmt.Printf("☔ Chance of precipitation: %.0f%%\n", *forecast.PrecipitationProbability)
+		}
+		fmt.Printf("☁️  Conditions: %s\n", forecast.ShortForecast)
+		fmt.Printf("\n")
+	}
+
+	return nil
+}
+
+// formatOptional formats a value that may be missing, "n/a" when it is
+func formatOptional(value *float64, format string) string {
+	if value == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf(format, *value)
+}
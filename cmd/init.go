@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"text/template"
 
 	homedir "github.com/mitchellh/go-homedir"
@@ -16,9 +19,14 @@ func init() {
 	cobra.OnInitialize(initConfig)
 }
 
+// Execute runs the root command. Ctrl-C or SIGTERM cancels the command's context, aborting
+// in-flight API requests and database saves.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -70,7 +78,7 @@ func initConfig() {
 		// Find home directory.
 		home, err := homedir.Dir()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
@@ -91,23 +99,23 @@ func initConfig() {
 	}
 
 	if configFile == "" {
-		fmt.Println("No config file found")
+		fmt.Fprintln(os.Stderr, "No config file found")
 		return
 	}
 
 	// Process the config file as a template
 	processedConfig, err := processConfigTemplate(configFile)
 	if err != nil {
-		fmt.Printf("Error processing config template: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error processing config template: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Set viper to read from the processed content
 	viper.SetConfigType(filepath.Ext(configFile)[1:]) // Remove the dot from extension
 	if err := viper.ReadConfig(bytes.NewReader(processedConfig)); err != nil {
-		fmt.Printf("Error reading processed config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading processed config: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("Using config file:", configFile)
+	fmt.Fprintln(os.Stderr, "Using config file:", configFile)
 }
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/types"
+)
+
+var (
+	locationLat       float64
+	locationLon       float64
+	locationNoResolve bool
+	locationProvider  string
+)
+
+func init() {
+	rootCmd.AddCommand(location)
+	location.AddCommand(locationAdd, locationList, locationRemove, locationShow)
+
+	locationAdd.Flags().Float64VarP(&locationLat, "lat", "a", 0.0, "Latitude of the location")
+	locationAdd.Flags().Float64VarP(&locationLon, "lon", "o", 0.0, "Longitude of the location")
+	locationAdd.Flags().BoolVar(&locationNoResolve, "no-resolve", false, "Don't look up the NWS grid, zones and stations for the location")
+	locationAdd.Flags().StringVar(&locationProvider, "provider", types.ProviderNWS, "Forecast provider for the location: nws or open-meteo")
+}
+
+var location = &cobra.Command{
+	Use:   "location",
+	Short: "Manage named locations",
+	Long: `Manage named locations that can be used with --location on every command instead of --lat/--lon.
+
+Locations are stored in the locations table together with their resolved NWS metadata (time
+zone, grid point, forecast zone, county and nearest observation stations). Locations may also
+be defined in the config file, in which case they are added to the table, with their NWS
+metadata, the first time they are used:
+
+  locations:
+    denver:
+      latitude: 39.7391
+      longitude: -104.9847
+    toronto:
+      latitude: 43.6532
+      longitude: -79.3832
+      provider: open-meteo   # NWS only covers US territory
+
+NWS metadata is only looked up for locations using the nws provider.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Usage()
+	},
+}
+
+var locationAdd = &cobra.Command{
+	Use:   "add NAME",
+	Short: "Add or update a named location",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if err := validateCoordinates(locationLat, locationLon); err != nil {
+			return err
+		}
+
+		loc, err := types.FindLocationByName(name)
+		if err != nil {
+			return fmt.Errorf("failed to look up location: %w", err)
+		}
+		if loc == nil {
+			loc = &types.Location{Name: name}
+		}
+		loc.SetCoordinates(locationLat, locationLon)
+
+		if err := validateProvider(locationProvider); err != nil {
+			return err
+		}
+		loc.Provider = locationProvider
+		if loc.Provider == types.ProviderNWS {
+			loc.Provider = ""
+		}
+
+		if !locationNoResolve && loc.ProviderName() == types.ProviderNWS {
+			fmt.Printf("Resolving NWS metadata for coordinates: %.4f, %.4f\n", loc.Latitude, loc.Longitude)
+			client, err := newWeatherClient()
+			if err != nil {
+				return err
+			}
+			if err := client.ResolveLocationContext(cmd.Context(), loc); err != nil {
+				return fmt.Errorf("failed to resolve location: %w", err)
+			}
+		}
+
+		if err := loc.Save(); err != nil {
+			return fmt.Errorf("failed to save location: %w", err)
+		}
+
+		fmt.Printf("✅ Location saved!\n\n")
+		fmt.Print(loc.FormatLocation())
+
+		return nil
+	},
+}
+
+var locationList = &cobra.Command{
+	Use:   "list",
+	Short: "List named locations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stored, configOnly, err := listLocations()
+		if err != nil {
+			return err
+		}
+
+		for _, loc := range stored {
+			fmt.Printf("%-20s %9.4f %10.4f  %-20s %s\n", loc.Name, loc.Latitude, loc.Longitude, loc.TimeZone, loc.ForecastZone)
+		}
+
+		for _, loc := range configOnly {
+			fmt.Printf("%-20s %9.4f %10.4f  (config only)\n", loc.Name, loc.Latitude, loc.Longitude)
+		}
+
+		if len(stored) == 0 && len(configOnly) == 0 {
+			fmt.Printf("No locations found. Use 'weather location add NAME --lat LAT --lon LON' to add one.\n")
+		}
+
+		return nil
+	},
+}
+
+var locationRemove = &cobra.Command{
+	Use:   "remove NAME",
+	Short: "Remove a named location",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		loc, err := types.FindLocationByName(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to look up location: %w", err)
+		}
+		if loc == nil {
+			return fmt.Errorf("location %q not found", args[0])
+		}
+
+		if err := loc.Delete(); err != nil {
+			return fmt.Errorf("failed to remove location: %w", err)
+		}
+
+		fmt.Printf("✅ Location %s removed. Saved forecasts for it are kept.\n", loc.Name)
+		return nil
+	},
+}
+
+var locationShow = &cobra.Command{
+	Use:   "show NAME",
+	Short: "Show a named location",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		loc, err := lookupLocation(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(loc.FormatLocation())
+		return nil
+	},
+}
+
+// listLocations returns the stored locations and, sorted by name, those only defined in the config file
+func listLocations() ([]types.Location, []types.Location, error) {
+	stored, err := types.GetLocations()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get locations: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, loc := range stored {
+		seen[loc.Name] = true
+	}
+
+	var configNames []string
+	for name := range viper.GetStringMap("locations") {
+		if !seen[name] {
+			configNames = append(configNames, name)
+		}
+	}
+	sort.Strings(configNames)
+
+	configOnly := make([]types.Location, 0, len(configNames))
+	for _, name := range configNames {
+		loc, err := configLocation(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		configOnly = append(configOnly, *loc)
+	}
+
+	return stored, configOnly, nil
+}
+
+// lookupLocation finds a named location in the database, falling back to the locations config
+// section. A config location is added to the database with its NWS metadata the first time it
+// is looked up, if there is a database; when that fails it is used without metadata.
+func lookupLocation(ctx context.Context, name string) (*types.Location, error) {
+	loc, dbErr := types.FindLocationByName(name)
+	if dbErr == nil && loc != nil {
+		return loc, nil
+	}
+
+	if viper.IsSet("locations." + name) {
+		loc, err := configLocation(name)
+		if err != nil || dbErr != nil {
+			return loc, err
+		}
+
+		stored, err := storedLocation(ctx, loc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v; using location %q without NWS metadata\n", err, name)
+			return loc, nil
+		}
+		return stored, nil
+	}
+
+	if dbErr != nil {
+		return nil, fmt.Errorf("failed to look up location %q: %w", name, dbErr)
+	}
+
+	return nil, fmt.Errorf("location %q not found. Use 'weather location add' or define it in the locations section of the config file", name)
+}
+
+// configLocation reads a location from the locations config section
+func configLocation(name string) (*types.Location, error) {
+	loc := &types.Location{
+		Name:      name,
+		Latitude:  viper.GetFloat64("locations." + name + ".latitude"),
+		Longitude: viper.GetFloat64("locations." + name + ".longitude"),
+		Provider:  viper.GetString("locations." + name + ".provider"),
+	}
+
+	if err := validateCoordinates(loc.Latitude, loc.Longitude); err != nil {
+		return nil, fmt.Errorf("location %q in config: %w", name, err)
+	}
+
+	return loc, nil
+}
+
+// storedLocation makes sure a location has a database record, adding config-only locations
+// to the locations table so saved data can reference them by ID. Their NWS metadata is resolved
+// first, the same as by 'location add'.
+func storedLocation(ctx context.Context, loc *types.Location) (*types.Location, error) {
+	if loc.ID != 0 {
+		return loc, nil
+	}
+
+	if loc.ProviderName() == types.ProviderNWS && !loc.IsResolved() {
+		client, err := newWeatherClient()
+		if err != nil {
+			return nil, err
+		}
+		if err := client.ResolveLocationContext(ctx, loc); err != nil {
+			return nil, fmt.Errorf("failed to resolve location %q: %w", loc.Name, err)
+		}
+	}
+
+	if err := loc.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save location %q: %w", loc.Name, err)
+	}
+
+	return loc, nil
+}
+
+// resolveCoordinates returns the coordinates to use for a command, preferring a named location
+// over explicit coordinates. A location from the config file is ignored when --lat or --lon was
+// given on the command line. The location is nil when coordinates were given directly.
+func resolveCoordinates(cmd *cobra.Command, locationName string, lat, lon float64) (*types.Location, float64, float64, error) {
+	if !cmd.Flags().Changed("location") && (cmd.Flags().Changed("lat") || cmd.Flags().Changed("lon")) {
+		locationName = ""
+	}
+
+	if locationName != "" {
+		loc, err := lookupLocation(cmd.Context(), locationName)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return loc, loc.Latitude, loc.Longitude, nil
+	}
+
+	if lat == 0.0 && lon == 0.0 {
+		return nil, 0, 0, fmt.Errorf("latitude and longitude must be provided. Use --location or --lat and --lon flags or set them in config file")
+	}
+
+	if err := validateCoordinates(lat, lon); err != nil {
+		return nil, 0, 0, err
+	}
+
+	return nil, lat, lon, nil
+}
+
+func validateCoordinates(lat, lon float64) error {
+	if lat == 0.0 && lon == 0.0 {
+		return fmt.Errorf("latitude and longitude must be provided")
+	}
+
+	if lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90 degrees")
+	}
+
+	if lon < -180 || lon > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180 degrees")
+	}
+
+	return nil
+}
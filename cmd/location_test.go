@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/db"
+	"github.com/dwburke/weather/types"
+)
+
+// TestMain runs the tests against a migrated in-memory sqlite database
+func TestMain(m *testing.M) {
+	viper.Set("db.driver", db.DriverSQLite)
+	viper.Set("db.path", ":memory:")
+
+	if _, err := db.GetDB().MigrateUp(0); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate test database: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// coordinatesCommand returns a command with the --location, --lat and --lon flags, parsed from args
+func coordinatesCommand(t *testing.T, args ...string) (*cobra.Command, string, float64, float64) {
+	t.Helper()
+
+	var name string
+	var lat, lon float64
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVarP(&name, "location", "l", "", "")
+	cmd.Flags().Float64VarP(&lat, "lat", "a", 0, "")
+	cmd.Flags().Float64VarP(&lon, "lon", "o", 0, "")
+	cmd.SetContext(context.Background())
+
+	if err := cmd.Flags().Parse(args); err != nil {
+		t.Fatalf("Parse(%v) error = %v", args, err)
+	}
+	return cmd, name, lat, lon
+}
+
+func TestResolveCoordinates(t *testing.T) {
+	stored := &types.Location{Name: "cmd-stored", Latitude: 41.1234, Longitude: -96.5678, Provider: types.ProviderOpenMeteo}
+	if err := stored.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("locations.cmd-config.latitude", 43.6532)
+	viper.Set("locations.cmd-config.longitude", -79.3832)
+	viper.Set("locations.cmd-config.provider", types.ProviderOpenMeteo)
+	viper.Set("locations.cmd-bad.latitude", 95.0)
+	viper.Set("locations.cmd-bad.longitude", -79.0)
+	t.Cleanup(func() { viper.Set("locations", nil) })
+
+	tests := []struct {
+		name    string
+		args    []string
+		config  string // location name from the config file, as if it were the --location default
+		wantLoc string
+		wantLat float64
+		wantLon float64
+		wantErr string
+	}{
+		{name: "coordinates", args: []string{"--lat", "39.5", "--lon", "-105.25"}, wantLat: 39.5, wantLon: -105.25},
+		{name: "stored location", args: []string{"--location", "cmd-stored"}, wantLoc: "cmd-stored", wantLat: 41.1234, wantLon: -96.5678},
+		{name: "config location", args: []string{"-l", "cmd-config"}, wantLoc: "cmd-config", wantLat: 43.6532, wantLon: -79.3832},
+		{name: "default location", config: "cmd-stored", wantLoc: "cmd-stored", wantLat: 41.1234, wantLon: -96.5678},
+		{name: "lat overrides default location", args: []string{"--lat", "39.5", "--lon", "-105.25"}, config: "cmd-stored", wantLat: 39.5, wantLon: -105.25},
+		{name: "location wins over lat", args: []string{"--location", "cmd-stored", "--lat", "39.5"}, wantLoc: "cmd-stored", wantLat: 41.1234, wantLon: -96.5678},
+		{name: "unknown location", args: []string{"--location", "cmd-missing"}, wantErr: `location "cmd-missing" not found`},
+		{name: "invalid config location", args: []string{"--location", "cmd-bad"}, wantErr: `location "cmd-bad" in config: latitude must be between`},
+		{name: "no coordinates", wantErr: "latitude and longitude must be provided"},
+		{name: "invalid latitude", args: []string{"--lat", "91", "--lon", "10"}, wantErr: "latitude must be between -90 and 90 degrees"},
+		{name: "invalid longitude", args: []string{"--lat", "10", "--lon", "-181"}, wantErr: "longitude must be between -180 and 180 degrees"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, name, lat, lon := coordinatesCommand(t, tt.args...)
+			if !cmd.Flags().Changed("location") {
+				name = tt.config
+			}
+
+			loc, gotLat, gotLon, err := resolveCoordinates(cmd, name, lat, lon)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveCoordinates() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveCoordinates() error = %v", err)
+			}
+
+			if gotLat != tt.wantLat || gotLon != tt.wantLon {
+				t.Errorf("resolveCoordinates() = %v, %v, want %v, %v", gotLat, gotLon, tt.wantLat, tt.wantLon)
+			}
+			switch {
+			case tt.wantLoc == "" && loc != nil:
+				t.Errorf("resolveCoordinates() location = %q, want none", loc.Name)
+			case tt.wantLoc != "" && (loc == nil || loc.Name != tt.wantLoc):
+				t.Errorf("resolveCoordinates() location = %+v, want %q", loc, tt.wantLoc)
+			}
+		})
+	}
+}
+
+func TestLookupConfigLocationIsStored(t *testing.T) {
+	viper.Set("locations.cmd-toronto.latitude", 43.7001)
+	viper.Set("locations.cmd-toronto.longitude", -79.4163)
+	viper.Set("locations.cmd-toronto.provider", types.ProviderOpenMeteo)
+	t.Cleanup(func() { viper.Set("locations", nil) })
+
+	loc, err := lookupLocation(context.Background(), "cmd-toronto")
+	if err != nil {
+		t.Fatalf("lookupLocation() error = %v", err)
+	}
+	if loc.ID == 0 {
+		t.Fatal("config location was not added to the database")
+	}
+
+	found, err := types.FindLocationByName("cmd-toronto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || found.ID != loc.ID || found.Provider != types.ProviderOpenMeteo {
+		t.Errorf("stored location = %+v, want ID %d with provider %s", found, loc.ID, types.ProviderOpenMeteo)
+	}
+
+	// The stored record is used from then on, even if the config changes
+	viper.Set("locations.cmd-toronto.latitude", 10.0)
+	again, err := lookupLocation(context.Background(), "cmd-toronto")
+	if err != nil {
+		t.Fatalf("second lookupLocation() error = %v", err)
+	}
+	if again.ID != loc.ID || again.Latitude != 43.7001 {
+		t.Errorf("second lookup = %+v, want the stored record", again)
+	}
+}
+
+func TestLocationAddMovesLocation(t *testing.T) {
+	loc := &types.Location{
+		Name:         "cmd-moved",
+		Latitude:     39.7456,
+		Longitude:    -97.0892,
+		TimeZone:     "America/Chicago",
+		GridID:       "TOP",
+		GridX:        32,
+		GridY:        81,
+		ForecastZone: "KSZ009",
+		County:       "KSC201",
+		Stations:     "KMYZ",
+	}
+	if err := loc.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	locationLat, locationLon = 39.7391, -104.9847
+	locationNoResolve = true
+	locationProvider = types.ProviderNWS
+	t.Cleanup(func() {
+		locationLat, locationLon = 0, 0
+		locationNoResolve = false
+	})
+
+	if err := locationAdd.RunE(locationAdd, []string{"cmd-moved"}); err != nil {
+		t.Fatalf("location add error = %v", err)
+	}
+
+	moved, err := types.FindLocationByName("cmd-moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.ID != loc.ID {
+		t.Errorf("location ID = %d, want the existing record %d", moved.ID, loc.ID)
+	}
+	if moved.Latitude != 39.7391 || moved.Longitude != -104.9847 {
+		t.Errorf("location coordinates = %v, %v, want the new coordinates", moved.Latitude, moved.Longitude)
+	}
+	if moved.IsResolved() || moved.TimeZone != "" || moved.ForecastZone != "" || moved.County != "" || moved.Stations != "" {
+		t.Errorf("moved location kept the old NWS metadata: %+v", moved)
+	}
+}
+
+func TestConfigLocation(t *testing.T) {
+	viper.Set("locations.cmd-denver.latitude", 39.7391)
+	viper.Set("locations.cmd-denver.longitude", -104.9847)
+	viper.Set("locations.cmd-nowhere.provider", types.ProviderOpenMeteo)
+	t.Cleanup(func() { viper.Set("locations", nil) })
+
+	loc, err := configLocation("cmd-denver")
+	if err != nil {
+		t.Fatalf("configLocation() error = %v", err)
+	}
+	if loc.Latitude != 39.7391 || loc.Longitude != -104.9847 || loc.ProviderName() != types.ProviderNWS {
+		t.Errorf("configLocation() = %+v, want Denver using NWS", loc)
+	}
+
+	if _, err := configLocation("cmd-nowhere"); err == nil {
+		t.Error("configLocation() without coordinates error = nil")
+	}
+}
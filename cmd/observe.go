@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/types"
+)
+
+var (
+	observeLat     float64
+	observeLon     float64
+	observePlace   string
+	observeStation string
+	observeHours   int
+	observeSave    bool
+)
+
+func init() {
+	rootCmd.AddCommand(observe)
+
+	// Add flags for coordinates
+	observe.Flags().Float64VarP(&observeLat, "lat", "a", 0.0, "Latitude to get observations for")
+	observe.Flags().Float64VarP(&observeLon, "lon", "o", 0.0, "Longitude to get observations for")
+	observe.Flags().StringVarP(&observePlace, "location", "l", "", "Named location to get observations for (instead of --lat/--lon)")
+	observe.Flags().StringVar(&observeStation, "station", "", "Observation station ID (default: nearest station to the coordinates)")
+	observe.Flags().IntVar(&observeHours, "hours", 0, "Number of hours of observation history to fetch (default: latest observation only)")
+	observe.Flags().BoolVarP(&observeSave, "save", "s", false, "Save observations to database")
+
+	// Bind flags to viper for configuration file support
+	viper.BindPFlag("observe.latitude", observe.Flags().Lookup("lat"))
+	viper.BindPFlag("observe.longitude", observe.Flags().Lookup("lon"))
+	viper.BindPFlag("observe.location", observe.Flags().Lookup("location"))
+	viper.BindPFlag("observe.station", observe.Flags().Lookup("station"))
+	viper.BindPFlag("observe.hours", observe.Flags().Lookup("hours"))
+	viper.BindPFlag("observe.save", observe.Flags().Lookup("save"))
+}
+
+var observe = &cobra.Command{
+	Use:   "observe",
+	Short: "Get observed weather conditions for a location",
+	Long: `Get measured conditions from the National Weather Service observation station nearest to
+the specified coordinates, or from a specific station with --station.
+
+Observations are saved against the given coordinates, and the location with --location, so
+they can be compared with saved forecasts using 'weather verify'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Get coordinates from flags or config, fallback to forecast config
+		lat := viper.GetFloat64("observe.latitude")
+		lon := viper.GetFloat64("observe.longitude")
+		stationID := viper.GetString("observe.station")
+		hours := viper.GetInt("observe.hours")
+		save := viper.GetBool("observe.save")
+
+		locationName := viper.GetString("observe.location")
+
+		if locationName == "" && lat == 0.0 && lon == 0.0 {
+			locationName = viper.GetString("forecast.location")
+			lat = viper.GetFloat64("forecast.latitude")
+			lon = viper.GetFloat64("forecast.longitude")
+		}
+
+		units, err := unitSystem()
+		if err != nil {
+			return err
+		}
+
+		// Resolve the named location or check the coordinates
+		loc, lat, lon, err := resolveCoordinates(cmd, locationName, lat, lon)
+		if err != nil {
+			return err
+		}
+
+		client, err := newWeatherClient()
+		if err != nil {
+			return err
+		}
+
+		// Prefer the nearest station resolved for a named location
+		if stationID == "" && loc != nil && len(loc.StationIDs()) > 0 {
+			stationID = loc.StationIDs()[0]
+		}
+
+		if stationID == "" {
+			stations, err := client.GetObservationStationsContext(cmd.Context(), lat, lon)
+			if err != nil {
+				return fmt.Errorf("failed to find observation stations: %w", err)
+			}
+			if len(stations) == 0 {
+				return fmt.Errorf("no observation stations found for coordinates %.4f, %.4f", lat, lon)
+			}
+			stationID = stations[0].Properties.StationIdentifier
+		}
+
+		fmt.Fprintf(os.Stderr, "Getting observations from station %s for coordinates: %.4f, %.4f\n\n", stationID, lat, lon)
+
+		var observations []types.ObservationResponse
+
+		if hours > 0 {
+			collection, err := client.GetStationObservationsContext(cmd.Context(), stationID, time.Now().Add(-time.Duration(hours)*time.Hour), time.Time{})
+			if err != nil {
+				return fmt.Errorf("failed to get observations: %w", err)
+			}
+			observations = collection.Features
+		} else {
+			observation, err := client.GetLatestObservationContext(cmd.Context(), stationID)
+			if err != nil {
+				return fmt.Errorf("failed to get latest observation: %w", err)
+			}
+			observations = append(observations, *observation)
+		}
+
+		// Save to database if requested
+		if save {
+			fmt.Fprintf(os.Stderr, "Saving observation data to database...\n")
+			// Stored locations keep their ID so verify can match the observations by location
+			var result *types.ObservationSaveResult
+			if loc != nil && loc.ID != 0 {
+				result, err = types.SaveObservationsForLocationContext(cmd.Context(), observations, loc)
+			} else {
+				result, err = types.SaveObservationsToDBContext(cmd.Context(), observations, lat, lon)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to save observations to database: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "📊 Database summary: %s\n", result)
+			fmt.Fprintf(os.Stderr, "✅ Observation data saved successfully!\n\n")
+		}
+
+		for _, observation := range observations {
+			fmt.Print(observation.FormatObservation(units))
+			fmt.Printf("\n")
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/output"
+	"github.com/dwburke/weather/types"
+)
+
+// addOutputFlag adds the --output flag to a command and binds it to <key>.output
+func addOutputFlag(cmd *cobra.Command, key string) {
+	cmd.Flags().String("output", output.Text, fmt.Sprintf("Output format: %s, %s", output.Text, strings.Join(output.Names(), ", ")))
+	viper.BindPFlag(key+".output", cmd.Flags().Lookup("output"))
+}
+
+// outputFormat returns the validated output format for a command
+func outputFormat(key string) (string, error) {
+	format := viper.GetString(key + ".output")
+	if format == "" {
+		format = output.Text
+	}
+	if err := output.Validate(format); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+// unitSystem returns the validated unit system from the units setting
+func unitSystem() (types.UnitSystem, error) {
+	return types.ParseUnitSystem(viper.GetString("units"))
+}
+
+// writeRecords writes records to stdout in the given machine-readable format
+func writeRecords(format string, records []output.Record) error {
+	return output.Write(os.Stdout, format, records)
+}
+
+// periodRecords converts the first periods of a forecast response to output records, with
+// temperatures and wind speeds converted to the unit system
+func periodRecords(forecast *types.ForecastResponse, lat, lon float64, periods int, units types.UnitSystem) []output.Record {
+	all := forecast.Properties.Periods
+	if periods <= 0 || periods > len(all) {
+		periods = len(all)
+	}
+
+	records := make([]output.Record, 0, periods)
+	for _, p := range all[:periods] {
+		var temperatureC, windMin, windMax, dewpointC *float64
+		if celsius, _, ok := types.ConvertTemperature(float64(p.Temperature), p.TemperatureUnit); ok {
+			temperatureC = &celsius
+		}
+		if min, max, ok := types.ParseWindSpeed(p.WindSpeed); ok {
+			windMin, windMax = &min, &max
+		}
+		if p.Dewpoint.Value != nil {
+			if celsius, _, ok := types.ConvertTemperature(*p.Dewpoint.Value, strings.TrimPrefix(p.Dewpoint.UnitCode, "wmoUnit:deg")); ok {
+				dewpointC = &celsius
+			}
+		}
+
+		record := output.Record{
+			{Name: "provider", Value: forecast.Provider},
+			{Name: "latitude", Value: lat},
+			{Name: "longitude", Value: lon},
+			{Name: "period_number", Value: p.Number},
+			{Name: "name", Value: p.Name},
+			{Name: "start_time", Value: p.StartTime},
+			{Name: "end_time", Value: p.EndTime},
+			{Name: "is_daytime", Value: p.IsDaytime},
+		}
+		record = append(record, convertedFields(units, temperatureC, p.TemperatureTrend, p.WindSpeed, windMin, windMax, p.WindDirection)...)
+		record = append(record, output.Record{
+			{Name: "precipitation_probability", Value: p.ProbabilityOfPrecipitation.Value},
+			{Name: "dewpoint", Value: convertValue(dewpointC, units.Temperature)},
+			{Name: "relative_humidity", Value: p.RelativeHumidity.Value},
+			{Name: "short_forecast", Value: p.ShortForecast},
+			{Name: "detailed_forecast", Value: p.DetailedForecast},
+		}...)
+		records = append(records, record)
+	}
+	return records
+}
+
+// storedForecastRecords converts saved forecast periods to output records, including the run
+// they were saved in and when it was retrieved, with temperatures and wind speeds converted to
+// the unit system
+func storedForecastRecords(forecasts []types.WeatherForecast, units types.UnitSystem) []output.Record {
+	records := make([]output.Record, 0, len(forecasts))
+	for _, f := range forecasts {
+		temperatureC := f.TemperatureC()
+
+		record := output.Record{
+			{Name: "run_id", Value: f.RunID},
+			{Name: "forecast_date", Value: f.ForecastDate},
+			{Name: "provider", Value: f.Provider},
+			{Name: "place_name", Value: f.PlaceName},
+			{Name: "latitude", Value: f.Latitude},
+			{Name: "longitude", Value: f.Longitude},
+			{Name: "period_number", Value: f.PeriodNumber},
+			{Name: "name", Value: f.Name},
+			{Name: "start_time", Value: f.StartTime},
+			{Name: "end_time", Value: f.EndTime},
+			{Name: "is_daytime", Value: f.IsDaytime},
+		}
+		record = append(record, convertedFields(units, &temperatureC, f.TemperatureTrend, f.WindSpeed, f.WindSpeedMinKmh, f.WindSpeedMaxKmh, f.WindDirection)...)
+		record = append(record, output.Record{
+			{Name: "precipitation_probability", Value: f.PrecipitationProbability},
+			{Name: "dewpoint", Value: convertValue(f.DewpointC, units.Temperature)},
+			{Name: "relative_humidity", Value: f.RelativeHumidity},
+			{Name: "short_forecast", Value: f.ShortForecast},
+			{Name: "detailed_forecast", Value: f.DetailedForecast},
+		}...)
+		records = append(records, record)
+	}
+	return records
+}
+
+// convertedFields returns the temperature and wind fields of a forecast period in the unit
+// system, with a unit column for each. The dewpoint shares the temperature unit.
+func convertedFields(units types.UnitSystem, temperatureC *float64, trend, windSpeed string, windMinKmh, windMaxKmh *float64, windDirection string) output.Record {
+	return output.Record{
+		{Name: "temperature", Value: convertValue(temperatureC, units.Temperature)},
+		{Name: "temperature_unit", Value: strings.TrimPrefix(units.TemperatureUnit(), "°")},
+		{Name: "temperature_trend", Value: trend},
+		{Name: "wind_speed", Value: units.FormatWindSpeed(windSpeed)},
+		{Name: "wind_speed_min", Value: convertValue(windMinKmh, units.Speed)},
+		{Name: "wind_speed_max", Value: convertValue(windMaxKmh, units.Speed)},
+		{Name: "wind_speed_unit", Value: units.SpeedUnit()},
+		{Name: "wind_direction", Value: windDirection},
+	}
+}
+
+// convertValue converts an optional value and rounds it to one decimal
+func convertValue(value *float64, convert func(float64) float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	converted := math.Round(convert(*value)*10) / 10
+	return &converted
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dwburke/weather/output"
+	"github.com/dwburke/weather/types"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// fieldValues returns the values of the named fields of a record, dereferencing optional numbers
+func fieldValues(t *testing.T, record output.Record, names ...string) []interface{} {
+	t.Helper()
+
+	values := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, f := range record {
+			if f.Name != name {
+				continue
+			}
+			found = true
+			value := f.Value
+			if v, ok := value.(*float64); ok {
+				value = nil
+				if v != nil {
+					value = *v
+				}
+			}
+			values = append(values, value)
+		}
+		if !found {
+			t.Fatalf("record has no %s field", name)
+		}
+	}
+	return values
+}
+
+var convertedNames = []string{"temperature", "temperature_unit", "wind_speed", "wind_speed_min", "wind_speed_max", "wind_speed_unit", "dewpoint"}
+
+func TestPeriodRecordsUnits(t *testing.T) {
+	forecast := &types.ForecastResponse{Provider: types.ProviderNWS}
+	forecast.Properties.Periods = []types.ForecastPeriod{
+		{
+			Number:          1,
+			Name:            "Today",
+			Temperature:     50,
+			TemperatureUnit: "F",
+			WindSpeed:       "10 to 15 mph",
+			Dewpoint:        types.QuantitativeValue{Value: floatPtr(5), UnitCode: "wmoUnit:degC"},
+		},
+		{Number: 2, Name: "Tonight", Temperature: 41, TemperatureUnit: "F", WindSpeed: "Calm"},
+	}
+
+	tests := []struct {
+		units types.UnitSystem
+		want  []interface{}
+	}{
+		{units: types.UnitsUS, want: []interface{}{50.0, "F", "10 to 15 mph", 10.0, 15.0, "mph", 41.0}},
+		{units: types.UnitsMetric, want: []interface{}{10.0, "C", "16 to 24 km/h", 16.1, 24.1, "km/h", 5.0}},
+		{units: types.UnitsSI, want: []interface{}{10.0, "C", "4 to 7 m/s", 4.5, 6.7, "m/s", 5.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.units), func(t *testing.T) {
+			records := periodRecords(forecast, 39.7456, -97.0892, 1, tt.units)
+			if len(records) != 1 {
+				t.Fatalf("periodRecords() returned %d records, want the 1 requested", len(records))
+			}
+
+			got := fieldValues(t, records[0], convertedNames...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("converted fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if records := periodRecords(forecast, 39.7456, -97.0892, 0, types.UnitsUS); len(records) != 2 {
+		t.Errorf("periodRecords() with no limit returned %d records, want 2", len(records))
+	}
+}
+
+func TestStoredForecastRecordsUnits(t *testing.T) {
+	forecasts := []types.WeatherForecast{
+		{
+			RunID:           7,
+			ForecastDate:    time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			Temperature:     68,
+			TemperatureUnit: "F",
+			WindSpeed:       "5 mph",
+			WindSpeedMinKmh: floatPtr(8.04672),
+			WindSpeedMaxKmh: floatPtr(8.04672),
+		},
+	}
+
+	tests := []struct {
+		units types.UnitSystem
+		want  []interface{}
+	}{
+		{units: types.UnitsUS, want: []interface{}{68.0, "F", "5 mph", 5.0, 5.0, "mph", nil}},
+		{units: types.UnitsMetric, want: []interface{}{20.0, "C", "8 km/h", 8.0, 8.0, "km/h", nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.units), func(t *testing.T) {
+			records := storedForecastRecords(forecasts, tt.units)
+			if len(records) != 1 {
+				t.Fatalf("storedForecastRecords() returned %d records, want 1", len(records))
+			}
+
+			got := fieldValues(t, records[0], convertedNames...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("converted fields = %v, want %v", got, tt.want)
+			}
+			if run := fieldValues(t, records[0], "run_id"); run[0] != uint(7) {
+				t.Errorf("run_id = %v, want 7", run[0])
+			}
+		})
+	}
+}
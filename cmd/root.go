@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/types"
 )
 
 var cfgFile string
@@ -10,6 +13,12 @@ var verbose bool
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.entity.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "verbose output")
+	rootCmd.PersistentFlags().String("units", string(types.UnitsUS), "Units to request forecasts in and show values in: us, si or metric")
+	viper.BindPFlag("units", rootCmd.PersistentFlags().Lookup("units"))
+	rootCmd.PersistentFlags().String("record", "", "Record every weather API response into this fixtures directory")
+	rootCmd.PersistentFlags().String("replay", "", "Serve weather API responses from this fixtures directory instead of the network")
+	viper.BindPFlag("http.record", rootCmd.PersistentFlags().Lookup("record"))
+	viper.BindPFlag("http.replay", rootCmd.PersistentFlags().Lookup("replay"))
 }
 
 var rootCmd = &cobra.Command{
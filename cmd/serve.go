@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/metrics"
+	"github.com/dwburke/weather/server"
+	"github.com/dwburke/weather/types"
+)
+
+func init() {
+	rootCmd.AddCommand(serve)
+
+	serve.Flags().String("listen", "127.0.0.1:8080", "Address to listen on, e.g. :8080 for every interface")
+	serve.Flags().Bool("live", false, "Allow live=true requests, fetched from the weather providers")
+	serve.Flags().Duration("cache-ttl", 5*time.Minute, "How long live responses are cached")
+
+	viper.BindPFlag("serve.listen", serve.Flags().Lookup("listen"))
+	viper.BindPFlag("serve.live", serve.Flags().Lookup("live"))
+	viper.BindPFlag("serve.cache_ttl", serve.Flags().Lookup("cache-ttl"))
+}
+
+var serve = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve saved weather data over a JSON HTTP API",
+	Long: `Run a read-only HTTP server that serves the data saved by the other commands as JSON:
+
+  GET /v1/forecast?location=denver             latest saved forecast run
+  GET /v1/forecast?lat=39.74&lon=-104.98&hourly=true&periods=24
+  GET /v1/history?location=denver&from=2024-06-01&to=2024-06-08
+  GET /v1/locations                            named locations
+  GET /v1/alerts?location=denver               saved active alerts (or zone=COZ039)
+  GET /metrics                                 Prometheus metrics, see 'weather exporter'
+
+With --live, forecast and alerts requests with live=true are fetched from the weather
+provider (the location's provider, or provider=nws|open-meteo) and cached for --cache-ttl.
+Live coordinates are rounded to two decimals. The server only listens on localhost unless
+--listen names another address.
+Errors are returned as {"error": "..."} with a 4xx or 5xx status.
+
+SIGINT or SIGTERM stops the server after in-flight requests finish.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen := viper.GetString("serve.listen")
+		cacheTTL := viper.GetDuration("serve.cache_ttl")
+
+		providers := make(map[string]types.Provider)
+		if viper.GetBool("serve.live") {
+			for _, name := range []string{types.ProviderNWS, types.ProviderOpenMeteo} {
+				provider, err := newProvider(name)
+				if err != nil {
+					return err
+				}
+				providers[name] = provider
+			}
+		}
+
+		logger := log.New(os.Stdout, "", log.LstdFlags)
+		srv := server.NewServer(locationStore{}, providers, cacheTTL, logger)
+
+		collector := metrics.NewWeatherCollector(locationStore{}, metrics.DefaultMaxLeadTime)
+		collector.Logger = logger
+		prometheus.MustRegister(collector)
+		srv.Metrics = metrics.Handler()
+
+		logger.Printf("serving weather API on %s", listen)
+		if err := srv.ListenAndServe(cmd.Context(), listen); err != nil {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		logger.Printf("server stopped")
+
+		return nil
+	},
+}
+
+// locationStore gives the API server the same named locations as --location
+type locationStore struct{}
+
+// Location returns the named location from the database or the config file, or nil if there is
+// none. Config locations are stored with their NWS metadata on first use, like with --location.
+func (locationStore) Location(name string) (*types.Location, error) {
+	loc, err := types.FindLocationByName(name)
+	if err != nil || loc != nil {
+		return loc, err
+	}
+
+	if viper.IsSet("locations." + name) {
+		return lookupLocation(context.Background(), name)
+	}
+
+	return nil, nil
+}
+
+// Locations returns the stored locations followed by those only defined in the config file
+func (locationStore) Locations() ([]types.Location, error) {
+	stored, configOnly, err := listLocations()
+	if err != nil {
+		return nil, err
+	}
+	return append(stored, configOnly...), nil
+}
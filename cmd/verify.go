@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/types"
+)
+
+var (
+	verifyLat    float64
+	verifyLon    float64
+	verifyPlace  string
+	verifyFrom   string
+	verifyTo     string
+	verifyDays   int
+	verifyHourly bool
+)
+
+func init() {
+	rootCmd.AddCommand(verify)
+
+	// Add flags for coordinates
+	verify.Flags().Float64VarP(&verifyLat, "lat", "a", 0.0, "Latitude to verify forecasts for")
+	verify.Flags().Float64VarP(&verifyLon, "lon", "o", 0.0, "Longitude to verify forecasts for")
+	verify.Flags().StringVarP(&verifyPlace, "location", "l", "", "Named location to verify forecasts for (instead of --lat/--lon)")
+	verify.Flags().StringVar(&verifyFrom, "from", "", "Start of the verification window (YYYY-MM-DD or RFC3339, default: --days ago)")
+	verify.Flags().StringVar(&verifyTo, "to", "", "End of the verification window (YYYY-MM-DD or RFC3339, default: now)")
+	verify.Flags().IntVarP(&verifyDays, "days", "d", 7, "Number of days to verify when --from is not given")
+	verify.Flags().BoolVarP(&verifyHourly, "hourly", "H", false, "Verify hourly forecasts instead of daily periods")
+
+	// Bind flags to viper for configuration file support
+	viper.BindPFlag("verify.latitude", verify.Flags().Lookup("lat"))
+	viper.BindPFlag("verify.longitude", verify.Flags().Lookup("lon"))
+	viper.BindPFlag("verify.location", verify.Flags().Lookup("location"))
+	viper.BindPFlag("verify.days", verify.Flags().Lookup("days"))
+	viper.BindPFlag("verify.hourly", verify.Flags().Lookup("hourly"))
+}
+
+var verify = &cobra.Command{
+	Use:   "verify",
+	Short: "Compare stored forecasts to observed conditions",
+	Long: `Pair forecasts saved with 'forecast --save' with observations stored for the same
+location or coordinates and report error metrics broken down by lead time (how far ahead
+the forecast was retrieved):
+
+  - temperature bias, mean absolute error and root mean square error
+  - wind speed bias and mean absolute error
+  - precipitation probability of detection, false alarm ratio and accuracy, counting a
+    period as forecasting precipitation when its chance of precipitation is at least 50%
+
+With --location, forecasts and observations saved for the location are matched by its ID,
+so they are found even if it was moved since. Otherwise forecasts and observations are
+matched to the coordinates rounded to 4 decimal places.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Get coordinates from flags or config, fallback to forecast config
+		lat := viper.GetFloat64("verify.latitude")
+		lon := viper.GetFloat64("verify.longitude")
+		days := viper.GetInt("verify.days")
+		hourly := viper.GetBool("verify.hourly")
+
+		locationName := viper.GetString("verify.location")
+
+		if locationName == "" && lat == 0.0 && lon == 0.0 {
+			locationName = viper.GetString("forecast.location")
+			lat = viper.GetFloat64("forecast.latitude")
+			lon = viper.GetFloat64("forecast.longitude")
+		}
+
+		units, err := unitSystem()
+		if err != nil {
+			return err
+		}
+
+		// Resolve the named location or check the coordinates
+		loc, lat, lon, err := resolveCoordinates(cmd, locationName, lat, lon)
+		if err != nil {
+			return err
+		}
+
+		if days <= 0 {
+			days = 7
+		}
+
+		to := time.Now()
+		if verifyTo != "" {
+			t, err := parseTimeFlag(verifyTo)
+			if err != nil {
+				return fmt.Errorf("invalid --to: %w", err)
+			}
+			to = t
+		}
+
+		from := to.AddDate(0, 0, -days)
+		if verifyFrom != "" {
+			t, err := parseTimeFlag(verifyFrom)
+			if err != nil {
+				return fmt.Errorf("invalid --from: %w", err)
+			}
+			from = t
+		}
+
+		if !from.Before(to) {
+			return fmt.Errorf("--from must be before --to")
+		}
+
+		if loc != nil {
+			fmt.Printf("Verifying forecasts for %s: %.4f, %.4f\n\n", loc.Name, lat, lon)
+		} else {
+			fmt.Printf("Verifying forecasts for coordinates: %.4f, %.4f\n\n", lat, lon)
+		}
+
+		// Stored locations are matched by ID, coordinates by their rounded value
+		var report *types.VerificationReport
+		if loc != nil && loc.ID != 0 {
+			report, err = types.VerifyForecastsForLocationContext(cmd.Context(), loc, from, to, hourly)
+		} else {
+			report, err = types.VerifyForecastsContext(cmd.Context(), lat, lon, from, to, hourly)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to verify forecasts: %w", err)
+		}
+
+		if report.MatchedCount == 0 {
+			fmt.Printf("No stored forecasts could be matched with observations for coordinates %.4f, %.4f\n", lat, lon)
+			fmt.Printf("Save forecasts with 'weather forecast --save' and observations for the same coordinates first.\n")
+			return nil
+		}
+
+		fmt.Print(report.FormatReport(units))
+
+		return nil
+	},
+}
+
+// parseTimeFlag parses a time given on the command line as either a date or an RFC3339 timestamp
+func parseTimeFlag(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// Collect fetches the data for a job from its provider and saves it to the database.
+// Cancelling ctx aborts the fetch and stops the save.
+func Collect(ctx context.Context, provider types.Provider, job *Job) error {
+	lat, lon := job.Latitude, job.Longitude
+
+	switch job.Type {
+	case JobDaily, JobHourly:
+		isHourly := job.Type == JobHourly
+		forecast, err := types.GetProviderForecast(ctx, provider, lat, lon, isHourly)
+		if err != nil {
+			return fmt.Errorf("failed to get weather forecast: %w", err)
+		}
+		return saveForecast(ctx, job, forecast, isHourly)
+
+	case JobGrid:
+		// Gridpoint data is specific to the NWS API
+		client, ok := provider.(*types.WeatherClient)
+		if !ok {
+			return fmt.Errorf("gridpoint data %w %s", types.ErrNotSupported, provider.Name())
+		}
+		grid, err := client.GetGridDataContext(ctx, lat, lon)
+		if err != nil {
+			return fmt.Errorf("failed to get gridpoint forecast: %w", err)
+		}
+		result, err := types.SaveGridDataToDBContext(ctx, grid, lat, lon)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "📊 Database summary: %s\n", result)
+		return nil
+
+	case JobAlerts:
+		alerts, err := types.GetProviderAlerts(ctx, provider, lat, lon)
+		if err != nil {
+			return fmt.Errorf("failed to get alerts: %w", err)
+		}
+		result, err := types.SaveAlertsToDBContext(ctx, alerts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "📊 Database summary: %s\n", result)
+		return nil
+
+	case JobObservations:
+		observations, err := types.GetProviderObservations(ctx, provider, lat, lon, time.Now().Add(-job.lookback))
+		if err != nil {
+			return fmt.Errorf("failed to get observations: %w", err)
+		}
+		return saveObservations(ctx, job, observations)
+	}
+
+	return fmt.Errorf("unknown job type %q", job.Type)
+}
+
+// saveForecast saves a forecast through the SaveForecastToDB path, keeping the job's location ID
+func saveForecast(ctx context.Context, job *Job, forecast *types.ForecastResponse, isHourly bool) error {
+	var result *types.SaveResult
+	var err error
+	if job.LocationID == 0 {
+		result, err = types.SaveForecastToDBContext(ctx, forecast, job.Latitude, job.Longitude, isHourly)
+	} else {
+		result, err = types.SaveForecastRunContext(ctx, &types.ForecastRun{
+			LocationID: job.LocationID,
+			Latitude:   job.Latitude,
+			Longitude:  job.Longitude,
+			IsHourly:   isHourly,
+		}, forecast)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "📊 Database summary: %s\n", result)
+	return nil
+}
+
+// saveObservations saves observations for the job, keeping its location ID
+func saveObservations(ctx context.Context, job *Job, observations []types.ObservationResponse) error {
+	var result *types.ObservationSaveResult
+	var err error
+	if job.LocationID == 0 {
+		result, err = types.SaveObservationsToDBContext(ctx, observations, job.Latitude, job.Longitude)
+	} else {
+		result, err = types.SaveObservationsForLocationContext(ctx, observations, &types.Location{
+			ID:        job.LocationID,
+			Latitude:  job.Latitude,
+			Longitude: job.Longitude,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "📊 Database summary: %s\n", result)
+	return nil
+}
@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/dwburke/weather/types"
+)
+
+// Job types supported by the collector
+const (
+	JobDaily        = "daily"
+	JobHourly       = "hourly"
+	JobGrid         = "grid"
+	JobAlerts       = "alerts"
+	JobObservations = "observations"
+)
+
+// Job is a single collection job read from the daemon.jobs config section
+type Job struct {
+	Name      string   `mapstructure:"name"`
+	Location  string   `mapstructure:"location"` // Named location, used instead of latitude/longitude
+	Latitude  float64  `mapstructure:"latitude"`
+	Longitude float64  `mapstructure:"longitude"`
+	Type      string   `mapstructure:"type"`     // daily, hourly, grid, alerts or observations
+	Provider  string   `mapstructure:"provider"` // Weather provider, default nws
+	Interval  string   `mapstructure:"interval"` // Go duration, e.g. "2h" or "30m"
+	Cron      string   `mapstructure:"cron"`     // Standard 5 field cron expression, used instead of interval
+	Lookback  string   `mapstructure:"lookback"` // How far back observations jobs fetch, default 3h
+	Seasons   []Season `mapstructure:"seasons"`  // Optional windows of the year the job runs in
+
+	// LocationID is the stored location the job's location name resolved to
+	LocationID uint `mapstructure:"-"`
+
+	schedule cron.Schedule
+	lookback time.Duration
+	running  atomic.Bool
+}
+
+// Season is a window of the year given as inclusive MM-DD dates. A window may wrap
+// around the new year, e.g. from 10-01 to 03-31.
+type Season struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+// Validate checks the job configuration and prepares its schedule
+func (j *Job) Validate() error {
+	switch j.Type {
+	case JobDaily, JobHourly, JobGrid, JobAlerts, JobObservations:
+	default:
+		return fmt.Errorf("job %q: unknown type %q (expected daily, hourly, grid, alerts or observations)", j.Name, j.Type)
+	}
+
+	if j.Latitude == 0.0 && j.Longitude == 0.0 {
+		return fmt.Errorf("job %q: a location or latitude and longitude must be provided", j.Name)
+	}
+
+	if j.Latitude < -90 || j.Latitude > 90 {
+		return fmt.Errorf("job %q: latitude must be between -90 and 90 degrees", j.Name)
+	}
+
+	if j.Longitude < -180 || j.Longitude > 180 {
+		return fmt.Errorf("job %q: longitude must be between -180 and 180 degrees", j.Name)
+	}
+
+	if j.Provider == "" {
+		j.Provider = types.ProviderNWS
+	}
+
+	if j.Name == "" && j.Location != "" {
+		j.Name = fmt.Sprintf("%s@%s", j.Type, j.Location)
+	} else if j.Name == "" {
+		j.Name = fmt.Sprintf("%s@%.4f,%.4f", j.Type, j.Latitude, j.Longitude)
+	}
+
+	switch {
+	case j.Cron != "" && j.Interval != "":
+		return fmt.Errorf("job %q: only one of interval and cron may be set", j.Name)
+	case j.Cron != "":
+		schedule, err := cron.ParseStandard(j.Cron)
+		if err != nil {
+			return fmt.Errorf("job %q: invalid cron expression: %w", j.Name, err)
+		}
+		j.schedule = schedule
+	case j.Interval != "":
+		interval, err := time.ParseDuration(j.Interval)
+		if err != nil {
+			return fmt.Errorf("job %q: invalid interval: %w", j.Name, err)
+		}
+		if interval < time.Minute {
+			return fmt.Errorf("job %q: interval must be at least 1m", j.Name)
+		}
+		j.schedule = cron.Every(interval)
+	default:
+		return fmt.Errorf("job %q: an interval or cron expression must be provided", j.Name)
+	}
+
+	j.lookback = 3 * time.Hour
+	if j.Lookback != "" {
+		lookback, err := time.ParseDuration(j.Lookback)
+		if err != nil {
+			return fmt.Errorf("job %q: invalid lookback: %w", j.Name, err)
+		}
+		j.lookback = lookback
+	}
+
+	for _, season := range j.Seasons {
+		if _, err := parseMonthDay(season.From); err != nil {
+			return fmt.Errorf("job %q: invalid season start: %w", j.Name, err)
+		}
+		if _, err := parseMonthDay(season.To); err != nil {
+			return fmt.Errorf("job %q: invalid season end: %w", j.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Next returns the next time the job is scheduled to run after t
+func (j *Job) Next(t time.Time) time.Time {
+	return j.schedule.Next(t)
+}
+
+// InSeason reports whether t falls within one of the job's seasons. Jobs without seasons run all year.
+func (j *Job) InSeason(t time.Time) bool {
+	if len(j.Seasons) == 0 {
+		return true
+	}
+
+	day := int(t.Month())*100 + t.Day()
+	for _, season := range j.Seasons {
+		from, _ := parseMonthDay(season.From)
+		to, _ := parseMonthDay(season.To)
+
+		if from <= to {
+			if day >= from && day <= to {
+				return true
+			}
+		} else if day >= from || day <= to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// daysInMonth is the length of each month, allowing February 29 so leap day seasons are valid
+var daysInMonth = [...]int{31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// parseMonthDay parses an MM-DD date into a comparable MMDD number
+func parseMonthDay(value string) (int, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%q is not an MM-DD date", value)
+	}
+
+	month, err := strconv.Atoi(parts[0])
+	if err != nil || month < 1 || month > 12 {
+		return 0, fmt.Errorf("%q is not an MM-DD date", value)
+	}
+
+	day, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an MM-DD date", value)
+	}
+	if day < 1 || day > daysInMonth[month-1] {
+		return 0, fmt.Errorf("%q is not a day of the year", value)
+	}
+
+	return month*100 + day, nil
+}
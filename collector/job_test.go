@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMonthDay(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr string
+	}{
+		{value: "04-01", want: 401},
+		{value: "12-31", want: 1231},
+		{value: "2-29", want: 229},
+		{value: "02-29", want: 229},
+		{value: "02-30", wantErr: "not a day of the year"},
+		{value: "04-31", wantErr: "not a day of the year"},
+		{value: "06-00", wantErr: "not a day of the year"},
+		{value: "13-01", wantErr: "not an MM-DD date"},
+		{value: "00-10", wantErr: "not an MM-DD date"},
+		{value: "04", wantErr: "not an MM-DD date"},
+		{value: "2024-04-01", wantErr: "not an MM-DD date"},
+		{value: "ap-01", wantErr: "not an MM-DD date"},
+		{value: "", wantErr: "not an MM-DD date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseMonthDay(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseMonthDay(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMonthDay(%q) error = %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseMonthDay(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInSeason(t *testing.T) {
+	summer := []Season{{From: "04-01", To: "09-30"}}
+	winter := []Season{{From: "10-01", To: "03-31"}}
+	leapDay := []Season{{From: "02-29", To: "02-29"}}
+	split := []Season{{From: "01-01", To: "01-31"}, {From: "07-01", To: "07-31"}}
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		seasons []Season
+		at      time.Time
+		want    bool
+	}{
+		{name: "no seasons", at: date(2026, time.February, 1), want: true},
+		{name: "summer first day", seasons: summer, at: date(2026, time.April, 1), want: true},
+		{name: "summer last day", seasons: summer, at: date(2026, time.September, 30), want: true},
+		{name: "summer day before", seasons: summer, at: date(2026, time.March, 31), want: false},
+		{name: "summer day after", seasons: summer, at: date(2026, time.October, 1), want: false},
+		{name: "winter before new year", seasons: winter, at: date(2026, time.December, 31), want: true},
+		{name: "winter after new year", seasons: winter, at: date(2027, time.January, 1), want: true},
+		{name: "winter last day", seasons: winter, at: date(2027, time.March, 31), want: true},
+		{name: "winter first day", seasons: winter, at: date(2026, time.October, 1), want: true},
+		{name: "winter off season", seasons: winter, at: date(2026, time.July, 4), want: false},
+		{name: "winter day after", seasons: winter, at: date(2027, time.April, 1), want: false},
+		{name: "leap day", seasons: leapDay, at: date(2028, time.February, 29), want: true},
+		{name: "leap day season in a common year", seasons: leapDay, at: date(2027, time.February, 28), want: false},
+		{name: "second of two seasons", seasons: split, at: date(2026, time.July, 15), want: true},
+		{name: "between two seasons", seasons: split, at: date(2026, time.April, 15), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{Seasons: tt.seasons}
+			if got := job.InSeason(tt.at); got != tt.want {
+				t.Errorf("InSeason(%s) = %v, want %v", tt.at.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSeasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		seasons []Season
+		wantErr string
+	}{
+		{name: "valid", seasons: []Season{{From: "10-01", To: "03-31"}}},
+		{name: "leap day", seasons: []Season{{From: "02-29", To: "03-31"}}},
+		{name: "bad start", seasons: []Season{{From: "02-30", To: "03-31"}}, wantErr: "invalid season start"},
+		{name: "bad end", seasons: []Season{{From: "04-01", To: "09-31"}}, wantErr: "invalid season end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{Latitude: 39.7456, Longitude: -97.0892, Type: JobHourly, Interval: "1h", Seasons: tt.seasons}
+			err := job.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// DefaultShutdownTimeout is how long in-flight runs get to finish after the scheduler is stopped
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Scheduler runs collection jobs on their schedules until its context is cancelled
+type Scheduler struct {
+	Jobs      []*Job
+	Providers map[string]types.Provider // Providers by name, one for every job's provider
+	Logger    *log.Logger
+
+	// ShutdownTimeout is how long in-flight runs get to finish once the scheduler's context is
+	// cancelled before they are cancelled too. Zero cancels them immediately.
+	ShutdownTimeout time.Duration
+
+	// OnRun, if set, is called after every job run with how long it took and its error, if any
+	OnRun func(job *Job, duration time.Duration, err error)
+
+	wg sync.WaitGroup
+}
+
+// NewScheduler validates the jobs and returns a scheduler for them
+func NewScheduler(jobs []*Job, providers map[string]types.Provider, logger *log.Logger) (*Scheduler, error) {
+	for _, job := range jobs {
+		if err := job.Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := providers[job.Provider]; !ok {
+			return nil, fmt.Errorf("job %q: unknown provider %q", job.Name, job.Provider)
+		}
+	}
+
+	return &Scheduler{
+		Jobs:            jobs,
+		Providers:       providers,
+		Logger:          logger,
+		ShutdownTimeout: DefaultShutdownTimeout,
+	}, nil
+}
+
+// Run starts every job and blocks until ctx is cancelled and all in-flight runs have finished.
+// Runs still in progress ShutdownTimeout after ctx is cancelled are cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	// Runs use their own context so a shutdown lets them finish within the grace period
+	runCtx, cancelRuns := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRuns()
+
+	var loops sync.WaitGroup
+
+	for _, job := range s.Jobs {
+		loops.Add(1)
+		go func(job *Job) {
+			defer loops.Done()
+			s.loop(ctx, runCtx, job)
+		}(job)
+	}
+
+	loops.Wait()
+
+	s.stop(cancelRuns)
+}
+
+// stop waits up to ShutdownTimeout for in-flight runs to finish, then cancels the remaining
+// runs and waits for them to return
+func (s *Scheduler) stop(cancelRuns context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	s.Logger.Printf("waiting up to %s for in-flight jobs to finish", s.ShutdownTimeout)
+
+	timer := time.NewTimer(s.ShutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return
+	case <-timer.C:
+	}
+
+	s.Logger.Printf("cancelling in-flight jobs")
+	cancelRuns()
+	<-done
+}
+
+// loop waits for each scheduled time of a job and triggers a run with runCtx
+func (s *Scheduler) loop(ctx, runCtx context.Context, job *Job) {
+	// Interval jobs collect immediately on startup, cron jobs wait for their first scheduled time
+	if job.Interval != "" {
+		s.trigger(runCtx, job, time.Now())
+	}
+
+	for {
+		next := job.Next(time.Now())
+		s.Logger.Printf("[%s] next run at %s", job.Name, next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.trigger(runCtx, job, next)
+		}
+	}
+}
+
+// trigger starts a run of the job with ctx unless it is out of season or the previous run is still in progress
+func (s *Scheduler) trigger(ctx context.Context, job *Job, at time.Time) {
+	if !job.InSeason(at) {
+		s.Logger.Printf("[%s] out of season, skipping", job.Name)
+		return
+	}
+
+	if !job.running.CompareAndSwap(false, true) {
+		s.Logger.Printf("[%s] previous run still in progress, skipping", job.Name)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer job.running.Store(false)
+
+		start := time.Now()
+		s.Logger.Printf("[%s] collecting %s data from %s for %.4f, %.4f", job.Name, job.Type, job.Provider, job.Latitude, job.Longitude)
+
+		err := Collect(ctx, s.Providers[job.Provider], job)
+		duration := time.Since(start)
+		if s.OnRun != nil {
+			s.OnRun(job, duration, err)
+		}
+
+		if err != nil {
+			s.Logger.Printf("[%s] failed after %s: %v", job.Name, duration.Round(time.Millisecond), err)
+			return
+		}
+
+		s.Logger.Printf("[%s] finished in %s", job.Name, duration.Round(time.Millisecond))
+	}()
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+var errFetched = errors.New("fetched")
+
+// slowProvider is an hourly forecast provider whose fetches take delay, or until ctx is cancelled
+type slowProvider struct {
+	delay   time.Duration
+	started chan struct{}
+}
+
+func (p *slowProvider) Name() string { return "slow" }
+
+func (p *slowProvider) HourlyForecast(ctx context.Context, lat, lon float64) (*types.ForecastResponse, error) {
+	close(p.started)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(p.delay):
+		return nil, errFetched
+	}
+}
+
+func TestSchedulerShutdown(t *testing.T) {
+	tests := []struct {
+		name            string
+		delay           time.Duration
+		shutdownTimeout time.Duration
+		wantErr         error
+	}{
+		{name: "run finishes within the grace period", delay: 50 * time.Millisecond, shutdownTimeout: 5 * time.Second, wantErr: errFetched},
+		{name: "run cancelled after the grace period", delay: time.Minute, shutdownTimeout: 50 * time.Millisecond, wantErr: context.Canceled},
+		{name: "zero grace period cancels immediately", delay: time.Minute, shutdownTimeout: 0, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &slowProvider{delay: tt.delay, started: make(chan struct{})}
+			job := &Job{Name: "slow", Latitude: 39.7456, Longitude: -97.0892, Type: JobHourly, Provider: "slow", Interval: "1h"}
+
+			scheduler, err := NewScheduler([]*Job{job}, map[string]types.Provider{"slow": provider}, log.New(io.Discard, "", 0))
+			if err != nil {
+				t.Fatalf("NewScheduler() error = %v", err)
+			}
+			scheduler.ShutdownTimeout = tt.shutdownTimeout
+
+			runErr := make(chan error, 1)
+			scheduler.OnRun = func(job *Job, duration time.Duration, err error) {
+				runErr <- err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan struct{})
+			go func() {
+				scheduler.Run(ctx)
+				close(stopped)
+			}()
+
+			// Interval jobs run on startup, shut down while that run is in progress
+			<-provider.started
+			cancel()
+
+			select {
+			case <-stopped:
+			case <-time.After(10 * time.Second):
+				t.Fatal("Run() did not return after shutdown")
+			}
+
+			if err := <-runErr; !errors.Is(err, tt.wantErr) {
+				t.Errorf("run error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSchedulerUnknownProvider(t *testing.T) {
+	job := &Job{Latitude: 39.7456, Longitude: -97.0892, Type: JobHourly, Provider: "missing", Interval: "1h"}
+
+	if _, err := NewScheduler([]*Job{job}, map[string]types.Provider{}, log.New(io.Discard, "", 0)); err == nil {
+		t.Fatal("NewScheduler() error = nil, want an unknown provider error")
+	}
+}
@@ -1,20 +1,39 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"sync"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 
 	"github.com/dwburke/weather/db/validate"
 )
 
+// Supported values of the db.driver setting
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 type MyDb struct {
 	conn     *gorm.DB
 	connOnce sync.Once
 	connErr  error
+
+	schemaMu sync.Mutex
+	schemaOK bool // Set once the schema has been found up to date
 }
 
 var (
@@ -23,13 +42,17 @@ var (
 )
 
 func init() {
+	viper.SetDefault("db.driver", DriverMySQL)
 	viper.SetDefault("db.maxidleconnections", 2)
 	viper.SetDefault("db.maxopenconnections", 12)
 	viper.SetDefault("db.connect_timeout", 90)
-	viper.SetDefault("db.port", 3306)
+	viper.SetDefault("db.port", 0) // 3306 for MySQL, 5432 for PostgreSQL
 	viper.SetDefault("db.user", "")
 	viper.SetDefault("db.name", "")
 	viper.SetDefault("db.pass", "")
+	viper.SetDefault("db.path", "~/.weather.db")
+	viper.SetDefault("db.sslmode", "require")
+	viper.SetDefault("db.timescale", false)
 
 }
 
@@ -45,23 +68,53 @@ func GetDB() *MyDb {
 	return globalDb
 }
 
-// get gorm db handle for default context
+// get gorm db handle for default context. An ErrSchemaOutOfDate error is returned until the
+// migrations compiled into this binary have been applied with `weather db migrate up`.
 func (db *MyDb) DB() (*gorm.DB, error) {
-	return db.dbh()
+	conn, err := db.dbh()
+	if err != nil {
+		return nil, err
+	}
+
+	db.schemaMu.Lock()
+	defer db.schemaMu.Unlock()
+
+	if !db.schemaOK {
+		if err := checkSchema(conn); err != nil {
+			return nil, err
+		}
+		db.schemaOK = true
+	}
+
+	return conn, nil
+}
+
+// Driver returns the configured database driver, DriverMySQL, DriverPostgres or DriverSQLite
+func Driver() string {
+	switch viper.GetString("db.driver") {
+	case "sqlite3":
+		return DriverSQLite
+	case "postgresql":
+		return DriverPostgres
+	}
+	return viper.GetString("db.driver")
 }
 
 func (db *MyDb) dbh() (*gorm.DB, error) {
 	db.connOnce.Do(func() {
-		connStr := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True&loc=Local&timeout=%ds",
-			viper.GetString("db.user"),
-			viper.GetString("db.pass"),
-			viper.GetString("db.host"),
-			viper.GetInt("db.port"),
-			viper.GetString("db.name"),
-			viper.GetInt("db.connect_timeout"),
-		)
-
-		conn, err := gorm.Open("mysql", connStr)
+		var conn *gorm.DB
+		var err error
+
+		switch Driver() {
+		case DriverMySQL:
+			conn, err = openMySQL()
+		case DriverPostgres:
+			conn, err = openPostgres()
+		case DriverSQLite:
+			conn, err = openSQLite()
+		default:
+			err = fmt.Errorf("unknown db.driver %q, must be %s, %s or %s", viper.GetString("db.driver"), DriverMySQL, DriverPostgres, DriverSQLite)
+		}
 		if err != nil {
 			db.connErr = err
 			return
@@ -71,6 +124,10 @@ func (db *MyDb) dbh() (*gorm.DB, error) {
 
 		conn.DB().SetMaxIdleConns(viper.GetInt("db.maxidleconnections"))
 		conn.DB().SetMaxOpenConns(viper.GetInt("db.maxopenconnections"))
+		if Driver() == DriverSQLite && viper.GetString("db.path") == ":memory:" {
+			// Every connection to :memory: is a separate database
+			conn.DB().SetMaxOpenConns(1)
+		}
 
 		validate.RegisterCallbacks(conn)
 
@@ -83,3 +140,75 @@ func (db *MyDb) dbh() (*gorm.DB, error) {
 
 	return db.conn, nil
 }
+
+func openMySQL() (*gorm.DB, error) {
+	connStr := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True&loc=Local&timeout=%ds",
+		viper.GetString("db.user"),
+		viper.GetString("db.pass"),
+		viper.GetString("db.host"),
+		port(3306),
+		viper.GetString("db.name"),
+		viper.GetInt("db.connect_timeout"),
+	)
+
+	return gorm.Open("mysql", connStr)
+}
+
+// openPostgres connects to PostgreSQL. Times are stored as timestamptz, so they are compared as
+// instants whatever the offset they were written with.
+func openPostgres() (*gorm.DB, error) {
+	return gorm.Open("postgres", postgresURL())
+}
+
+// postgresURL returns the connection URL for the db settings
+func postgresURL() string {
+	query := url.Values{}
+	query.Set("sslmode", viper.GetString("db.sslmode"))
+	query.Set("connect_timeout", strconv.Itoa(viper.GetInt("db.connect_timeout")))
+
+	connURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(viper.GetString("db.user"), viper.GetString("db.pass")),
+		Host:     net.JoinHostPort(viper.GetString("db.host"), strconv.Itoa(port(5432))),
+		Path:     "/" + viper.GetString("db.name"),
+		RawQuery: query.Encode(),
+	}
+
+	return connURL.String()
+}
+
+// port returns the db.port setting, or the driver's standard port if it is not set
+func port(standard int) int {
+	if p := viper.GetInt("db.port"); p != 0 {
+		return p
+	}
+	return standard
+}
+
+// openSQLite opens the database file at db.path, creating it and its directory if needed.
+// The WAL journal lets readers run while the daemon writes, and writers wait for each other
+// for up to db.connect_timeout seconds instead of failing with "database is locked".
+func openSQLite() (*gorm.DB, error) {
+	path, err := homedir.Expand(viper.GetString("db.path"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid db.path: %w", err)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("db.path must be set for the sqlite driver")
+	}
+
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?_loc=auto&_journal_mode=WAL&_busy_timeout=%d", path, viper.GetInt("db.connect_timeout")*1000)
+
+	sqlDB, err := sql.Open(sqliteUTCDriver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return gorm.Open("sqlite3", sqlDB)
+}
@@ -0,0 +1,255 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrSchemaOutOfDate is returned by DB when the database schema does not match the migrations
+// compiled into this binary
+var ErrSchemaOutOfDate = errors.New("database schema is out of date")
+
+// Migration is one versioned change to the database schema. Up and Down run in a transaction
+// together with the schema_migrations bookkeeping, though MySQL commits DDL statements
+// immediately, so a failed migration may have to be cleaned up by hand there.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+}
+
+// MigrationStatus is a known migration and when it was applied, nil if it is pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table, one per applied migration
+type schemaMigration struct {
+	Version     int       `gorm:"column:version;primary_key;auto_increment:false"`
+	Description string    `gorm:"column:description"`
+	AppliedAt   time.Time `gorm:"column:applied_at;not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// hypertable is a table converted to a TimescaleDB hypertable after migrating up
+type hypertable struct {
+	table, timeColumn string
+}
+
+var (
+	migrations  []Migration
+	hypertables []hypertable
+)
+
+// RegisterMigrations adds migrations to the set applied by MigrateUp. Versions must be unique.
+func RegisterMigrations(ms ...Migration) {
+	for _, m := range ms {
+		for _, existing := range migrations {
+			if existing.Version == m.Version {
+				panic(fmt.Sprintf("db: migration %d registered twice", m.Version))
+			}
+		}
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+}
+
+// RegisterHypertable marks table to be turned into a TimescaleDB hypertable partitioned on
+// timeColumn by MigrateUp, when the postgres driver is used with db.timescale enabled
+func RegisterHypertable(table, timeColumn string) {
+	hypertables = append(hypertables, hypertable{table, timeColumn})
+}
+
+// LatestVersion returns the version of the newest known migration
+func LatestVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// appliedMigrations returns the applied migrations by version
+func appliedMigrations(conn *gorm.DB) (map[int]schemaMigration, error) {
+	applied := make(map[int]schemaMigration)
+	if !conn.HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+
+	var rows []schemaMigration
+	if err := conn.Order("version ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// checkSchema returns an ErrSchemaOutOfDate error unless exactly the known migrations are applied
+func checkSchema(conn *gorm.DB) error {
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			return fmt.Errorf("%w: the database is at version %d but this version of weather needs %d, run `weather db migrate up`",
+				ErrSchemaOutOfDate, current, LatestVersion())
+		}
+	}
+
+	if current > LatestVersion() {
+		return fmt.Errorf("%w: the database is at version %d but this version of weather only knows migrations up to %d, upgrade weather",
+			ErrSchemaOutOfDate, current, LatestVersion())
+	}
+
+	return nil
+}
+
+// MigrationStatus returns every known migration with the time it was applied, oldest first
+func (db *MyDb) MigrationStatus() ([]MigrationStatus, error) {
+	conn, err := db.dbh()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Migration: m}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// MigrateUp applies the pending migrations up to and including version, or all of them if
+// version is 0, then creates any TimescaleDB hypertables. It returns the migrations applied.
+func (db *MyDb) MigrateUp(version int) ([]Migration, error) {
+	conn, err := db.dbh()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.AutoMigrate(&schemaMigration{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if version > 0 && m.Version > version {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "⏳ Applying migration %d: %s\n", m.Version, m.Description)
+		if err := runMigration(conn, m, m.Up, func(tx *gorm.DB) error {
+			return tx.Create(&schemaMigration{Version: m.Version, Description: m.Description, AppliedAt: time.Now()}).Error
+		}); err != nil {
+			return done, fmt.Errorf("migration %d failed: %w", m.Version, err)
+		}
+		done = append(done, m)
+	}
+
+	for _, h := range hypertables {
+		if err := ensureHypertable(conn, h.table, h.timeColumn); err != nil {
+			return done, err
+		}
+	}
+
+	return done, nil
+}
+
+// MigrateDown reverts the applied migrations newer than version, newest first, and returns them
+func (db *MyDb) MigrateDown(version int) ([]Migration, error) {
+	conn, err := db.dbh()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= version {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "⏳ Reverting migration %d: %s\n", m.Version, m.Description)
+		if err := runMigration(conn, m, m.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&schemaMigration{}, "version = ?", m.Version).Error
+		}); err != nil {
+			return done, fmt.Errorf("reverting migration %d failed: %w", m.Version, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// runMigration runs one direction of a migration and its bookkeeping in a transaction
+func runMigration(conn *gorm.DB, m Migration, step, record func(tx *gorm.DB) error) error {
+	if step == nil {
+		return fmt.Errorf("migration %d cannot be run in this direction", m.Version)
+	}
+
+	tx := conn.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := step(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,241 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/spf13/viper"
+)
+
+// newTestDB returns a database handle for a new, empty in-memory sqlite database
+func newTestDB(t *testing.T) *MyDb {
+	t.Helper()
+
+	viper.Set("db.driver", DriverSQLite)
+	viper.Set("db.path", ":memory:")
+
+	db := NewDB()
+	t.Cleanup(func() {
+		if db.conn != nil {
+			db.conn.Close()
+		}
+	})
+	return db
+}
+
+// useMigrations replaces the registered migrations for the duration of the test
+func useMigrations(t *testing.T, ms ...Migration) {
+	t.Helper()
+
+	saved := migrations
+	migrations = nil
+	RegisterMigrations(ms...)
+	t.Cleanup(func() { migrations = saved })
+}
+
+// createTable returns a migration creating a one-column table and dropping it again
+func createTable(version int, table string) Migration {
+	return Migration{
+		Version:     version,
+		Description: "create " + table,
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE " + table + " (id INTEGER)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE " + table).Error
+		},
+	}
+}
+
+func versions(ms []Migration) []int {
+	var vs []int
+	for _, m := range ms {
+		vs = append(vs, m.Version)
+	}
+	return vs
+}
+
+func equalVersions(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRegisterMigrations(t *testing.T) {
+	useMigrations(t, createTable(3, "c"), createTable(1, "a"))
+	RegisterMigrations(createTable(2, "b"))
+
+	if got := versions(migrations); !equalVersions(got, []int{1, 2, 3}) {
+		t.Errorf("registered versions = %v, want [1 2 3]", got)
+	}
+	if got := LatestVersion(); got != 3 {
+		t.Errorf("LatestVersion() = %d, want 3", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a version twice did not panic")
+		}
+	}()
+	RegisterMigrations(createTable(2, "b2"))
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	useMigrations(t, createTable(1, "a"), createTable(2, "b"), createTable(3, "c"))
+	db := newTestDB(t)
+
+	tests := []struct {
+		name        string
+		run         func() ([]Migration, error)
+		wantRun     []int
+		wantTables  []string
+		wantMissing []string
+	}{
+		{
+			name:        "up to a version",
+			run:         func() ([]Migration, error) { return db.MigrateUp(2) },
+			wantRun:     []int{1, 2},
+			wantTables:  []string{"a", "b"},
+			wantMissing: []string{"c"},
+		},
+		{
+			name:       "up to the latest",
+			run:        func() ([]Migration, error) { return db.MigrateUp(0) },
+			wantRun:    []int{3},
+			wantTables: []string{"a", "b", "c"},
+		},
+		{
+			name:       "up with nothing pending",
+			run:        func() ([]Migration, error) { return db.MigrateUp(0) },
+			wantTables: []string{"a", "b", "c"},
+		},
+		{
+			name:        "down reverts newest first",
+			run:         func() ([]Migration, error) { return db.MigrateDown(1) },
+			wantRun:     []int{3, 2},
+			wantTables:  []string{"a"},
+			wantMissing: []string{"b", "c"},
+		},
+		{
+			name:        "down to nothing",
+			run:         func() ([]Migration, error) { return db.MigrateDown(0) },
+			wantRun:     []int{1},
+			wantMissing: []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, err := tt.run()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got := versions(done); !equalVersions(got, tt.wantRun) {
+				t.Errorf("ran %v, want %v", got, tt.wantRun)
+			}
+
+			conn, _ := db.dbh()
+			for _, table := range tt.wantTables {
+				if !conn.HasTable(table) {
+					t.Errorf("table %s is missing", table)
+				}
+			}
+			for _, table := range tt.wantMissing {
+				if conn.HasTable(table) {
+					t.Errorf("table %s exists", table)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateUpFailure(t *testing.T) {
+	failing := Migration{
+		Version:     2,
+		Description: "fails halfway",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE half (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return errors.New("boom")
+		},
+	}
+	useMigrations(t, createTable(1, "a"), failing, createTable(3, "c"))
+	db := newTestDB(t)
+
+	done, err := db.MigrateUp(0)
+	if err == nil {
+		t.Fatal("MigrateUp() error = nil, want the failure of migration 2")
+	}
+	if got := versions(done); !equalVersions(got, []int{1}) {
+		t.Errorf("MigrateUp() ran %v before failing, want [1]", got)
+	}
+
+	conn, _ := db.dbh()
+	if conn.HasTable("half") {
+		t.Error("failed migration was not rolled back")
+	}
+	if conn.HasTable("c") {
+		t.Error("migration after the failed one was applied")
+	}
+
+	statuses, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus() error = %v", err)
+	}
+	for _, status := range statuses {
+		if applied := status.AppliedAt != nil; applied != (status.Version == 1) {
+			t.Errorf("migration %d applied = %v", status.Version, applied)
+		}
+	}
+
+	// The failed migration has no Down, but it was not recorded, so only migration 1 is reverted
+	done, err = db.MigrateDown(0)
+	if err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+	if got := versions(done); !equalVersions(got, []int{1}) {
+		t.Errorf("MigrateDown() reverted %v, want [1]", got)
+	}
+}
+
+func TestDBSchemaCheck(t *testing.T) {
+	useMigrations(t, createTable(1, "a"), createTable(2, "b"))
+	db := newTestDB(t)
+
+	if _, err := db.DB(); !errors.Is(err, ErrSchemaOutOfDate) {
+		t.Fatalf("DB() on an empty database error = %v, want ErrSchemaOutOfDate", err)
+	}
+
+	if _, err := db.MigrateUp(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DB(); !errors.Is(err, ErrSchemaOutOfDate) {
+		t.Fatalf("DB() with a pending migration error = %v, want ErrSchemaOutOfDate", err)
+	}
+
+	if _, err := db.MigrateUp(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DB(); err != nil {
+		t.Fatalf("DB() after migrating error = %v", err)
+	}
+
+	// A database migrated by a newer binary is refused too
+	newer := newTestDB(t)
+	useMigrations(t, createTable(1, "a"), createTable(2, "b"), createTable(3, "c"))
+	if _, err := newer.MigrateUp(0); err != nil {
+		t.Fatal(err)
+	}
+	useMigrations(t, createTable(1, "a"), createTable(2, "b"))
+	if _, err := newer.DB(); !errors.Is(err, ErrSchemaOutOfDate) {
+		t.Errorf("DB() on a newer schema error = %v, want ErrSchemaOutOfDate", err)
+	}
+}
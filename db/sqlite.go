@@ -0,0 +1,51 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteUTCDriver is the name of the SQLite driver that stores every time in UTC
+const sqliteUTCDriver = "sqlite3_utc"
+
+func init() {
+	sql.Register(sqliteUTCDriver, &sqliteDriver{})
+}
+
+// sqliteDriver wraps the SQLite driver so times are always written and compared in UTC.
+// SQLite stores times as text, so range queries and equality checks only work when every
+// value has the same offset. Times are read back in the local time zone (_loc=auto).
+type sqliteDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type sqliteConn struct {
+	*sqlite3.SQLiteConn
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, converting time arguments to UTC and
+// leaving every other argument to the default conversion
+func (c *sqliteConn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch v := nv.Value.(type) {
+	case time.Time:
+		nv.Value = v.UTC()
+		return nil
+	case *time.Time:
+		if v != nil {
+			nv.Value = v.UTC()
+			return nil
+		}
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestOpenSQLiteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "weather.db")
+	viper.Set("db.driver", "sqlite3")
+	viper.Set("db.path", path)
+	t.Cleanup(func() { viper.Set("db.path", ":memory:") })
+
+	db := NewDB()
+	conn, err := db.dbh()
+	if err != nil {
+		t.Fatalf("dbh() error = %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database file was not created: %v", err)
+	}
+
+	var mode string
+	if err := conn.Raw("PRAGMA journal_mode").Row().Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+}
+
+func TestSQLiteStoresUTC(t *testing.T) {
+	conn, err := newTestDB(t).dbh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Exec("CREATE TABLE readings (at DATETIME)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// The same instant written with two offsets is stored, and compared, as one value
+	at := time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC)
+	denver := at.In(time.FixedZone("MDT", -6*60*60))
+	if err := conn.Exec("INSERT INTO readings (at) VALUES (?), (?)", at, &denver).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var stored []string
+	rows, err := conn.Raw("SELECT CAST(at AS TEXT) FROM readings").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		rows.Scan(&value)
+		stored = append(stored, value)
+	}
+	if len(stored) != 2 || stored[0] != stored[1] {
+		t.Errorf("stored %q, want the same UTC time twice", stored)
+	}
+
+	var count int
+	if err := conn.Raw("SELECT COUNT(*) FROM readings WHERE at >= ?", denver).Row().Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("range query with a local time matched %d rows, want 2", count)
+	}
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jinzhu/gorm"
+	"github.com/spf13/viper"
+)
+
+// ensureHypertable turns table into a TimescaleDB hypertable partitioned on timeColumn when the
+// postgres driver is used with db.timescale enabled, and does nothing otherwise. TimescaleDB
+// requires the partitioning column in every unique index, so the primary key becomes
+// (id, timeColumn). Rows already in the table are moved into chunks.
+func ensureHypertable(gdbh *gorm.DB, table, timeColumn string) error {
+	if Driver() != DriverPostgres || !viper.GetBool("db.timescale") {
+		return nil
+	}
+
+	if err := gdbh.Exec("CREATE EXTENSION IF NOT EXISTS timescaledb").Error; err != nil {
+		return fmt.Errorf("failed to enable the timescaledb extension: %w", err)
+	}
+
+	var count int
+	if err := gdbh.Raw("SELECT COUNT(*) FROM timescaledb_information.hypertables WHERE hypertable_schema = current_schema() AND hypertable_name = ?", table).
+		Row().Scan(&count); err != nil {
+		return fmt.Errorf("failed to look up hypertables: %w", err)
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "⏳ Converting %s to a hypertable\n", table)
+
+	tx := gdbh.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	for _, stmt := range []string{
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", table, timeColumn),
+		fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s_pkey", table, table),
+		fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (id, %s)", table, timeColumn),
+		fmt.Sprintf("SELECT create_hypertable('%s', '%s', migrate_data => true)", table, timeColumn),
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to create hypertable %s: %w", table, err)
+		}
+	}
+
+	return tx.Commit().Error
+}
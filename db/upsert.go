@@ -0,0 +1,38 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UpsertClause returns the clause to append to a multi-row INSERT so that rows conflicting with
+// the unique key on conflictColumns update updateColumns instead of failing. Without
+// updateColumns, conflicting rows are skipped and the rows affected only count the new rows.
+// MySQL resolves conflicts on any unique key, so conflictColumns are only used by PostgreSQL and
+// SQLite, and by MySQL to write a no-op update of the first one when skipping rows.
+// Column names are quoted with quote.
+func UpsertClause(quote func(string) string, conflictColumns, updateColumns []string) string {
+	updates := make([]string, 0, len(updateColumns))
+
+	if Driver() == DriverMySQL {
+		if len(updateColumns) == 0 {
+			return fmt.Sprintf("ON DUPLICATE KEY UPDATE %s = %s", quote(conflictColumns[0]), quote(conflictColumns[0]))
+		}
+		for _, column := range updateColumns {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", quote(column), quote(column)))
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	}
+
+	conflicts := make([]string, 0, len(conflictColumns))
+	for _, column := range conflictColumns {
+		conflicts = append(conflicts, quote(column))
+	}
+	if len(updateColumns) == 0 {
+		return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", strings.Join(conflicts, ", "))
+	}
+	for _, column := range updateColumns {
+		updates = append(updates, fmt.Sprintf("%s = excluded.%s", quote(column), quote(column)))
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(conflicts, ", "), strings.Join(updates, ", "))
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func quoteColumn(column string) string {
+	return `"` + column + `"`
+}
+
+func TestUpsertClause(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		updates []string
+		want    string
+	}{
+		{
+			name:    "sqlite update",
+			driver:  DriverSQLite,
+			updates: []string{"name", "temp"},
+			want:    `ON CONFLICT ("run_id", "period") DO UPDATE SET "name" = excluded."name", "temp" = excluded."temp"`,
+		},
+		{
+			name:   "sqlite skip",
+			driver: DriverSQLite,
+			want:   `ON CONFLICT ("run_id", "period") DO NOTHING`,
+		},
+		{
+			name:    "postgres update",
+			driver:  DriverPostgres,
+			updates: []string{"name"},
+			want:    `ON CONFLICT ("run_id", "period") DO UPDATE SET "name" = excluded."name"`,
+		},
+		{
+			name:    "mysql update",
+			driver:  DriverMySQL,
+			updates: []string{"name", "temp"},
+			want:    `ON DUPLICATE KEY UPDATE "name" = VALUES("name"), "temp" = VALUES("temp")`,
+		},
+		{
+			name:   "mysql skip",
+			driver: DriverMySQL,
+			want:   `ON DUPLICATE KEY UPDATE "run_id" = "run_id"`,
+		},
+	}
+
+	defer viper.Set("db.driver", viper.GetString("db.driver"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("db.driver", tt.driver)
+			if got := UpsertClause(quoteColumn, []string{"run_id", "period"}, tt.updates); got != tt.want {
+				t.Errorf("UpsertClause() = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertClauseConflicts(t *testing.T) {
+	conn, err := newTestDB(t).dbh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Exec(`CREATE TABLE periods (run_id INTEGER, period INTEGER, name TEXT, UNIQUE (run_id, period))`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Exec(`INSERT INTO periods VALUES (1, 1, 'Today'), (1, 2, 'Tonight')`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	const insert = `INSERT INTO periods (run_id, period, name) VALUES (1, 2, 'Overnight'), (1, 3, 'Tomorrow') `
+
+	tests := []struct {
+		name         string
+		updates      []string
+		wantAffected int64
+		wantName     string
+	}{
+		{name: "skip", wantAffected: 1, wantName: "Tonight"},
+		{name: "update", updates: []string{"name"}, wantAffected: 2, wantName: "Overnight"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := conn.Exec(`DELETE FROM periods WHERE period = 3`).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			result := conn.Exec(insert + UpsertClause(quoteColumn, []string{"run_id", "period"}, tt.updates))
+			if result.Error != nil {
+				t.Fatalf("insert error = %v", result.Error)
+			}
+			if result.RowsAffected != tt.wantAffected {
+				t.Errorf("rows affected = %d, want %d", result.RowsAffected, tt.wantAffected)
+			}
+
+			var name string
+			if err := conn.Raw(`SELECT name FROM periods WHERE run_id = 1 AND period = 2`).Row().Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			if name != tt.wantName {
+				t.Errorf("conflicting row name = %q, want %q", name, tt.wantName)
+			}
+
+			var count int
+			conn.Raw(`SELECT COUNT(*) FROM periods`).Row().Scan(&count)
+			if count != 3 {
+				t.Errorf("table has %d rows, want 3", count)
+			}
+		})
+	}
+}
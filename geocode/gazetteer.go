@@ -0,0 +1,212 @@
+package geocode
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SourceGazetteer is the Source of places found in a gazetteer
+const SourceGazetteer = "gazetteer"
+
+// embeddedGazetteer is a sample gazetteer covering state capitals, the largest US cities and a
+// few dozen ZIP codes, not the full Census dataset. A complete file, e.g. built from the Census
+// Gazetteer ZCTA and place files, can be loaded with LoadGazetteer.
+//
+//go:embed data/gazetteer.tsv.gz
+var embeddedGazetteer []byte
+
+var (
+	defaultGazetteer     *Gazetteer
+	defaultGazetteerErr  error
+	defaultGazetteerOnce sync.Once
+)
+
+// Gazetteer is an offline geocoder backed by a table of populated places and ZIP code centroids.
+//
+// The table is tab separated, optionally gzip compressed, with the columns
+//
+//	zip  name  state  country  latitude  longitude  population
+//
+// Rows with a zip are ZIP code centroids, the others are populated places. Empty lines and
+// lines starting with # are ignored. Such a file can be built from the Census Gazetteer ZCTA
+// and place files or from GeoNames.
+type Gazetteer struct {
+	places []Place          // Populated places, most populous first
+	byName map[string][]int // Indexes into places by normalized name
+	zips   map[string]Place // ZIP code centroids by ZIP
+}
+
+// DefaultGazetteer returns the gazetteer embedded in the binary
+func DefaultGazetteer() (*Gazetteer, error) {
+	defaultGazetteerOnce.Do(func() {
+		defaultGazetteer, defaultGazetteerErr = NewGazetteer(bytes.NewReader(embeddedGazetteer))
+	})
+	return defaultGazetteer, defaultGazetteerErr
+}
+
+// LoadGazetteer reads a gazetteer file
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gazetteer: %w", err)
+	}
+	defer file.Close()
+
+	return NewGazetteer(file)
+}
+
+// NewGazetteer reads a gazetteer table, decompressing it if it is gzipped
+func NewGazetteer(r io.Reader) (*Gazetteer, error) {
+	buffered := bufio.NewReader(r)
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gazetteer: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = buffered
+	}
+
+	g := &Gazetteer{
+		byName: make(map[string][]int),
+		zips:   make(map[string]Place),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		place, err := parseGazetteerLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer line %d: %w", lineNumber, err)
+		}
+
+		if place.ZIP != "" {
+			g.zips[place.ZIP] = place
+		} else {
+			g.places = append(g.places, place)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read gazetteer: %w", err)
+	}
+
+	sort.SliceStable(g.places, func(i, j int) bool { return g.places[i].Population > g.places[j].Population })
+	for i, place := range g.places {
+		key := normalizeName(place.Name)
+		g.byName[key] = append(g.byName[key], i)
+	}
+
+	return g, nil
+}
+
+func parseGazetteerLine(line string) (Place, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 7 {
+		return Place{}, fmt.Errorf("expected 7 tab separated columns, got %d", len(fields))
+	}
+
+	lat, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return Place{}, fmt.Errorf("invalid latitude %q", fields[4])
+	}
+	lon, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return Place{}, fmt.Errorf("invalid longitude %q", fields[5])
+	}
+
+	var population int
+	if fields[6] != "" {
+		if population, err = strconv.Atoi(fields[6]); err != nil {
+			return Place{}, fmt.Errorf("invalid population %q", fields[6])
+		}
+	}
+
+	return Place{
+		ZIP:        fields[0],
+		Name:       fields[1],
+		State:      fields[2],
+		Country:    fields[3],
+		Latitude:   lat,
+		Longitude:  lon,
+		Population: population,
+		Source:     SourceGazetteer,
+	}, nil
+}
+
+// Geocode implements Geocoder. A query ending in a known ZIP code returns that ZIP's centroid.
+// Otherwise places are matched by name, filtered by any state or country given after a comma.
+// Leading address components the gazetteer cannot resolve, such as a street, are dropped one
+// at a time, so "1 Main St, Boulder, CO" resolves to Boulder.
+func (g *Gazetteer) Geocode(ctx context.Context, query string, limit int) ([]Place, error) {
+	q := parseQuery(query)
+
+	if q.ZIP != "" {
+		if place, ok := g.zips[q.ZIP]; ok {
+			return []Place{place}, nil
+		}
+	}
+
+	for i := range q.Parts {
+		places := g.search(q.Parts[i], q.Parts[i+1:])
+		if len(places) == 0 {
+			continue
+		}
+		if limit > 0 && len(places) > limit {
+			places = places[:limit]
+		}
+		return places, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrNotFound, query)
+}
+
+// search returns the places with the given name in every region given, most populous first
+func (g *Gazetteer) search(name string, regions []string) []Place {
+	var places []Place
+
+	for _, i := range g.byName[normalizeName(name)] {
+		place := g.places[i]
+		if matchesRegions(&place, regions) {
+			places = append(places, place)
+		}
+	}
+
+	return places
+}
+
+// matchesRegions reports whether a place lies in every region, each a state or country
+func matchesRegions(place *Place, regions []string) bool {
+	for _, region := range regions {
+		switch {
+		case stateCode(region) != "":
+			if place.Country != "US" || place.State != stateCode(region) {
+				return false
+			}
+		case isUnitedStates(region):
+			if place.Country != "US" {
+				return false
+			}
+		default:
+			if !strings.EqualFold(place.Country, region) && !strings.EqualFold(place.State, region) {
+				return false
+			}
+		}
+	}
+	return true
+}
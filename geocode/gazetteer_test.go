@@ -0,0 +1,154 @@
+package geocode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testGazetteer = `# zip	name	state	country	latitude	longitude	population
+	Springfield	IL	US	39.7990	-89.6440	114394
+	Springfield	MO	US	37.2090	-93.2923	169176
+	Springfield	MA	US	42.1015	-72.5898	155929
+
+	Saint Louis	MO	US	38.6270	-90.1994	301578
+	Paris	TX	US	33.6609	-95.5555	24171
+	Paris		FR	48.8566	2.3522	2148000
+	Boulder	CO	US	40.0150	-105.2705	108250
+80301	Boulder	CO	US	40.0497	-105.2143	
+`
+
+func TestGazetteerGeocode(t *testing.T) {
+	g, err := NewGazetteer(strings.NewReader(testGazetteer))
+	if err != nil {
+		t.Fatalf("NewGazetteer() error = %v", err)
+	}
+
+	tests := []struct {
+		query   string
+		limit   int
+		want    []string // State or country, and ZIP, of each place
+		wantErr bool
+	}{
+		{query: "Springfield", want: []string{"MO", "MA", "IL"}},
+		{query: "springfield", limit: 1, want: []string{"MO"}},
+		{query: "Springfield, IL", want: []string{"IL"}},
+		{query: "Springfield Illinois", want: []string{"IL"}},
+		{query: "Springfield, TX", wantErr: true},
+		{query: "St. Louis, MO", want: []string{"MO"}},
+		{query: "Paris", want: []string{"FR", "TX"}},
+		{query: "Paris, fr", want: []string{"FR"}},
+		{query: "Paris, USA", want: []string{"TX"}},
+		{query: "80301", want: []string{"CO 80301"}},
+		{query: "1 Main St, Boulder, CO 80301", want: []string{"CO 80301"}},
+		{query: "1 Main St, Boulder, CO 80302", want: []string{"CO"}},
+		{query: "Atlantis", wantErr: true},
+		{query: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			places, err := g.Geocode(context.Background(), tt.query, tt.limit)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("Geocode() error = %v, want ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Geocode() error = %v", err)
+			}
+
+			var got []string
+			for _, place := range places {
+				region := place.State
+				if region == "" {
+					region = place.Country
+				}
+				if place.ZIP != "" {
+					region += " " + place.ZIP
+				}
+				got = append(got, region)
+				if place.Source != SourceGazetteer {
+					t.Errorf("Source = %q, want %q", place.Source, SourceGazetteer)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Geocode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGazetteerGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(testGazetteer))
+	gz.Close()
+
+	g, err := NewGazetteer(&compressed)
+	if err != nil {
+		t.Fatalf("NewGazetteer() error = %v", err)
+	}
+
+	place, err := Lookup(context.Background(), g, "Boulder, CO")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if place.Latitude != 40.0150 || place.Longitude != -105.2705 || place.Population != 108250 {
+		t.Errorf("Lookup() = %+v, want Boulder's coordinates and population", place)
+	}
+}
+
+func TestNewGazetteerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		wantErr string
+	}{
+		{name: "missing column", table: "\tBoulder\tCO\tUS\t40.0150\t-105.2705\n", wantErr: "line 1: expected 7 tab separated columns, got 6"},
+		{name: "latitude", table: "# header\n\tBoulder\tCO\tUS\tnorth\t-105.2705\t1\n", wantErr: `line 2: invalid latitude "north"`},
+		{name: "longitude", table: "\tBoulder\tCO\tUS\t40.0150\t\t1\n", wantErr: `line 1: invalid longitude ""`},
+		{name: "population", table: "\n\n\tBoulder\tCO\tUS\t40.0150\t-105.2705\tmany\n", wantErr: `line 3: invalid population "many"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGazetteer(strings.NewReader(tt.table))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewGazetteer() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultGazetteer(t *testing.T) {
+	g, err := DefaultGazetteer()
+	if err != nil {
+		t.Fatalf("DefaultGazetteer() error = %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"Denver, CO", "Denver, CO"},
+		{"80202", "Denver, CO 80202"},
+		{"Portland, Maine", "Portland, ME"},
+		{"Portland", "Portland, OR"},
+	}
+
+	for _, tt := range tests {
+		place, err := Lookup(context.Background(), g, tt.query)
+		if err != nil {
+			t.Errorf("Lookup(%q) error = %v", tt.query, err)
+			continue
+		}
+		if got := place.DisplayName(); got != tt.want {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
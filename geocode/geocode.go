@@ -0,0 +1,183 @@
+// Package geocode resolves place names, addresses and US ZIP codes to coordinates. Geocoders
+// are pluggable: an offline gazetteer is embedded in the binary, and an online geocoder can be
+// chained after it for places the gazetteer does not know.
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound is returned when no place matches a query
+var ErrNotFound = errors.New("place not found")
+
+// Place is a geocoding result
+type Place struct {
+	Name       string  `json:"name"`
+	State      string  `json:"state,omitempty"` // Two letter code for US states, otherwise the region name
+	Country    string  `json:"country"`         // ISO 3166-1 alpha-2 country code
+	ZIP        string  `json:"zip,omitempty"`   // Set when the place is a ZIP code centroid
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Population int     `json:"population,omitempty"`
+	Source     string  `json:"source"` // Geocoder the place came from
+}
+
+// DisplayName returns the place formatted as "Boulder, CO 80301", "Boulder, CO" or "Paris, FR"
+func (p *Place) DisplayName() string {
+	name := p.Name
+	switch {
+	case p.State != "":
+		name += ", " + p.State
+	case p.Country != "":
+		name += ", " + p.Country
+	}
+	if p.ZIP != "" {
+		name += " " + p.ZIP
+	}
+	return name
+}
+
+// Geocoder looks up places matching a query, best match first. It returns an error wrapping
+// ErrNotFound when nothing matches.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string, limit int) ([]Place, error)
+}
+
+// Chain tries each geocoder in turn and returns the results of the first one that finds a match
+type Chain []Geocoder
+
+// Geocode implements Geocoder
+func (c Chain) Geocode(ctx context.Context, query string, limit int) ([]Place, error) {
+	for _, geocoder := range c {
+		places, err := geocoder.Geocode(ctx, query, limit)
+		if err == nil && len(places) > 0 {
+			return places, nil
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrNotFound, query)
+}
+
+// Lookup returns the best match for a query
+func Lookup(ctx context.Context, geocoder Geocoder, query string) (*Place, error) {
+	places, err := geocoder.Geocode(ctx, query, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(places) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, query)
+	}
+	return &places[0], nil
+}
+
+var (
+	zipPattern         = regexp.MustCompile(`^\d{5}(?:-\d{4})?$`)
+	trailingZIPPattern = regexp.MustCompile(`(?:^|[\s,])(\d{5})(?:-\d{4})?$`)
+)
+
+// IsZIP reports whether value is a US ZIP or ZIP+4 code
+func IsZIP(value string) bool {
+	return zipPattern.MatchString(strings.TrimSpace(value))
+}
+
+// query is a parsed free-form place query
+type query struct {
+	ZIP   string   // Trailing ZIP code, if any
+	Parts []string // Comma separated components with the ZIP removed, e.g. ["1 Main St", "Boulder", "CO"]
+}
+
+// parseQuery splits a query such as "1 Main St, Boulder, CO 80301" into its components
+func parseQuery(value string) query {
+	value = strings.TrimSpace(value)
+
+	var q query
+	if match := trailingZIPPattern.FindStringSubmatchIndex(value); match != nil {
+		q.ZIP = value[match[2]:match[3]]
+		value = strings.TrimSpace(value[:match[0]])
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			q.Parts = append(q.Parts, part)
+		}
+	}
+
+	// "Boulder CO" is treated like "Boulder, CO"
+	if len(q.Parts) == 1 {
+		words := strings.Fields(q.Parts[0])
+		if last := len(words) - 1; last > 0 && stateCode(words[last]) != "" {
+			q.Parts = []string{strings.Join(words[:last], " "), words[last]}
+		}
+	}
+
+	return q
+}
+
+var nameReplacer = strings.NewReplacer(".", "", "'", "", "-", " ")
+
+// normalizeName folds case, punctuation and common abbreviations so "St. Louis" matches "Saint Louis"
+func normalizeName(name string) string {
+	words := strings.Fields(strings.ToLower(nameReplacer.Replace(name)))
+	for i, word := range words {
+		switch word {
+		case "saint", "st":
+			words[i] = "st"
+		case "fort", "ft":
+			words[i] = "ft"
+		case "mount", "mt":
+			words[i] = "mt"
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// stateCode returns the two letter code for a US state name or code, or "" if it is neither
+func stateCode(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) == 2 {
+		code := strings.ToUpper(value)
+		if _, ok := stateNames[code]; ok {
+			return code
+		}
+		return ""
+	}
+
+	normalized := normalizeName(value)
+	for code, name := range stateNames {
+		if normalizeName(name) == normalized {
+			return code
+		}
+	}
+	return ""
+}
+
+// isUnitedStates reports whether a query component names the United States
+func isUnitedStates(value string) bool {
+	switch normalizeName(value) {
+	case "us", "usa", "united states", "united states of america":
+		return true
+	}
+	return false
+}
+
+var stateNames = map[string]string{
+	"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas", "CA": "California",
+	"CO": "Colorado", "CT": "Connecticut", "DE": "Delaware", "DC": "District of Columbia",
+	"FL": "Florida", "GA": "Georgia", "HI": "Hawaii", "ID": "Idaho", "IL": "Illinois",
+	"IN": "Indiana", "IA": "Iowa", "KS": "Kansas", "KY": "Kentucky", "LA": "Louisiana",
+	"ME": "Maine", "MD": "Maryland", "MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota",
+	"MS": "Mississippi", "MO": "Missouri", "MT": "Montana", "NE": "Nebraska", "NV": "Nevada",
+	"NH": "New Hampshire", "NJ": "New Jersey", "NM": "New Mexico", "NY": "New York",
+	"NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio", "OK": "Oklahoma", "OR": "Oregon",
+	"PA": "Pennsylvania", "RI": "Rhode Island", "SC": "South Carolina", "SD": "South Dakota",
+	"TN": "Tennessee", "TX": "Texas", "UT": "Utah", "VT": "Vermont", "VA": "Virginia",
+	"WA": "Washington", "WV": "West Virginia", "WI": "Wisconsin", "WY": "Wyoming",
+	"PR": "Puerto Rico", "GU": "Guam", "VI": "Virgin Islands", "AS": "American Samoa",
+	"MP": "Northern Mariana Islands",
+}
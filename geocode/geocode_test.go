@@ -0,0 +1,198 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		value string
+		want  query
+	}{
+		{"Boulder", query{Parts: []string{"Boulder"}}},
+		{"Boulder, CO", query{Parts: []string{"Boulder", "CO"}}},
+		{"Boulder CO", query{Parts: []string{"Boulder", "CO"}}},
+		{"Fort Collins Colorado", query{Parts: []string{"Fort Collins", "Colorado"}}},
+		{"Grand Junction", query{Parts: []string{"Grand Junction"}}},
+		{"80301", query{ZIP: "80301"}},
+		{"80301-1234", query{ZIP: "80301"}},
+		{"1 Main St, Boulder, CO 80301", query{ZIP: "80301", Parts: []string{"1 Main St", "Boulder", "CO"}}},
+		{"  Paris, , France ", query{Parts: []string{"Paris", "France"}}},
+		{"Route 12345a", query{Parts: []string{"Route 12345a"}}},
+	}
+
+	for _, tt := range tests {
+		if got := parseQuery(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseQuery(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"St. Louis", "Saint Louis"},
+		{"Ft Worth", "fort worth"},
+		{"Mt. Pleasant", "Mount Pleasant"},
+		{"Winston-Salem", "winston salem"},
+		{"Coeur d'Alene", "Coeur dAlene"},
+	}
+
+	for _, tt := range tests {
+		if normalizeName(tt.a) != normalizeName(tt.b) {
+			t.Errorf("normalizeName(%q) = %q, normalizeName(%q) = %q, want them equal", tt.a, normalizeName(tt.a), tt.b, normalizeName(tt.b))
+		}
+	}
+}
+
+func TestStateCode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"CO", "CO"},
+		{"co", "CO"},
+		{"Colorado", "CO"},
+		{"new york", "NY"},
+		{"District of Columbia", "DC"},
+		{"XX", ""},
+		{"France", ""},
+	}
+
+	for _, tt := range tests {
+		if got := stateCode(tt.value); got != tt.want {
+			t.Errorf("stateCode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIsZIP(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"80301", true},
+		{" 80301 ", true},
+		{"80301-1234", true},
+		{"8030", false},
+		{"803011", false},
+		{"Boulder 80301", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsZIP(tt.value); got != tt.want {
+			t.Errorf("IsZIP(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	tests := []struct {
+		place Place
+		want  string
+	}{
+		{Place{Name: "Boulder", State: "CO", Country: "US", ZIP: "80301"}, "Boulder, CO 80301"},
+		{Place{Name: "Boulder", State: "CO", Country: "US"}, "Boulder, CO"},
+		{Place{Name: "Paris", Country: "FR"}, "Paris, FR"},
+		{Place{Name: "Nowhere"}, "Nowhere"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.place.DisplayName(); got != tt.want {
+			t.Errorf("DisplayName() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// fakeGeocoder returns places or err, counting its calls
+type fakeGeocoder struct {
+	places []Place
+	err    error
+	calls  int
+}
+
+func (f *fakeGeocoder) Geocode(ctx context.Context, query string, limit int) ([]Place, error) {
+	f.calls++
+	return f.places, f.err
+}
+
+func TestChain(t *testing.T) {
+	boulder := []Place{{Name: "Boulder", State: "CO", Country: "US"}}
+	failure := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		first     *fakeGeocoder
+		second    *fakeGeocoder
+		want      []Place
+		wantErr   error
+		wantCalls int // Calls to the second geocoder
+	}{
+		{
+			name:   "first finds it",
+			first:  &fakeGeocoder{places: boulder},
+			second: &fakeGeocoder{places: []Place{{Name: "Other"}}},
+			want:   boulder,
+		},
+		{
+			name:      "falls back when not found",
+			first:     &fakeGeocoder{err: ErrNotFound},
+			second:    &fakeGeocoder{places: boulder},
+			want:      boulder,
+			wantCalls: 1,
+		},
+		{
+			name:      "falls back on no results",
+			first:     &fakeGeocoder{},
+			second:    &fakeGeocoder{places: boulder},
+			want:      boulder,
+			wantCalls: 1,
+		},
+		{
+			name:    "stops on other errors",
+			first:   &fakeGeocoder{err: failure},
+			second:  &fakeGeocoder{places: boulder},
+			wantErr: failure,
+		},
+		{
+			name:      "nothing finds it",
+			first:     &fakeGeocoder{err: ErrNotFound},
+			second:    &fakeGeocoder{},
+			wantErr:   ErrNotFound,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			places, err := Chain{tt.first, tt.second}.Geocode(context.Background(), "Boulder", 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Geocode() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(places, tt.want) {
+				t.Errorf("Geocode() = %+v, want %+v", places, tt.want)
+			}
+			if tt.second.calls != tt.wantCalls {
+				t.Errorf("second geocoder called %d times, want %d", tt.second.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	place, err := Lookup(context.Background(), &fakeGeocoder{places: []Place{{Name: "Denver"}, {Name: "Denver City"}}}, "Denver")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if place.Name != "Denver" {
+		t.Errorf("Lookup() = %q, want the first place", place.Name)
+	}
+
+	if _, err := Lookup(context.Background(), &fakeGeocoder{}, "Nowhere"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() with no places error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,144 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SourceOpenMeteo is the Source of places found by the Open-Meteo geocoding API
+const SourceOpenMeteo = "open-meteo"
+
+// OpenMeteoGeocoder looks up places worldwide with the Open-Meteo geocoding API
+// (open-meteo.com/en/docs/geocoding-api). It searches by place name only; ZIP codes and
+// addresses are left to the gazetteer.
+type OpenMeteoGeocoder struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewOpenMeteoGeocoder returns a geocoder for the public Open-Meteo geocoding API
+func NewOpenMeteoGeocoder() *OpenMeteoGeocoder {
+	return &OpenMeteoGeocoder{
+		BaseURL: "https://geocoding-api.open-meteo.com/v1",
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// openMeteoSearchResponse is the subset of the /search response that is used
+type openMeteoSearchResponse struct {
+	Results []struct {
+		Name        string  `json:"name"`
+		Latitude    float64 `json:"latitude"`
+		Longitude   float64 `json:"longitude"`
+		CountryCode string  `json:"country_code"`
+		Country     string  `json:"country"`
+		Admin1      string  `json:"admin1"`
+		Population  int     `json:"population"`
+	} `json:"results"`
+}
+
+// Geocode implements Geocoder. The first component of the query is searched for, and any
+// further components are matched against the result's state or country.
+func (o *OpenMeteoGeocoder) Geocode(ctx context.Context, query string, limit int) ([]Place, error) {
+	q := parseQuery(query)
+	if len(q.Parts) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, query)
+	}
+
+	name, regions := q.Parts[0], q.Parts[1:]
+
+	// Regions are filtered locally, so ask for more results than needed
+	count := limit
+	if count <= 0 || len(regions) > 0 {
+		count = 100
+	}
+
+	params := url.Values{}
+	params.Set("name", name)
+	params.Set("count", fmt.Sprint(count))
+	params.Set("language", "en")
+	params.Set("format", "json")
+
+	var resp openMeteoSearchResponse
+	if err := o.getJSON(ctx, o.BaseURL+"/search?"+params.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to search for place: %w", err)
+	}
+
+	var places []Place
+	for _, result := range resp.Results {
+		place := Place{
+			Name:       result.Name,
+			State:      result.Admin1,
+			Country:    strings.ToUpper(result.CountryCode),
+			Latitude:   result.Latitude,
+			Longitude:  result.Longitude,
+			Population: result.Population,
+			Source:     SourceOpenMeteo,
+		}
+		if place.Country == "US" {
+			if code := stateCode(result.Admin1); code != "" {
+				place.State = code
+			}
+		}
+
+		if !matchesRegions(&place, regions) && !matchesCountryName(result.Country, regions) {
+			continue
+		}
+
+		places = append(places, place)
+		if limit > 0 && len(places) == limit {
+			break
+		}
+	}
+
+	if len(places) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, query)
+	}
+
+	return places, nil
+}
+
+// matchesCountryName reports whether the only region given is the full country name, e.g. "France"
+func matchesCountryName(country string, regions []string) bool {
+	return len(regions) == 1 && country != "" && normalizeName(country) == normalizeName(regions[0])
+}
+
+func (o *OpenMeteoGeocoder) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+
+		var apiErr struct {
+			Reason string `json:"reason"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Reason != "" {
+			return fmt.Errorf("open-meteo API error: %s - %s", resp.Status, apiErr.Reason)
+		}
+		return fmt.Errorf("open-meteo API error: %s - %s", resp.Status, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const openMeteoResults = `{"results": [
+	{"name": "Paris", "latitude": 48.85341, "longitude": 2.3488, "country_code": "FR", "country": "France", "admin1": "Île-de-France", "population": 2138551},
+	{"name": "Paris", "latitude": 33.66094, "longitude": -95.55551, "country_code": "US", "country": "United States", "admin1": "Texas", "population": 24171},
+	{"name": "Paris", "latitude": 36.302, "longitude": -88.32671, "country_code": "US", "country": "United States", "admin1": "Tennessee", "population": 10156}
+]}`
+
+func TestOpenMeteoGeocoder(t *testing.T) {
+	var searches []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches = append(searches, r.URL.Query())
+		if r.URL.Query().Get("name") != "Paris" {
+			w.Write([]byte(`{"generationtime_ms": 0.5}`))
+			return
+		}
+		w.Write([]byte(openMeteoResults))
+	}))
+	defer server.Close()
+
+	geocoder := NewOpenMeteoGeocoder()
+	geocoder.BaseURL = server.URL
+	geocoder.HTTPClient = server.Client()
+
+	tests := []struct {
+		query     string
+		limit     int
+		want      []string
+		wantCount string
+	}{
+		{query: "Paris", limit: 1, want: []string{"Paris, Île-de-France"}, wantCount: "1"},
+		{query: "Paris", want: []string{"Paris, Île-de-France", "Paris, TX", "Paris, TN"}, wantCount: "100"},
+		{query: "Paris, TN", limit: 1, want: []string{"Paris, TN"}, wantCount: "100"},
+		{query: "Paris, USA", limit: 5, want: []string{"Paris, TX", "Paris, TN"}, wantCount: "100"},
+		{query: "Paris, France", limit: 5, want: []string{"Paris, Île-de-France"}, wantCount: "100"},
+		{query: "Paris, CO", limit: 5, wantCount: "100"},
+		{query: "Atlantis", limit: 1, wantCount: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			searches = nil
+			places, err := geocoder.Geocode(context.Background(), tt.query, tt.limit)
+			if len(tt.want) == 0 {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("Geocode() error = %v, want ErrNotFound", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Geocode() error = %v", err)
+			}
+
+			var got []string
+			for _, place := range places {
+				got = append(got, place.DisplayName())
+				if place.Source != SourceOpenMeteo {
+					t.Errorf("Source = %q, want %q", place.Source, SourceOpenMeteo)
+				}
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("Geocode() = %v, want %v", got, tt.want)
+			}
+
+			if len(searches) != 1 || searches[0].Get("count") != tt.wantCount {
+				t.Errorf("searches = %v, want one with count %s", searches, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestOpenMeteoGeocoderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": true, "reason": "Parameter count must be between 1 and 100."}`))
+	}))
+	defer server.Close()
+
+	geocoder := NewOpenMeteoGeocoder()
+	geocoder.BaseURL = server.URL
+	geocoder.HTTPClient = server.Client()
+
+	_, err := geocoder.Geocode(context.Background(), "Paris", 1)
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Geocode() error = %v, want the API error", err)
+	}
+	if want := "open-meteo API error: 400 Bad Request - Parameter count must be between 1 and 100."; !strings.Contains(err.Error(), want) {
+		t.Errorf("Geocode() error = %v, want it to contain %q", err, want)
+	}
+}
@@ -0,0 +1,100 @@
+// Package metrics publishes weather data and collector health as Prometheus metrics.
+//
+// Requests to the weather APIs and collection runs are recorded in the process that makes
+// them, through InstrumentTransport and ObserveCollection. The saved forecasts, observations
+// and alerts are published by WeatherCollector, which reads the database at scrape time.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	apiRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "weather_api_requests_total",
+		Help: `Requests made to weather APIs by API and HTTP status code, "error" when no response was received.`,
+	}, []string{"api", "code"})
+
+	apiRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "weather_api_request_errors_total",
+		Help: "Requests to weather APIs that failed or returned a 4xx or 5xx status.",
+	}, []string{"api"})
+
+	apiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "weather_api_request_duration_seconds",
+		Help:    "Latency of requests to weather APIs.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"api"})
+
+	collectionRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "weather_collection_runs_total",
+		Help: `Collector job runs by job, type and result ("success" or "failure").`,
+	}, []string{"job", "type", "result"})
+
+	collectionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "weather_collection_duration_seconds",
+		Help:    "Duration of collector job runs.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"job", "type"})
+
+	collectionLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "weather_collection_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful run of each collector job.",
+	}, []string{"job", "type"})
+)
+
+// Handler returns the handler serving the metrics of the default registry at /metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// InstrumentTransport wraps next, or http.DefaultTransport if it is nil, to record the latency,
+// status code and errors of every request made to the named API
+func InstrumentTransport(api string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		apiRequestDuration.WithLabelValues(api).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			apiRequests.WithLabelValues(api, "error").Inc()
+			apiRequestErrors.WithLabelValues(api).Inc()
+			return nil, err
+		}
+
+		apiRequests.WithLabelValues(api, strconv.Itoa(resp.StatusCode)).Inc()
+		if resp.StatusCode >= http.StatusBadRequest {
+			apiRequestErrors.WithLabelValues(api).Inc()
+		}
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// ObserveCollection records the outcome and duration of a collector job run
+func ObserveCollection(job, jobType string, duration time.Duration, err error) {
+	collectionDuration.WithLabelValues(job, jobType).Observe(duration.Seconds())
+
+	if err != nil {
+		collectionRuns.WithLabelValues(job, jobType, "failure").Inc()
+		return
+	}
+
+	collectionRuns.WithLabelValues(job, jobType, "success").Inc()
+	collectionLastSuccess.WithLabelValues(job, jobType).SetToCurrentTime()
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: InstrumentTransport("test-api", server.Client().Transport)}
+	for _, path := range []string{"/ok", "/ok", "/fail"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	refused := InstrumentTransport("test-api", roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, err := refused.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip() error = nil, want the transport error")
+	}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"200 responses", testutil.ToFloat64(apiRequests.WithLabelValues("test-api", "200")), 2},
+		{"503 responses", testutil.ToFloat64(apiRequests.WithLabelValues("test-api", "503")), 1},
+		{"failed requests", testutil.ToFloat64(apiRequests.WithLabelValues("test-api", "error")), 1},
+		{"errors", testutil.ToFloat64(apiRequestErrors.WithLabelValues("test-api")), 2},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if got := testutil.CollectAndCount(apiRequestDuration, "weather_api_request_duration_seconds"); got < 1 {
+		t.Errorf("request durations published for %d APIs, want test-api", got)
+	}
+}
+
+func TestObserveCollection(t *testing.T) {
+	ObserveCollection("home", "forecast", time.Second, nil)
+	ObserveCollection("home", "forecast", time.Second, errors.New("timeout"))
+	ObserveCollection("home", "forecast", 2*time.Second, nil)
+	ObserveCollection("away", "alerts", time.Second, errors.New("timeout"))
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"home successes", testutil.ToFloat64(collectionRuns.WithLabelValues("home", "forecast", "success")), 2},
+		{"home failures", testutil.ToFloat64(collectionRuns.WithLabelValues("home", "forecast", "failure")), 1},
+		{"away failures", testutil.ToFloat64(collectionRuns.WithLabelValues("away", "alerts", "failure")), 1},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if last := testutil.ToFloat64(collectionLastSuccess.WithLabelValues("home", "forecast")); time.Since(time.Unix(int64(last), 0)) > time.Minute {
+		t.Errorf("last success = %v, want the current time", last)
+	}
+	if last := testutil.ToFloat64(collectionLastSuccess.WithLabelValues("away", "alerts")); last != 0 {
+		t.Errorf("last success of a job that only failed = %v, want 0", last)
+	}
+}
@@ -0,0 +1,265 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dwburke/weather/types"
+)
+
+// DefaultMaxLeadTime is how far ahead forecast temperatures are published by default
+const DefaultMaxLeadTime = 48 * time.Hour
+
+// scrapeTimeout bounds the database queries of a single scrape
+const scrapeTimeout = 10 * time.Second
+
+// alertSeverities are the CAP severities, published even when no alert has them so alert rules
+// can compare against zero
+var alertSeverities = []string{"Extreme", "Severe", "Moderate", "Minor", "Unknown"}
+
+var (
+	forecastTemperatureDesc = prometheus.NewDesc(
+		"weather_forecast_temperature_celsius",
+		"Forecast temperature from the latest saved run, by hours until the period starts (0 for the current period).",
+		[]string{"location", "forecast", "lead_hours"}, nil)
+
+	observedTemperatureDesc = prometheus.NewDesc(
+		"weather_observed_temperature_celsius",
+		"Temperature of the latest saved observation.",
+		[]string{"location", "station"}, nil)
+
+	observedDewpointDesc = prometheus.NewDesc(
+		"weather_observed_dewpoint_celsius",
+		"Dewpoint of the latest saved observation.",
+		[]string{"location", "station"}, nil)
+
+	observedHumidityDesc = prometheus.NewDesc(
+		"weather_observed_relative_humidity_percent",
+		"Relative humidity of the latest saved observation.",
+		[]string{"location", "station"}, nil)
+
+	observedWindSpeedDesc = prometheus.NewDesc(
+		"weather_observed_wind_speed_kmh",
+		"Wind speed of the latest saved observation.",
+		[]string{"location", "station"}, nil)
+
+	observedTimestampDesc = prometheus.NewDesc(
+		"weather_observed_timestamp_seconds",
+		"Unix time the latest saved observation was measured.",
+		[]string{"location", "station"}, nil)
+
+	lastForecastSaveDesc = prometheus.NewDesc(
+		"weather_last_forecast_save_timestamp_seconds",
+		"Unix time the latest forecast run was saved.",
+		[]string{"location", "forecast"}, nil)
+
+	lastObservationSaveDesc = prometheus.NewDesc(
+		"weather_last_observation_save_timestamp_seconds",
+		"Unix time the latest observation was saved.",
+		[]string{"location"}, nil)
+
+	activeAlertsDesc = prometheus.NewDesc(
+		"weather_active_alerts",
+		"Saved alerts that have not expired, been superseded or been cancelled.",
+		[]string{"severity"}, nil)
+
+	scrapeErrorDesc = prometheus.NewDesc(
+		"weather_exporter_scrape_error",
+		"1 if reading the database failed during the last scrape, 0 otherwise.",
+		nil, nil)
+)
+
+// LocationLister lists the named locations weather metrics are published for
+type LocationLister interface {
+	Locations() ([]types.Location, error)
+}
+
+// WeatherCollector is a prometheus.Collector publishing the saved forecasts, observations and
+// alerts of every named location. The database is read on every scrape.
+type WeatherCollector struct {
+	Locations   LocationLister
+	MaxLeadTime time.Duration // Forecast periods starting further ahead are not published
+	Logger      *log.Logger   // Where database errors are logged, the standard logger if nil
+}
+
+// NewWeatherCollector returns a collector for the given locations
+func NewWeatherCollector(locations LocationLister, maxLeadTime time.Duration) *WeatherCollector {
+	return &WeatherCollector{
+		Locations:   locations,
+		MaxLeadTime: maxLeadTime,
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *WeatherCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		forecastTemperatureDesc, observedTemperatureDesc, observedDewpointDesc, observedHumidityDesc,
+		observedWindSpeedDesc, observedTimestampDesc, lastForecastSaveDesc, lastObservationSaveDesc,
+		activeAlertsDesc, scrapeErrorDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *WeatherCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	failed := false
+	check := func(err error, format string, args ...interface{}) {
+		if err != nil {
+			failed = true
+			c.logf("metrics: "+format+": %v", append(args, err)...)
+		}
+	}
+
+	locations, err := c.Locations.Locations()
+	check(err, "failed to list locations")
+
+	// A failing query only drops its own series, the others are still published
+	now := time.Now()
+	for i := range locations {
+		loc := &locations[i]
+		for _, hourly := range []bool{true, false} {
+			check(c.collectForecast(ctx, ch, loc, hourly, now), "failed to read %s forecast for %s", forecastType(hourly), loc.Name)
+		}
+		check(collectObservation(ctx, ch, loc), "failed to read observation for %s", loc.Name)
+	}
+
+	check(collectAlerts(ctx, ch), "failed to read alerts")
+
+	scrapeError := 0.0
+	if failed {
+		scrapeError = 1
+	}
+	ch <- prometheus.MustNewConstMetric(scrapeErrorDesc, prometheus.GaugeValue, scrapeError)
+}
+
+// logf logs a collection error
+func (c *WeatherCollector) logf(format string, args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// forecastType returns the forecast label value of a forecast type
+func forecastType(hourly bool) string {
+	if hourly {
+		return "hourly"
+	}
+	return "daily"
+}
+
+// collectForecast publishes the latest forecast of a location and type
+func (c *WeatherCollector) collectForecast(ctx context.Context, ch chan<- prometheus.Metric, loc *types.Location, hourly bool, now time.Time) error {
+	runs, err := types.GetForecastRuns(loc.Latitude, loc.Longitude, 1, hourly)
+	if err != nil {
+		return err
+	}
+	if len(runs) > 0 {
+		ch <- prometheus.MustNewConstMetric(lastForecastSaveDesc, prometheus.GaugeValue,
+			float64(runs[0].RetrievedAt.Unix()), loc.Name, forecastType(hourly))
+	}
+
+	var forecasts []types.WeatherForecast
+	if loc.ID != 0 {
+		forecasts, err = types.GetLatestForecastForLocationContext(ctx, loc.ID, 0, hourly)
+	} else {
+		forecasts, err = types.GetLatestForecastContext(ctx, loc.Latitude, loc.Longitude, 0, hourly)
+	}
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int]bool)
+	for i := range forecasts {
+		forecast := &forecasts[i]
+		if !forecast.EndTime.After(now) {
+			continue
+		}
+
+		lead := forecast.StartTime.Sub(now)
+		if lead > c.MaxLeadTime {
+			break
+		}
+
+		leadHours := 0
+		if lead > 0 {
+			leadHours = int(lead / time.Hour)
+		}
+		if seen[leadHours] {
+			continue
+		}
+		seen[leadHours] = true
+
+		ch <- prometheus.MustNewConstMetric(forecastTemperatureDesc, prometheus.GaugeValue,
+			forecast.TemperatureC(), loc.Name, forecastType(hourly), strconv.Itoa(leadHours))
+	}
+
+	return nil
+}
+
+// collectObservation publishes the latest observation of a location
+func collectObservation(ctx context.Context, ch chan<- prometheus.Metric, loc *types.Location) error {
+	observation, err := types.GetLatestObservationContext(ctx, loc.Latitude, loc.Longitude)
+	if err != nil {
+		return err
+	}
+	if observation == nil {
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(lastObservationSaveDesc, prometheus.GaugeValue,
+		float64(observation.CreatedAt.Unix()), loc.Name)
+	ch <- prometheus.MustNewConstMetric(observedTimestampDesc, prometheus.GaugeValue,
+		float64(observation.Timestamp.Unix()), loc.Name, observation.StationID)
+
+	for _, value := range []struct {
+		desc  *prometheus.Desc
+		value *float64
+	}{
+		{observedTemperatureDesc, observation.TemperatureC},
+		{observedDewpointDesc, observation.DewpointC},
+		{observedHumidityDesc, observation.RelativeHumidity},
+		{observedWindSpeedDesc, observation.WindSpeedKmh},
+	} {
+		if value.value != nil {
+			ch <- prometheus.MustNewConstMetric(value.desc, prometheus.GaugeValue, *value.value, loc.Name, observation.StationID)
+		}
+	}
+
+	return nil
+}
+
+// collectAlerts publishes the number of active saved alerts by severity
+func collectAlerts(ctx context.Context, ch chan<- prometheus.Metric) error {
+	alerts, err := types.GetActiveStoredAlertsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, severity := range alertSeverities {
+		counts[severity] = 0
+	}
+	for _, alert := range alerts {
+		severity := alert.Severity
+		if severity == "" {
+			severity = "Unknown"
+		}
+		counts[severity]++
+	}
+
+	for severity, count := range counts {
+		ch <- prometheus.MustNewConstMetric(activeAlertsDesc, prometheus.GaugeValue, float64(count), severity)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/db"
+	"github.com/dwburke/weather/types"
+)
+
+// TestMain runs the tests against a migrated in-memory sqlite database
+func TestMain(m *testing.M) {
+	viper.Set("db.driver", db.DriverSQLite)
+	viper.Set("db.path", ":memory:")
+
+	if _, err := db.GetDB().MigrateUp(0); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate test database: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeLister lists locations, or fails with err
+type fakeLister struct {
+	locations []types.Location
+	err       error
+}
+
+func (f *fakeLister) Locations() ([]types.Location, error) {
+	return f.locations, f.err
+}
+
+func TestWeatherCollector(t *testing.T) {
+	now := time.Now()
+	period := func(number int, start time.Time, temperature int) types.ForecastPeriod {
+		return types.ForecastPeriod{
+			Number: number, Name: fmt.Sprintf("Hour %d", number), Temperature: temperature, TemperatureUnit: "F",
+			StartTime: start.Format(time.RFC3339), EndTime: start.Add(time.Hour).Format(time.RFC3339),
+		}
+	}
+
+	run := &types.ForecastRun{Latitude: 46.8721, Longitude: -113.9940, IsHourly: true, Provider: types.ProviderNWS}
+	if _, err := types.SaveForecastRun(run, &types.ForecastResponse{Properties: types.ForecastProperties{Periods: []types.ForecastPeriod{
+		period(1, now.Add(-90*time.Minute), 41), // Over
+		period(2, now.Add(-30*time.Minute), 50), // Current
+		period(3, now.Add(30*time.Minute), 59),  // Same lead hour as the current period
+		period(4, now.Add(90*time.Minute), 68),
+		period(5, now.Add(50*time.Hour), 77), // Past the maximum lead time
+	}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	temperature, dewpoint, humidity := 12.5, 1.5, 47.0
+	observation := types.ObservationResponse{Properties: types.ObservationProperties{
+		Station:          "https://api.weather.gov/stations/KMSO",
+		Timestamp:        now.Add(-time.Hour).Format(time.RFC3339),
+		Temperature:      types.QuantitativeValue{Value: &temperature, UnitCode: "wmoUnit:degC"},
+		Dewpoint:         types.QuantitativeValue{Value: &dewpoint, UnitCode: "wmoUnit:degC"},
+		RelativeHumidity: types.QuantitativeValue{Value: &humidity, UnitCode: "wmoUnit:percent"},
+	}}
+	if _, err := types.SaveObservationsToDB([]types.ObservationResponse{observation}, 46.8721, -113.9940); err != nil {
+		t.Fatal(err)
+	}
+
+	expires := now.Add(time.Hour).Format(time.RFC3339)
+	if _, err := types.SaveAlertsToDB([]types.Alert{
+		{ID: "urn:metrics.1", MessageType: "Alert", Severity: "Severe", Expires: expires},
+		{ID: "urn:metrics.2", MessageType: "Alert", Severity: "Severe", Expires: expires},
+		{ID: "urn:metrics.3", MessageType: "Alert", Expires: expires},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	collector := NewWeatherCollector(&fakeLister{locations: []types.Location{
+		{Name: "missoula", Latitude: 46.8721, Longitude: -113.9940},
+		{Name: "empty", Latitude: 0, Longitude: 0},
+	}}, DefaultMaxLeadTime)
+
+	expected := `
+# HELP weather_active_alerts Saved alerts that have not expired, been superseded or been cancelled.
+# TYPE weather_active_alerts gauge
+weather_active_alerts{severity="Extreme"} 0
+weather_active_alerts{severity="Minor"} 0
+weather_active_alerts{severity="Moderate"} 0
+weather_active_alerts{severity="Severe"} 2
+weather_active_alerts{severity="Unknown"} 1
+# HELP weather_exporter_scrape_error 1 if reading the database failed during the last scrape, 0 otherwise.
+# TYPE weather_exporter_scrape_error gauge
+weather_exporter_scrape_error 0
+# HELP weather_forecast_temperature_celsius Forecast temperature from the latest saved run, by hours until the period starts (0 for the current period).
+# TYPE weather_forecast_temperature_celsius gauge
+weather_forecast_temperature_celsius{forecast="hourly",lead_hours="0",location="missoula"} 10
+weather_forecast_temperature_celsius{forecast="hourly",lead_hours="1",location="missoula"} 20
+# HELP weather_observed_dewpoint_celsius Dewpoint of the latest saved observation.
+# TYPE weather_observed_dewpoint_celsius gauge
+weather_observed_dewpoint_celsius{location="missoula",station="KMSO"} 1.5
+# HELP weather_observed_relative_humidity_percent Relative humidity of the latest saved observation.
+# TYPE weather_observed_relative_humidity_percent gauge
+weather_observed_relative_humidity_percent{location="missoula",station="KMSO"} 47
+# HELP weather_observed_temperature_celsius Temperature of the latest saved observation.
+# TYPE weather_observed_temperature_celsius gauge
+weather_observed_temperature_celsius{location="missoula",station="KMSO"} 12.5
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"weather_active_alerts", "weather_exporter_scrape_error", "weather_forecast_temperature_celsius",
+		"weather_observed_dewpoint_celsius", "weather_observed_relative_humidity_percent",
+		"weather_observed_temperature_celsius", "weather_observed_wind_speed_kmh"); err != nil {
+		t.Error(err)
+	}
+
+	// Only the location with saved data gets save timestamps
+	if got := testutil.CollectAndCount(collector, "weather_last_forecast_save_timestamp_seconds", "weather_last_observation_save_timestamp_seconds"); got != 2 {
+		t.Errorf("published %d save timestamps, want 2", got)
+	}
+}
+
+func TestWeatherCollectorErrors(t *testing.T) {
+	var logged bytes.Buffer
+	collector := NewWeatherCollector(&fakeLister{err: errors.New("config file is not valid")}, DefaultMaxLeadTime)
+	collector.Logger = log.New(&logged, "", 0)
+
+	expected := `
+# HELP weather_exporter_scrape_error 1 if reading the database failed during the last scrape, 0 otherwise.
+# TYPE weather_exporter_scrape_error gauge
+weather_exporter_scrape_error 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "weather_exporter_scrape_error"); err != nil {
+		t.Error(err)
+	}
+	if want := "metrics: failed to list locations: config file is not valid"; !strings.Contains(logged.String(), want) {
+		t.Errorf("logged %q, want %q", logged.String(), want)
+	}
+
+	// The alert series are still published
+	if got := testutil.CollectAndCount(collector, "weather_active_alerts"); got != len(alertSeverities) {
+		t.Errorf("published %d alert series, want %d", got, len(alertSeverities))
+	}
+}
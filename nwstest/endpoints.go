@@ -0,0 +1,536 @@
+package nwstest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// Forecast lengths matching the real API
+const (
+	dailyPeriods  = 14
+	hourlyPeriods = 156
+	gridHours     = 156
+)
+
+// points serves /points/{lat},{lon}
+func (h *Handler) points(w http.ResponseWriter, r *http.Request) {
+	lat, lon, ok := parseLatLon(r.PathValue("point"))
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "Invalid Parameter", fmt.Sprintf("Unable to provide data for requested point %s", r.PathValue("point")))
+		return
+	}
+
+	p := pointAt(lat, lon)
+	base := baseURL(r)
+	grid := base + p.gridPath()
+
+	writeJSON(w, types.PointsResponse{Properties: types.PointsProperties{
+		GridID:              p.wfo(),
+		GridX:               p.x,
+		GridY:               p.y,
+		Forecast:            grid + "/forecast",
+		ForecastHourly:      grid + "/forecast/hourly",
+		ForecastGridData:    grid,
+		ObservationStations: grid + "/stations",
+		TimeZone:            p.timeZoneName(),
+		ForecastZone:        base + "/zones/forecast/" + p.zoneID(),
+		County:              base + "/zones/county/" + fmt.Sprintf("FKC%04d%04d", p.x, p.y),
+	}})
+}
+
+// gridPoint returns the grid point of a /gridpoints request, writing a 404 if there is none
+func gridPoint(w http.ResponseWriter, r *http.Request) (point, bool) {
+	p, ok := parseGridPath(r.PathValue("wfo"), r.PathValue("xy"))
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("Grid point %s/%s does not exist", r.PathValue("wfo"), r.PathValue("xy")))
+	}
+	return p, ok
+}
+
+// forecastUnitsOf returns the units requested with the units query parameter, us by default
+func forecastUnitsOf(w http.ResponseWriter, r *http.Request) (forecastUnits, bool) {
+	switch units := r.URL.Query().Get("units"); units {
+	case "", "us":
+		return forecastUnits{}, true
+	case "si":
+		return forecastUnits{si: true}, true
+	default:
+		writeProblem(w, http.StatusBadRequest, "Invalid Parameter", fmt.Sprintf("Parameter \"units\" is invalid: %q", units))
+		return forecastUnits{}, false
+	}
+}
+
+// forecastProperties returns the update times shared by the forecasts of the current hour, so
+// fetching twice within an hour returns the same forecast update
+func (h *Handler) forecastProperties(periods []types.ForecastPeriod) types.ForecastProperties {
+	now := h.now().UTC()
+	return types.ForecastProperties{
+		GeneratedAt: now.Format(time.RFC3339),
+		UpdateTime:  now.Truncate(time.Hour).Format(time.RFC3339),
+		Periods:     periods,
+	}
+}
+
+// forecast serves /gridpoints/{wfo}/{x},{y}/forecast: day and night periods for a week
+func (h *Handler) forecast(w http.ResponseWriter, r *http.Request) {
+	p, ok := gridPoint(w, r)
+	if !ok {
+		return
+	}
+	units, ok := forecastUnitsOf(w, r)
+	if !ok {
+		return
+	}
+
+	now := h.now().In(p.zone())
+	start := now.Truncate(time.Hour)
+
+	var periods []types.ForecastPeriod
+	for len(periods) < dailyPeriods {
+		isDaytime := p.isDaytime(start)
+		end := time.Date(start.Year(), start.Month(), start.Day(), 18, 0, 0, 0, start.Location())
+		if !isDaytime {
+			end = time.Date(start.Year(), start.Month(), start.Day(), 6, 0, 0, 0, start.Location())
+			if start.Hour() >= 18 {
+				end = end.AddDate(0, 0, 1)
+			}
+		}
+
+		periods = append(periods, p.forecastPeriod(len(periods)+1, now, start, end, isDaytime, units))
+		start = end
+	}
+
+	writeJSON(w, types.ForecastResponse{Properties: h.forecastProperties(periods)})
+}
+
+// forecastPeriod summarizes the hours of a day or night period
+func (p point) forecastPeriod(number int, now, start, end time.Time, isDaytime bool, units forecastUnits) types.ForecastPeriod {
+	temperature := math.Inf(-1)
+	if !isDaytime {
+		temperature = math.Inf(1)
+	}
+	lowWind, highWind, pop, sky := math.Inf(1), 0.0, 0.0, 0.0
+	var gust *float64
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		if isDaytime {
+			temperature = math.Max(temperature, p.temperature(t))
+		} else {
+			temperature = math.Min(temperature, p.temperature(t))
+		}
+		lowWind = math.Min(lowWind, p.windSpeed(t))
+		highWind = math.Max(highWind, p.windSpeed(t))
+		pop = math.Max(pop, p.precipitationChance(t))
+		sky = math.Max(sky, p.skyCover(t))
+		if g := p.windGust(t); g != nil && (gust == nil || *g > *gust) {
+			gust = g
+		}
+	}
+
+	middle := start.Add(end.Sub(start) / 2)
+	direction := compass(p.windDirection(middle))
+	shortForecast := conditions(isDaytime, pop, sky, temperature)
+
+	extreme := "high"
+	if !isDaytime {
+		extreme = "low"
+	}
+	detailed := fmt.Sprintf("%s, with a %s near %d. %s wind %s", shortForecast, extreme,
+		units.temperature(temperature), compassName(direction), units.speedRange(lowWind, highWind))
+	if gust != nil {
+		detailed += fmt.Sprintf(", with gusts as high as %d %s", units.speed(*gust), units.speedUnit())
+	}
+	detailed += "."
+	if pop >= 20 {
+		detailed += fmt.Sprintf(" Chance of precipitation is %.0f%%.", pop)
+	}
+
+	return types.ForecastPeriod{
+		Number:                     number,
+		Name:                       periodName(now, start, isDaytime, number == 1),
+		StartTime:                  start.Format(time.RFC3339),
+		EndTime:                    end.Format(time.RFC3339),
+		IsDaytime:                  isDaytime,
+		Temperature:                units.temperature(temperature),
+		TemperatureUnit:            units.temperatureUnit(),
+		WindSpeed:                  units.speedRange(lowWind, highWind),
+		WindDirection:              direction,
+		Icon:                       iconURL(isDaytime, shortForecast),
+		ShortForecast:              shortForecast,
+		DetailedForecast:           detailed,
+		ProbabilityOfPrecipitation: quantity(pop, "percent"),
+	}
+}
+
+// periodName names a daily period the way NWS does: "Overnight", "Today", "Tonight", then
+// weekdays such as "Tuesday" and "Tuesday Night"
+func periodName(now, start time.Time, isDaytime, first bool) string {
+	sameDay := start.Year() == now.Year() && start.YearDay() == now.YearDay()
+	switch {
+	case first && !isDaytime && start.Hour() < 6:
+		return "Overnight"
+	case sameDay && isDaytime:
+		return "Today"
+	case sameDay:
+		return "Tonight"
+	case isDaytime:
+		return start.Weekday().String()
+	}
+	return start.Weekday().String() + " Night"
+}
+
+// iconURL returns an NWS style icon URL for a period's conditions
+func iconURL(isDaytime bool, shortForecast string) string {
+	icon := "skc"
+	switch {
+	case shortForecast == "Mostly Cloudy":
+		icon = "bkn"
+	case shortForecast == "Partly Sunny" || shortForecast == "Partly Cloudy":
+		icon = "sct"
+	case shortForecast == "Mostly Sunny" || shortForecast == "Mostly Clear":
+		icon = "few"
+	case shortForecast != "Sunny" && shortForecast != "Clear":
+		icon = "rain_showers"
+	}
+
+	timeOfDay := "day"
+	if !isDaytime {
+		timeOfDay = "night"
+	}
+	return fmt.Sprintf("https://api.weather.gov/icons/land/%s/%s?size=medium", timeOfDay, icon)
+}
+
+// hourlyForecast serves /gridpoints/{wfo}/{x},{y}/forecast/hourly
+func (h *Handler) hourlyForecast(w http.ResponseWriter, r *http.Request) {
+	p, ok := gridPoint(w, r)
+	if !ok {
+		return
+	}
+	units, ok := forecastUnitsOf(w, r)
+	if !ok {
+		return
+	}
+
+	start := h.now().In(p.zone()).Truncate(time.Hour)
+
+	periods := make([]types.ForecastPeriod, 0, hourlyPeriods)
+	for i := 0; i < hourlyPeriods; i++ {
+		t := start.Add(time.Duration(i) * time.Hour)
+		isDaytime := p.isDaytime(t)
+		pop := p.precipitationChance(t)
+		shortForecast := conditions(isDaytime, pop, p.skyCover(t), p.temperature(t))
+
+		periods = append(periods, types.ForecastPeriod{
+			Number:                     i + 1,
+			StartTime:                  t.Format(time.RFC3339),
+			EndTime:                    t.Add(time.Hour).Format(time.RFC3339),
+			IsDaytime:                  isDaytime,
+			Temperature:                units.temperature(p.temperature(t)),
+			TemperatureUnit:            units.temperatureUnit(),
+			WindSpeed:                  fmt.Sprintf("%d %s", units.speed(p.windSpeed(t)), units.speedUnit()),
+			WindDirection:              compass(p.windDirection(t)),
+			Icon:                       iconURL(isDaytime, shortForecast),
+			ShortForecast:              shortForecast,
+			ProbabilityOfPrecipitation: quantity(pop, "percent"),
+			Dewpoint:                   quantity(p.dewpoint(t), "degC"),
+			RelativeHumidity:           quantity(math.Round(p.relativeHumidity(t)), "percent"),
+		})
+	}
+
+	writeJSON(w, types.ForecastResponse{Properties: h.forecastProperties(periods)})
+}
+
+// gridData serves /gridpoints/{wfo}/{x},{y}: hourly numeric layers in SI units
+func (h *Handler) gridData(w http.ResponseWriter, r *http.Request) {
+	p, ok := gridPoint(w, r)
+	if !ok {
+		return
+	}
+
+	now := h.now().UTC()
+	start := now.Truncate(time.Hour)
+
+	layer := func(uom string, value func(t time.Time) *float64) types.GridLayer {
+		l := types.GridLayer{Uom: "wmoUnit:" + uom}
+		for i := 0; i < gridHours; i++ {
+			t := start.Add(time.Duration(i) * time.Hour)
+			v := value(t)
+			if v != nil {
+				rounded := math.Round(*v*100) / 100
+				v = &rounded
+			}
+			l.Values = append(l.Values, types.GridValue{ValidTime: t.Format(time.RFC3339) + "/PT1H", Value: v})
+		}
+		return l
+	}
+	value := func(f func(t time.Time) float64) func(t time.Time) *float64 {
+		return func(t time.Time) *float64 {
+			v := f(t)
+			return &v
+		}
+	}
+	// Rain falls in the hours with a high chance of precipitation
+	rain := func(t time.Time) float64 {
+		if pop := p.precipitationChance(t); pop >= 50 && p.random("rain", t.Unix()/3600) < pop/100 {
+			return 0.2 + 2*p.random("rain-amount", t.Unix()/3600)
+		}
+		return 0
+	}
+	snow := func(t time.Time) float64 {
+		if p.temperature(t) > 0 {
+			return 0
+		}
+		return rain(t) * 10
+	}
+
+	writeJSON(w, types.GridDataResponse{Properties: types.GridDataProperties{
+		UpdateTime:                 now.Truncate(time.Hour).Format(time.RFC3339),
+		ValidTimes:                 start.Format(time.RFC3339) + fmt.Sprintf("/PT%dH", gridHours),
+		Temperature:                layer("degC", value(p.temperature)),
+		Dewpoint:                   layer("degC", value(p.dewpoint)),
+		RelativeHumidity:           layer("percent", value(p.relativeHumidity)),
+		ApparentTemperature:        layer("degC", value(p.temperature)),
+		SkyCover:                   layer("percent", value(p.skyCover)),
+		WindDirection:              layer("degree_(angle)", value(p.windDirection)),
+		WindSpeed:                  layer("km_h-1", value(p.windSpeed)),
+		WindGust:                   layer("km_h-1", p.windGust),
+		ProbabilityOfPrecipitation: layer("percent", value(p.precipitationChance)),
+		QuantitativePrecipitation:  layer("mm", value(rain)),
+		SnowfallAmount:             layer("mm", value(snow)),
+		IceAccumulation:            layer("mm", value(func(time.Time) float64 { return 0 })),
+	}})
+}
+
+// stationList serves /gridpoints/{wfo}/{x},{y}/stations
+func (h *Handler) stationList(w http.ResponseWriter, r *http.Request) {
+	p, ok := gridPoint(w, r)
+	if !ok {
+		return
+	}
+
+	var stations types.StationCollection
+	for i, id := range p.stationIDs() {
+		stations.Features = append(stations.Features, types.Station{Properties: types.StationProperties{
+			StationIdentifier: id,
+			Name:              fmt.Sprintf("%s Fake Station %d", p.wfo(), i+1),
+			TimeZone:          p.timeZoneName(),
+		}})
+	}
+
+	writeJSON(w, stations)
+}
+
+// stationPoint returns the grid point of a /stations request, writing a 404 if there is none
+func stationPoint(w http.ResponseWriter, r *http.Request) (point, bool) {
+	p, ok := parseCellID(r.PathValue("id"), "FK", "FL")
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("Station %s not found", r.PathValue("id")))
+	}
+	return p, ok
+}
+
+// observation returns the observation a station reports at t
+func (p point) observation(base, stationID string, t time.Time) types.ObservationResponse {
+	hour := t.Unix() / 3600
+	noise := func(kind string, scale float64) float64 { return (p.random(kind, hour)*2 - 1) * scale }
+
+	temperature := p.temperature(t) + noise("obs-temperature", 1.5)
+	dewpoint := math.Min(temperature, p.dewpoint(t)+noise("obs-dewpoint", 1))
+	speed := math.Max(0, p.windSpeed(t)+noise("obs-wind", 5))
+	pop := p.precipitationChance(t)
+
+	precipitation := 0.0
+	description := conditions(p.isDaytime(t), 0, p.skyCover(t), temperature)
+	if pop >= 50 && p.random("rain", hour) < pop/100 {
+		precipitation = 0.2 + 2*p.random("rain-amount", hour)
+		description = "Light Rain"
+		if temperature <= 0 {
+			description = "Light Snow"
+		}
+	}
+
+	var gust *float64
+	if g := p.windGust(t); g != nil {
+		observed := *g + noise("obs-gust", 5)
+		gust = &observed
+	}
+
+	magnus := func(c float64) float64 { return math.Exp(17.625 * c / (243.04 + c)) }
+	pressure := 101325 + 1500*p.smoothRandom("pressure", t)
+
+	return types.ObservationResponse{Properties: types.ObservationProperties{
+		Station:               base + "/stations/" + stationID,
+		Timestamp:             t.UTC().Format(time.RFC3339),
+		TextDescription:       description,
+		Temperature:           quantity(temperature, "degC"),
+		Dewpoint:              quantity(dewpoint, "degC"),
+		WindDirection:         quantity(math.Round(p.windDirection(t)/10)*10, "degree_(angle)"),
+		WindSpeed:             quantity(speed, "km_h-1"),
+		WindGust:              optionalQuantity(gust, "km_h-1"),
+		BarometricPressure:    quantity(pressure, "Pa"),
+		SeaLevelPressure:      quantity(pressure+120, "Pa"),
+		Visibility:            quantity(16090-10000*pop/100, "m"),
+		PrecipitationLastHour: quantity(precipitation, "mm"),
+		RelativeHumidity:      quantity(100*magnus(dewpoint)/magnus(temperature), "percent"),
+	}}
+}
+
+// observationTime returns the time of the latest hourly observation at or before t, taken at 53
+// minutes past the hour like most ASOS stations
+func observationTime(t time.Time) time.Time {
+	observed := t.Truncate(time.Hour).Add(53 * time.Minute)
+	if observed.After(t) {
+		observed = observed.Add(-time.Hour)
+	}
+	return observed
+}
+
+// observations serves /stations/{id}/observations, newest first. Without start the last day is
+// returned.
+func (h *Handler) observations(w http.ResponseWriter, r *http.Request) {
+	p, ok := stationPoint(w, r)
+	if !ok {
+		return
+	}
+
+	now := h.now()
+	end, start := now, now.Add(-24*time.Hour)
+	for name, target := range map[string]*time.Time{"start": &start, "end": &end} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "Invalid Parameter", fmt.Sprintf("Parameter %q is invalid: %s", name, value))
+			return
+		}
+		*target = t
+	}
+	if end.After(now) {
+		end = now
+	}
+
+	limit := 500
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 && n < limit {
+			limit = n
+		}
+	}
+
+	var observations types.ObservationCollection
+	for t := observationTime(end); !t.Before(start) && len(observations.Features) < limit; t = t.Add(-time.Hour) {
+		observations.Features = append(observations.Features, p.observation(baseURL(r), r.PathValue("id"), t))
+	}
+
+	writeJSON(w, observations)
+}
+
+// latestObservation serves /stations/{id}/observations/latest
+func (h *Handler) latestObservation(w http.ResponseWriter, r *http.Request) {
+	p, ok := stationPoint(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, p.observation(baseURL(r), r.PathValue("id"), observationTime(h.now())))
+}
+
+// pointAlerts serves /alerts/active?point={lat},{lon}
+func (h *Handler) pointAlerts(w http.ResponseWriter, r *http.Request) {
+	value := r.URL.Query().Get("point")
+	lat, lon, ok := parseLatLon(value)
+	if !ok {
+		writeProblem(w, http.StatusBadRequest, "Invalid Parameter", fmt.Sprintf("Parameter \"point\" is invalid: %q", value))
+		return
+	}
+
+	writeJSON(w, h.activeAlerts(baseURL(r), pointAt(lat, lon)))
+}
+
+// zoneAlerts serves /alerts/active/zone/{zone}
+func (h *Handler) zoneAlerts(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseCellID(r.PathValue("zone"), "FKZ", "FKC")
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("Zone %s not found", r.PathValue("zone")))
+		return
+	}
+
+	writeJSON(w, h.activeAlerts(baseURL(r), p))
+}
+
+// activeAlerts returns the alerts in effect at the grid point: a wind advisory on windy days, a
+// heat advisory on hot days and a winter weather advisory on snowy days, each from 6 AM to 10 PM
+func (h *Handler) activeAlerts(base string, p point) types.AlertCollection {
+	now := h.now().In(p.zone())
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	onset, ends := dayStart.Add(6*time.Hour), dayStart.Add(22*time.Hour)
+
+	alerts := types.AlertCollection{Features: []types.AlertFeature{}}
+	if now.Before(onset.Add(-2*time.Hour)) || !now.Before(ends) {
+		return alerts
+	}
+
+	afternoon := dayStart.Add(15 * time.Hour)
+	morning := dayStart.Add(6 * time.Hour)
+
+	var events []struct{ event, severity, description, instruction string }
+	if p.windSpeed(afternoon) >= 36 {
+		events = append(events, struct{ event, severity, description, instruction string }{
+			"Wind Advisory", "Moderate",
+			"* WHAT...Gusty winds expected.\n\n* IMPACTS...Gusty winds will blow around unsecured objects.",
+			"Use extra caution when driving, especially if operating a high profile vehicle.",
+		})
+	}
+	if p.temperature(afternoon) >= 35 {
+		events = append(events, struct{ event, severity, description, instruction string }{
+			"Heat Advisory", "Moderate",
+			"* WHAT...Hot temperatures expected.\n\n* IMPACTS...Hot temperatures may cause heat illnesses.",
+			"Drink plenty of fluids, stay in an air-conditioned room, and stay out of the sun.",
+		})
+	}
+	if p.temperature(morning) <= 0 && p.precipitationChance(morning) >= 60 {
+		events = append(events, struct{ event, severity, description, instruction string }{
+			"Winter Weather Advisory", "Minor",
+			"* WHAT...Snow expected.\n\n* IMPACTS...Plan on slippery road conditions.",
+			"Slow down and use caution while traveling.",
+		})
+	}
+
+	lat, lon := p.lat(), p.lon()
+	polygon := [][][2]float64{{{lon - 0.2, lat - 0.2}, {lon + 0.2, lat - 0.2}, {lon + 0.2, lat + 0.2}, {lon - 0.2, lat + 0.2}, {lon - 0.2, lat - 0.2}}}
+	sent := onset.Add(-2 * time.Hour)
+
+	for i, e := range events {
+		alerts.Features = append(alerts.Features, types.AlertFeature{
+			Geometry: &types.Geometry{Type: "Polygon", Coordinates: rawJSON(polygon)},
+			Properties: types.Alert{
+				ID:            fmt.Sprintf("urn:oid:2.49.0.1.840.0.fake.%d.%04d%04d.%d", p.day(now), p.x, p.y, i+1),
+				AreaDesc:      fmt.Sprintf("%s Fake County", p.wfo()),
+				AffectedZones: []string{base + "/zones/forecast/" + p.zoneID()},
+				Sent:          sent.Format(time.RFC3339),
+				Effective:     sent.Format(time.RFC3339),
+				Onset:         onset.Format(time.RFC3339),
+				Expires:       ends.Format(time.RFC3339),
+				Ends:          ends.Format(time.RFC3339),
+				Status:        "Actual",
+				MessageType:   "Alert",
+				Category:      "Met",
+				Severity:      e.severity,
+				Certainty:     "Likely",
+				Urgency:       "Expected",
+				Event:         e.event,
+				SenderName:    fmt.Sprintf("NWS %s (fake)", p.wfo()),
+				Headline:      fmt.Sprintf("%s issued %s until %s by NWS %s", e.event, sent.Format("January 2 at 3:04PM"), ends.Format("January 2 at 3:04PM"), p.wfo()),
+				Description:   e.description,
+				Instruction:   e.instruction,
+			},
+		})
+	}
+
+	return alerts
+}
@@ -0,0 +1,178 @@
+package nwstest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FaultKind is a kind of failure the fake API can inject
+type FaultKind string
+
+const (
+	FaultServerError FaultKind = "500"       // 500 Internal Server Error
+	FaultUnavailable FaultKind = "503"       // 503 Service Unavailable
+	FaultRateLimit   FaultKind = "429"       // 429 Too Many Requests with a Retry-After header
+	FaultNotFound    FaultKind = "404"       // 404 Not Found, e.g. a grid point that moved
+	FaultSlow        FaultKind = "slow"      // A normal response after a delay
+	FaultMalformed   FaultKind = "malformed" // A 200 response with truncated JSON
+)
+
+// Default fault parameters
+const (
+	DefaultFaultDelay      = 5 * time.Second
+	DefaultFaultRetryAfter = 1 * time.Second
+)
+
+// Fault makes matching requests fail
+type Fault struct {
+	Kind       FaultKind
+	Path       string        // Only requests whose path contains Path, e.g. "/forecast/hourly"; all if empty
+	Count      int           // Number of matching requests that fail, after which the fault is used up; 0 for all
+	Delay      time.Duration // Delay of FaultSlow responses, DefaultFaultDelay if 0
+	RetryAfter time.Duration // Retry-After of FaultRateLimit responses, DefaultFaultRetryAfter if 0
+}
+
+// activeFault is a fault with the number of requests it has failed so far
+type activeFault struct {
+	Fault
+	used int
+}
+
+// ParseFault parses a fault given as a kind followed by comma separated options, e.g.
+// "500,path=/gridpoints,count=2", "429,retry-after=3s", "slow,delay=10s" or "404,path=/forecast"
+func ParseFault(spec string) (Fault, error) {
+	parts := strings.Split(spec, ",")
+
+	fault := Fault{Kind: FaultKind(strings.TrimSpace(parts[0]))}
+	switch fault.Kind {
+	case FaultServerError, FaultUnavailable, FaultRateLimit, FaultNotFound, FaultSlow, FaultMalformed:
+	default:
+		return Fault{}, fmt.Errorf("unknown fault %q, must be one of 500, 503, 429, 404, slow or malformed", parts[0])
+	}
+
+	for _, option := range parts[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(option), "=")
+		if !ok {
+			return Fault{}, fmt.Errorf("invalid fault option %q in %q, expected key=value", option, spec)
+		}
+
+		var err error
+		switch key {
+		case "path":
+			fault.Path = value
+		case "count":
+			fault.Count, err = strconv.Atoi(value)
+		case "delay":
+			fault.Delay, err = time.ParseDuration(value)
+		case "retry-after":
+			fault.RetryAfter, err = time.ParseDuration(value)
+		default:
+			return Fault{}, fmt.Errorf("unknown fault option %q in %q, must be path, count, delay or retry-after", key, spec)
+		}
+		if err != nil {
+			return Fault{}, fmt.Errorf("invalid fault option %q in %q: %w", option, spec, err)
+		}
+	}
+
+	return fault, nil
+}
+
+// String formats the fault the way ParseFault reads it
+func (f Fault) String() string {
+	result := string(f.Kind)
+	if f.Path != "" {
+		result += ",path=" + f.Path
+	}
+	if f.Count > 0 {
+		result += fmt.Sprintf(",count=%d", f.Count)
+	}
+	if f.Delay > 0 {
+		result += ",delay=" + f.Delay.String()
+	}
+	if f.RetryAfter > 0 {
+		result += ",retry-after=" + f.RetryAfter.String()
+	}
+	return result
+}
+
+// AddFault makes the handler fail matching requests. When several faults match a request the one
+// added first is used.
+func (h *Handler) AddFault(fault Fault) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.faults = append(h.faults, &activeFault{Fault: fault})
+}
+
+// ClearFaults removes every fault
+func (h *Handler) ClearFaults() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.faults = nil
+}
+
+// matchFault returns the first fault that is not used up and matches the request, counting the
+// request against it
+func (h *Handler) matchFault(r *http.Request) *Fault {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, fault := range h.faults {
+		if fault.Count > 0 && fault.used >= fault.Count {
+			continue
+		}
+		if fault.Path != "" && !strings.Contains(r.URL.Path, fault.Path) {
+			continue
+		}
+		fault.used++
+		f := fault.Fault
+		return &f
+	}
+	return nil
+}
+
+// applyFault fails the request if a fault matches it. It reports whether the response has been
+// written; slow responses are delayed and then left to the normal handler.
+func (h *Handler) applyFault(w http.ResponseWriter, r *http.Request) bool {
+	fault := h.matchFault(r)
+	if fault == nil {
+		return false
+	}
+
+	switch fault.Kind {
+	case FaultServerError:
+		writeProblem(w, http.StatusInternalServerError, "Unexpected Problem", "An unexpected problem has occurred (injected fault).")
+	case FaultUnavailable:
+		writeProblem(w, http.StatusServiceUnavailable, "Service Unavailable", "The service is temporarily unavailable (injected fault).")
+	case FaultRateLimit:
+		retryAfter := fault.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = DefaultFaultRetryAfter
+		}
+		// Retry-After is whole seconds, rounded up so clients never retry early
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+		writeProblem(w, http.StatusTooManyRequests, "Too Many Requests", "Request rate limit exceeded (injected fault).")
+	case FaultNotFound:
+		writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("%s was not found (injected fault).", r.URL.Path))
+	case FaultMalformed:
+		w.Header().Set("Content-Type", "application/geo+json")
+		fmt.Fprint(w, `{"properties": {"periods": [{"number": 1, "name": "Tod`)
+	case FaultSlow:
+		delay := fault.Delay
+		if delay <= 0 {
+			delay = DefaultFaultDelay
+		}
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-r.Context().Done():
+			return true
+		case <-timer.C:
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,207 @@
+package nwstest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+func TestParseFault(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Fault
+		wantErr string
+	}{
+		{spec: "500", want: Fault{Kind: FaultServerError}},
+		{spec: "503,count=2", want: Fault{Kind: FaultUnavailable, Count: 2}},
+		{spec: "429,path=/alerts,retry-after=3s", want: Fault{Kind: FaultRateLimit, Path: "/alerts", RetryAfter: 3 * time.Second}},
+		{spec: "404,path=/forecast,count=1", want: Fault{Kind: FaultNotFound, Path: "/forecast", Count: 1}},
+		{spec: "slow,delay=250ms", want: Fault{Kind: FaultSlow, Delay: 250 * time.Millisecond}},
+		{spec: "malformed,path=/gridpoints", want: Fault{Kind: FaultMalformed, Path: "/gridpoints"}},
+		{spec: " 500 , count=1", want: Fault{Kind: FaultServerError, Count: 1}},
+		{spec: "502", wantErr: "unknown fault"},
+		{spec: "500,count", wantErr: "expected key=value"},
+		{spec: "500,color=red", wantErr: "unknown fault option"},
+		{spec: "500,count=many", wantErr: "invalid fault option"},
+		{spec: "slow,delay=5", wantErr: "invalid fault option"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseFault(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseFault(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFault(%q) error = %v", tt.spec, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseFault(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+
+			// String formats the fault so that ParseFault reads it back unchanged
+			again, err := ParseFault(got.String())
+			if err != nil {
+				t.Fatalf("ParseFault(%q) error = %v", got.String(), err)
+			}
+			if again != got {
+				t.Errorf("ParseFault(%q) = %+v, want %+v", got.String(), again, got)
+			}
+		})
+	}
+}
+
+func TestFaultString(t *testing.T) {
+	fault := Fault{Kind: FaultRateLimit, Path: "/alerts", Count: 2, Delay: time.Second, RetryAfter: 1500 * time.Millisecond}
+	if got, want := fault.String(), "429,path=/alerts,count=2,delay=1s,retry-after=1.5s"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+// countingServer starts a fake API server that counts the requests it receives
+func countingServer(t *testing.T) (*Server, *atomic.Int32) {
+	t.Helper()
+
+	handler := NewHandler()
+	var requests atomic.Int32
+	server := &Server{
+		Server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			handler.ServeHTTP(w, r)
+		})),
+		Handler: handler,
+	}
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestFaultRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		faults       []Fault
+		maxBackoff   time.Duration // Overrides the test client's, if set
+		timeout      time.Duration
+		wantStatus   int    // Status of the APIError expected, 0 for none
+		wantErr      string // Error text expected, if no status
+		wantDeadline bool
+		wantRequests int32 // Requests the server receives, 0 to not check
+	}{
+		{
+			name:         "500 retried",
+			faults:       []Fault{{Kind: FaultServerError, Count: 1}},
+			wantRequests: 3, // points failing, points, forecast
+		},
+		{
+			name:         "503 retried",
+			faults:       []Fault{{Kind: FaultUnavailable, Path: "/forecast", Count: 2}},
+			wantRequests: 4, // points, forecast failing twice, forecast
+		},
+		{
+			name:         "500 until out of attempts",
+			faults:       []Fault{{Kind: FaultServerError}},
+			wantStatus:   http.StatusInternalServerError,
+			wantRequests: 4, // every attempt of the default policy
+		},
+		{
+			name:         "429 waits for Retry-After",
+			faults:       []Fault{{Kind: FaultRateLimit, Count: 1, RetryAfter: time.Second}},
+			maxBackoff:   2 * time.Second,
+			wantRequests: 3,
+		},
+		{
+			name:         "429 Retry-After above max backoff",
+			faults:       []Fault{{Kind: FaultRateLimit, RetryAfter: 3 * time.Second}},
+			wantStatus:   http.StatusTooManyRequests,
+			wantRequests: 1,
+		},
+		{
+			name:         "404 refreshes points",
+			faults:       []Fault{{Kind: FaultNotFound, Path: "/forecast", Count: 1}},
+			wantRequests: 4, // points, forecast missing, points again, forecast
+		},
+		{
+			name:         "404 after refresh",
+			faults:       []Fault{{Kind: FaultNotFound, Path: "/forecast"}},
+			wantStatus:   http.StatusNotFound,
+			wantRequests: 4, // 404 is not retried, only looked up again once
+		},
+		{
+			name:         "slow within timeout",
+			faults:       []Fault{{Kind: FaultSlow, Delay: 20 * time.Millisecond}},
+			wantRequests: 2,
+		},
+		{
+			name:         "slow past timeout",
+			faults:       []Fault{{Kind: FaultSlow, Delay: 5 * time.Second}},
+			timeout:      100 * time.Millisecond,
+			wantDeadline: true,
+		},
+		{
+			name:         "malformed not retried",
+			faults:       []Fault{{Kind: FaultMalformed, Path: "/forecast"}},
+			wantErr:      "failed to decode response",
+			wantRequests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := countingServer(t)
+			for _, fault := range tt.faults {
+				server.AddFault(fault)
+			}
+
+			client := server.Client()
+			if tt.maxBackoff > 0 {
+				client.RetryPolicy.MaxBackoff = tt.maxBackoff
+			}
+
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = 10 * time.Second
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			forecast, err := client.GetForecastByCoordinatesContext(ctx, 39.7456, -97.0892)
+
+			var apiErr *types.APIError
+			switch {
+			case tt.wantStatus != 0:
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+					t.Fatalf("error = %v, want API error %d", err, tt.wantStatus)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+			case tt.wantDeadline:
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("error = %v", err)
+				}
+				if len(forecast.Properties.Periods) == 0 {
+					t.Fatal("forecast has no periods")
+				}
+			}
+
+			if tt.wantRequests != 0 && requests.Load() != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", requests.Load(), tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package nwstest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// TestForecastSaveHistory fetches hourly forecasts from the fake API as the forecast command
+// does with --save, then reads them back the way the history command does
+func TestForecastSaveHistory(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, time.UTC)
+	server.Now = func() time.Time { return now }
+
+	client := server.Client()
+	ctx := context.Background()
+	lat, lon := 39.7456, -97.0892
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		wantNewRun bool
+		want       types.SaveResult
+	}{
+		{name: "first fetch", wantNewRun: true, want: types.SaveResult{Inserted: hourlyPeriods}},
+		{name: "same update", advance: 10 * time.Minute, want: types.SaveResult{Unchanged: hourlyPeriods}},
+		{name: "next update", advance: time.Hour, wantNewRun: true, want: types.SaveResult{Inserted: hourlyPeriods}},
+	}
+
+	var runIDs []uint
+	for _, step := range steps {
+		now = now.Add(step.advance)
+
+		forecast, err := client.GetHourlyForecastByCoordinatesContext(ctx, lat, lon)
+		if err != nil {
+			t.Fatalf("%s: failed to get forecast: %v", step.name, err)
+		}
+		result, err := types.SaveForecastToDBContext(ctx, forecast, lat, lon, true)
+		if err != nil {
+			t.Fatalf("%s: failed to save forecast: %v", step.name, err)
+		}
+
+		if result.NewRun != step.wantNewRun || result.Inserted != step.want.Inserted ||
+			result.Updated != step.want.Updated || result.Unchanged != step.want.Unchanged {
+			t.Fatalf("%s: saved %s (new run %v), want %d inserted, %d updated, %d unchanged (new run %v)",
+				step.name, result, result.NewRun, step.want.Inserted, step.want.Updated, step.want.Unchanged, step.wantNewRun)
+		}
+		if result.NewRun {
+			runIDs = append(runIDs, result.RunID)
+		}
+	}
+
+	// history shows the periods of the newest run
+	latest, err := types.GetLatestForecast(lat, lon, 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 3 {
+		t.Fatalf("GetLatestForecast() returned %d periods, want 3", len(latest))
+	}
+	start := now.Truncate(time.Hour)
+	for i, period := range latest {
+		if period.RunID != runIDs[1] {
+			t.Errorf("period %d is from run %d, want latest run %d", period.PeriodNumber, period.RunID, runIDs[1])
+		}
+		if want := start.Add(time.Duration(i) * time.Hour); !period.StartTime.Equal(want) {
+			t.Errorf("period %d starts %s, want %s", period.PeriodNumber, period.StartTime, want)
+		}
+		if period.TemperatureCelsius == nil || period.DewpointC == nil || period.RelativeHumidity == nil {
+			t.Errorf("period %d is missing numeric values: %+v", period.PeriodNumber, period)
+		}
+	}
+
+	// history --at shows how the forecast for an hour evolved across both runs
+	target := start.Add(2 * time.Hour)
+	evolution, err := types.GetForecastEvolution(lat, lon, target, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evolution) != 2 {
+		t.Fatalf("GetForecastEvolution() returned %d forecasts, want one per run", len(evolution))
+	}
+	seen := map[uint]bool{}
+	for _, forecast := range evolution {
+		seen[forecast.RunID] = true
+	}
+	if !seen[runIDs[0]] || !seen[runIDs[1]] {
+		t.Errorf("GetForecastEvolution() runs = %v, want %v", seen, runIDs)
+	}
+
+	// The hour before the second run was only forecast by the first
+	inRange, err := types.GetForecastsInRange(lat, lon, start.Add(-time.Hour), start, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inRange) != 1 || inRange[0].RunID != runIDs[0] {
+		t.Errorf("GetForecastsInRange() = %d forecasts, want 1 from run %d", len(inRange), runIDs[0])
+	}
+
+	runs, err := types.GetForecastRuns(lat, lon, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != runIDs[1] {
+		t.Errorf("GetForecastRuns() = %+v, want runs %v newest first", runs, runIDs)
+	}
+}
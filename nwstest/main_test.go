@@ -0,0 +1,24 @@
+package nwstest
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/db"
+)
+
+// TestMain runs the tests against a migrated in-memory sqlite database
+func TestMain(m *testing.M) {
+	viper.Set("db.driver", db.DriverSQLite)
+	viper.Set("db.path", ":memory:")
+
+	if _, err := db.GetDB().MigrateUp(0); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate test database: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
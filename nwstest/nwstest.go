@@ -0,0 +1,153 @@
+// Package nwstest provides a fake NWS API for local development and tests. It serves realistic
+// /points, forecast, hourly forecast, gridpoint, station, observation and alert responses for any
+// coordinates, generated from a simple deterministic climate, and can inject faults such as
+// server errors, rate limiting, slow or malformed responses and missing grid points.
+//
+// Start one with NewServer in tests, or run 'weather fake-nws', and point WeatherClient.BaseURL
+// (the nws.base_url setting) at it.
+package nwstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// Handler serves the fake NWS API
+type Handler struct {
+	Now func() time.Time // Clock the responses are generated for, time.Now if nil
+	Log io.Writer        // Optional request log
+
+	mu     sync.Mutex
+	faults []*activeFault
+}
+
+// NewHandler returns a fake NWS API handler with no faults
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// ServeHTTP applies any matching fault, then serves the request
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+
+	if !h.applyFault(rec, r) {
+		h.routes().ServeHTTP(rec, r)
+	}
+
+	if h.Log != nil {
+		fmt.Fprintf(h.Log, "%s %s %d %s\n", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// routes returns the API endpoints
+func (h *Handler) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /points/{point}", h.points)
+	mux.HandleFunc("GET /gridpoints/{wfo}/{xy}", h.gridData)
+	mux.HandleFunc("GET /gridpoints/{wfo}/{xy}/forecast", h.forecast)
+	mux.HandleFunc("GET /gridpoints/{wfo}/{xy}/forecast/hourly", h.hourlyForecast)
+	mux.HandleFunc("GET /gridpoints/{wfo}/{xy}/stations", h.stationList)
+	mux.HandleFunc("GET /stations/{id}/observations", h.observations)
+	mux.HandleFunc("GET /stations/{id}/observations/latest", h.latestObservation)
+	mux.HandleFunc("GET /alerts/active", h.pointAlerts)
+	mux.HandleFunc("GET /alerts/active/zone/{zone}", h.zoneAlerts)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("%s is not an endpoint of the fake NWS API", r.URL.Path))
+	})
+	return mux
+}
+
+// now returns the current time of the handler's clock
+func (h *Handler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+// Server is a fake NWS API listening on a local port
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts a fake NWS API server. Call Close when done.
+func NewServer() *Server {
+	handler := NewHandler()
+	return &Server{Server: httptest.NewServer(handler), Handler: handler}
+}
+
+// Client returns a weather client using the server, with an in-memory points cache and retry
+// backoffs short enough for tests
+func (s *Server) Client() *types.WeatherClient {
+	client := types.NewWeatherClient()
+	client.BaseURL = s.URL
+	client.RetryPolicy.InitialBackoff = 10 * time.Millisecond
+	client.RetryPolicy.MaxBackoff = 50 * time.Millisecond
+	return client
+}
+
+// statusRecorder remembers the status written, for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// baseURL returns the URL the request reached the server at, used in links between resources
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// writeJSON writes a GeoJSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeProblem writes an error in the problem details format used by the NWS API
+func writeProblem(w http.ResponseWriter, status int, title, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":   "https://api.weather.gov/problems/" + strings.ReplaceAll(title, " ", ""),
+		"title":  title,
+		"status": status,
+		"detail": detail,
+	})
+}
+
+// parseLatLon parses coordinates given as "lat,lon"
+func parseLatLon(value string) (float64, float64, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	lat, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
@@ -0,0 +1,303 @@
+package nwstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// point is a cell of the fake forecast grid, a tenth of a degree wide. Grid coordinates, station
+// IDs and zone IDs all encode the cell, so every endpoint can be served without state.
+type point struct {
+	x, y int
+}
+
+// wfos are the forecast offices grid points are spread over
+var wfos = []string{"BOU", "PUB", "GJT", "SLC", "ABQ", "OKX", "LOT", "SEW", "MFR", "FWD", "MIA", "BOX"}
+
+// pointAt returns the grid cell containing the coordinates
+func pointAt(lat, lon float64) point {
+	return point{x: int(math.Round((lon + 180) * 10)), y: int(math.Round((lat + 90) * 10))}
+}
+
+func (p point) lat() float64 { return float64(p.y)/10 - 90 }
+func (p point) lon() float64 { return float64(p.x)/10 - 180 }
+
+// wfo returns the forecast office of the grid point
+func (p point) wfo() string {
+	return wfos[p.hash("wfo", 0)%uint64(len(wfos))]
+}
+
+// gridPath returns the path of the grid point, e.g. "/gridpoints/BOU/750,1297"
+func (p point) gridPath() string {
+	return fmt.Sprintf("/gridpoints/%s/%d,%d", p.wfo(), p.x, p.y)
+}
+
+// stationIDs returns the two observation stations of the grid point, nearest first
+func (p point) stationIDs() []string {
+	return []string{fmt.Sprintf("FK%04d%04d", p.x, p.y), fmt.Sprintf("FL%04d%04d", p.x, p.y)}
+}
+
+// zoneID returns the forecast zone of the grid point
+func (p point) zoneID() string {
+	return fmt.Sprintf("FKZ%04d%04d", p.x, p.y)
+}
+
+// parseGridPath returns the grid point of a gridpoints path's wfo and "x,y" segments
+func parseGridPath(wfo, xy string) (point, bool) {
+	xs, ys, ok := strings.Cut(xy, ",")
+	if !ok {
+		return point{}, false
+	}
+	x, errX := strconv.Atoi(xs)
+	y, errY := strconv.Atoi(ys)
+	p := point{x: x, y: y}
+	if errX != nil || errY != nil || x < 0 || x > 3600 || y < 0 || y > 1800 || p.wfo() != wfo {
+		return point{}, false
+	}
+	return p, true
+}
+
+// parseCellID returns the grid point encoded in a station or zone ID after its prefix
+func parseCellID(id string, prefixes ...string) (point, bool) {
+	for _, prefix := range prefixes {
+		rest, ok := strings.CutPrefix(id, prefix)
+		if !ok || len(rest) != 8 {
+			continue
+		}
+		x, errX := strconv.Atoi(rest[:4])
+		y, errY := strconv.Atoi(rest[4:])
+		if errX == nil && errY == nil {
+			return point{x: x, y: y}, true
+		}
+	}
+	return point{}, false
+}
+
+// zone returns the local time zone of the grid point, a whole number of hours from UTC
+func (p point) zone() *time.Location {
+	offset := int(math.Round(p.lon() / 15))
+	return time.FixedZone(fmt.Sprintf("UTC%+d", offset), offset*3600)
+}
+
+// timeZoneName returns an IANA name for the grid point's time zone
+func (p point) timeZoneName() string {
+	offset := int(math.Round(p.lon() / 15))
+	names := map[int]string{
+		-5: "America/New_York", -6: "America/Chicago", -7: "America/Denver",
+		-8: "America/Los_Angeles", -9: "America/Anchorage", -10: "Pacific/Honolulu",
+	}
+	if name, ok := names[offset]; ok {
+		return name
+	}
+	if offset == 0 {
+		return "Etc/UTC"
+	}
+	// Etc zones count the other way
+	return fmt.Sprintf("Etc/GMT%+d", -offset)
+}
+
+// hash returns a pseudo-random number for the grid point, a kind of value and an index
+func (p point) hash(kind string, index int64) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d,%d,%s,%d", p.x, p.y, kind, index)
+	return h.Sum64()
+}
+
+// random returns a pseudo-random number in [0, 1) for the grid point, a kind of value and an index
+func (p point) random(kind string, index int64) float64 {
+	return float64(p.hash(kind, index)%1000000) / 1000000
+}
+
+// day returns the index of the local day of t
+func (p point) day(t time.Time) int64 {
+	_, offset := t.In(p.zone()).Zone()
+	return int64(math.Floor(float64(t.Unix()+int64(offset)) / 86400))
+}
+
+// smoothRandom interpolates daily random values in [-1, 1) linearly across the hours of the day
+func (p point) smoothRandom(kind string, t time.Time) float64 {
+	_, offset := t.In(p.zone()).Zone()
+	days := float64(t.Unix()+int64(offset)) / 86400
+	day := math.Floor(days)
+	frac := days - day
+	a := p.random(kind, int64(day))*2 - 1
+	b := p.random(kind, int64(day)+1)*2 - 1
+	return a + (b-a)*frac
+}
+
+// temperature returns the temperature in °C: a latitude dependent mean with a seasonal and a
+// daily cycle, peaking at 3 PM local time, and a random anomaly that changes day by day
+func (p point) temperature(t time.Time) float64 {
+	local := t.In(p.zone())
+	absLat := math.Abs(p.lat())
+
+	mean := 27 - 0.45*absLat
+	season := -0.35 * absLat * math.Cos(2*math.Pi*float64(local.YearDay()-20)/365.25)
+	if p.lat() < 0 {
+		season = -season
+	}
+	hour := float64(local.Hour()) + float64(local.Minute())/60
+	daily := 6 * math.Sin(2*math.Pi*(hour-9)/24)
+
+	return mean + season + daily + 5*p.smoothRandom("temperature", t)
+}
+
+// precipitationChance returns the chance of precipitation in %, in steps of 10
+func (p point) precipitationChance(t time.Time) float64 {
+	daily := math.Max(0, p.random("pop", p.day(t))*140-40)
+	hourly := daily * (0.6 + 0.8*p.random("pop-hour", t.Unix()/3600))
+	return math.Min(100, math.Round(hourly/10)*10)
+}
+
+// skyCover returns the sky cover in %
+func (p point) skyCover(t time.Time) float64 {
+	return math.Min(100, p.precipitationChance(t)+40*(p.smoothRandom("sky", t)+1))
+}
+
+// windSpeed returns the wind speed in km/h, strongest in the afternoon
+func (p point) windSpeed(t time.Time) float64 {
+	base := 5 + 35*p.random("wind", p.day(t))
+	hour := float64(t.In(p.zone()).Hour())
+	return base * (0.7 + 0.3*math.Sin(2*math.Pi*(hour-9)/24))
+}
+
+// windGust returns the wind gust in km/h, nil when the wind is not gusty
+func (p point) windGust(t time.Time) *float64 {
+	speed := p.windSpeed(t)
+	if speed < 25 {
+		return nil
+	}
+	gust := speed * 1.5
+	return &gust
+}
+
+// windDirection returns the direction the wind blows from in degrees
+func (p point) windDirection(t time.Time) float64 {
+	direction := 360*p.random("wind-direction", p.day(t)) + 20*p.smoothRandom("wind-veer", t)
+	return math.Mod(direction+360, 360)
+}
+
+// dewpoint returns the dewpoint in °C, closer to the temperature when precipitation is likely
+func (p point) dewpoint(t time.Time) float64 {
+	return p.temperature(t) - 3 - 12*(1-p.precipitationChance(t)/100)
+}
+
+// relativeHumidity returns the relative humidity in % from the temperature and dewpoint
+func (p point) relativeHumidity(t time.Time) float64 {
+	magnus := func(c float64) float64 { return math.Exp(17.625 * c / (243.04 + c)) }
+	return math.Min(100, 100*magnus(p.dewpoint(t))/magnus(p.temperature(t)))
+}
+
+// isDaytime reports whether t is between 6 AM and 6 PM local time
+func (p point) isDaytime(t time.Time) bool {
+	hour := t.In(p.zone()).Hour()
+	return hour >= 6 && hour < 18
+}
+
+// conditions returns the short forecast for a chance of precipitation and sky cover
+func conditions(isDaytime bool, pop, skyCover, temperature float64) string {
+	precipitation := "Rain Showers"
+	if temperature <= 0 {
+		precipitation = "Snow Showers"
+	}
+
+	switch {
+	case pop >= 60:
+		return precipitation + " Likely"
+	case pop >= 30:
+		return "Chance " + precipitation
+	case pop >= 20:
+		return "Slight Chance " + precipitation
+	}
+
+	sky := []string{"Sunny", "Mostly Sunny", "Partly Sunny", "Mostly Cloudy"}
+	if !isDaytime {
+		sky = []string{"Clear", "Mostly Clear", "Partly Cloudy", "Mostly Cloudy"}
+	}
+	return sky[min(3, int(skyCover/25))]
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// compass returns the 16-point compass direction of an angle in degrees
+func compass(degrees float64) string {
+	return compassPoints[int(math.Round(degrees/22.5))%16]
+}
+
+var compassNames = map[string]string{"N": "North", "E": "East", "S": "South", "W": "West"}
+
+// compassName spells out the main directions the way NWS forecast text does
+func compassName(direction string) string {
+	if name, ok := compassNames[direction]; ok {
+		return name
+	}
+	return direction
+}
+
+// forecastUnits converts temperatures and speeds for the units query parameter of forecasts
+type forecastUnits struct {
+	si bool
+}
+
+func (u forecastUnits) temperature(celsius float64) int {
+	if u.si {
+		return int(math.Round(celsius))
+	}
+	return int(math.Round(celsius*9/5 + 32))
+}
+
+func (u forecastUnits) temperatureUnit() string {
+	if u.si {
+		return "C"
+	}
+	return "F"
+}
+
+func (u forecastUnits) speed(kmh float64) int {
+	if u.si {
+		return int(math.Round(kmh))
+	}
+	return int(math.Round(kmh / 1.609344))
+}
+
+func (u forecastUnits) speedUnit() string {
+	if u.si {
+		return "km/h"
+	}
+	return "mph"
+}
+
+// speedRange formats a wind speed range, e.g. "5 to 10 mph"
+func (u forecastUnits) speedRange(low, high float64) string {
+	if u.speed(low) == u.speed(high) {
+		return fmt.Sprintf("%d %s", u.speed(high), u.speedUnit())
+	}
+	return fmt.Sprintf("%d to %d %s", u.speed(low), u.speed(high), u.speedUnit())
+}
+
+// quantity returns a quantitative value as served by the API, rounded to two decimals
+func quantity(value float64, unitCode string) types.QuantitativeValue {
+	rounded := math.Round(value*100) / 100
+	return types.QuantitativeValue{Value: &rounded, UnitCode: "wmoUnit:" + unitCode}
+}
+
+// optionalQuantity returns a quantitative value that may be missing
+func optionalQuantity(value *float64, unitCode string) types.QuantitativeValue {
+	if value == nil {
+		return types.QuantitativeValue{UnitCode: "wmoUnit:" + unitCode}
+	}
+	return quantity(*value, unitCode)
+}
+
+// rawJSON marshals a value that is known to marshal
+func rawJSON(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
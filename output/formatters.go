@@ -0,0 +1,123 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register("json", FormatterFunc(formatJSON))
+	Register("ndjson", FormatterFunc(formatNDJSON))
+	Register("csv", FormatterFunc(formatCSV))
+	Register("tsv", FormatterFunc(formatTSV))
+	Register("yaml", FormatterFunc(formatYAML))
+	Register("table", FormatterFunc(formatTable))
+}
+
+// formatJSON writes the records as an indented JSON array
+func formatJSON(w io.Writer, records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// formatNDJSON writes one compact JSON object per line
+func formatNDJSON(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatCSV writes RFC 4180 CSV with a header row
+func formatCSV(w io.Writer, records []Record) error {
+	writer := csv.NewWriter(w)
+	if columns := Columns(records); columns != nil {
+		writer.Write(columns)
+	}
+	for _, record := range records {
+		row := make([]string, len(record))
+		for i, field := range record {
+			row[i] = FormatValue(field.Value)
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var tsvEscaper = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+// formatTSV writes tab separated values with a header row. Values are not quoted; tabs and
+// line breaks inside values are replaced with spaces.
+func formatTSV(w io.Writer, records []Record) error {
+	if columns := Columns(records); columns != nil {
+		if _, err := fmt.Fprintln(w, strings.Join(columns, "\t")); err != nil {
+			return err
+		}
+	}
+	for _, record := range records {
+		row := make([]string, len(record))
+		for i, field := range record {
+			row[i] = tsvEscaper.Replace(FormatValue(field.Value))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatYAML writes the records as a YAML sequence of mappings with keys in field order
+func formatYAML(w io.Writer, records []Record) error {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, record := range records {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		for _, field := range record {
+			var value yaml.Node
+			if err := value.Encode(field.Value); err != nil {
+				return err
+			}
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Name}, &value)
+		}
+		list.Content = append(list.Content, mapping)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(list); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// formatTable writes the records as space-aligned columns with an upper case header
+func formatTable(w io.Writer, records []Record) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if columns := Columns(records); columns != nil {
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	}
+	for _, record := range records {
+		row := make([]string, len(record))
+		for i, field := range record {
+			row[i] = tsvEscaper.Replace(FormatValue(field.Value))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
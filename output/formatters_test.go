@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestFormatters(t *testing.T) {
+	start := time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC)
+	chance := 20.0
+	records := []Record{
+		{{"name", "Today"}, {"start", start}, {"temperature", 61}, {"precipitation", &chance}, {"forecast", "Sunny, then\n\"breezy\""}},
+		{{"name", "Tonight"}, {"start", start.Add(12 * time.Hour)}, {"temperature", 38}, {"precipitation", (*float64)(nil)}, {"forecast", "Clear\tand cold"}},
+	}
+
+	tests := []struct {
+		format    string
+		want      string
+		wantEmpty string
+	}{
+		{
+			format: "json",
+			want: `[
+  {
+    "name": "Today",
+    "start": "2026-10-17T06:00:00Z",
+    "temperature": 61,
+    "precipitation": 20,
+    "forecast": "Sunny, then\n\"breezy\""
+  },
+  {
+    "name": "Tonight",
+    "start": "2026-10-17T18:00:00Z",
+    "temperature": 38,
+    "precipitation": null,
+    "forecast": "Clear\tand cold"
+  }
+]
+`,
+			wantEmpty: "[]\n",
+		},
+		{
+			format: "ndjson",
+			want: `{"name":"Today","start":"2026-10-17T06:00:00Z","temperature":61,"precipitation":20,"forecast":"Sunny, then\n\"breezy\""}
+{"name":"Tonight","start":"2026-10-17T18:00:00Z","temperature":38,"precipitation":null,"forecast":"Clear\tand cold"}
+`,
+		},
+		{
+			format: "csv",
+			want: `name,start,temperature,precipitation,forecast
+Today,2026-10-17T06:00:00Z,61,20,"Sunny, then
+""breezy"""
+Tonight,2026-10-17T18:00:00Z,38,,Clear	and cold
+`,
+		},
+		{
+			format: "tsv",
+			want: "name\tstart\ttemperature\tprecipitation\tforecast\n" +
+				"Today\t2026-10-17T06:00:00Z\t61\t20\tSunny, then \"breezy\"\n" +
+				"Tonight\t2026-10-17T18:00:00Z\t38\t\tClear and cold\n",
+		},
+		{
+			format: "yaml",
+			want: `- name: Today
+  start: 2026-10-17T06:00:00Z
+  temperature: 61
+  precipitation: 20
+  forecast: |-
+    Sunny, then
+    "breezy"
+- name: Tonight
+  start: 2026-10-17T18:00:00Z
+  temperature: 38
+  precipitation: null
+  forecast: "Clear\tand cold"
+`,
+			wantEmpty: "[]\n",
+		},
+		{
+			format: "table",
+			want: `NAME     START                 TEMPERATURE  PRECIPITATION  FORECAST
+Today    2026-10-17T06:00:00Z  61           20             Sunny, then "breezy"
+Tonight  2026-10-17T18:00:00Z  38                          Clear and cold
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, records); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+
+			buf.Reset()
+			if err := Write(&buf, tt.format, nil); err != nil {
+				t.Fatalf("Write() without records error = %v", err)
+			}
+			if buf.String() != tt.wantEmpty {
+				t.Errorf("Write() without records = %q, want %q", buf.String(), tt.wantEmpty)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package output renders command results in machine-readable formats. Commands convert their
+// results to records, which any registered formatter can write.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Text is the default output mode. It is not a registered formatter: each command prints its
+// own human-readable prose for it.
+const Text = "text"
+
+// Field is a named value of a record
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record is a single result row. Fields keep their order so columns and keys are stable.
+type Record []Field
+
+// Formatter writes records to w in a particular format
+type Formatter interface {
+	Format(w io.Writer, records []Record) error
+}
+
+// FormatterFunc adapts a function to the Formatter interface
+type FormatterFunc func(w io.Writer, records []Record) error
+
+// Format calls f(w, records)
+func (f FormatterFunc) Format(w io.Writer, records []Record) error {
+	return f(w, records)
+}
+
+var formatters = map[string]Formatter{}
+
+// Register makes a formatter available under name, replacing any formatter already registered with it
+func Register(name string, formatter Formatter) {
+	formatters[name] = formatter
+}
+
+// Get returns the formatter registered under name
+func Get(name string) (Formatter, error) {
+	formatter, ok := formatters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q, must be %s or one of %s", name, Text, strings.Join(Names(), ", "))
+	}
+	return formatter, nil
+}
+
+// Names returns the names of the registered formatters, sorted
+func Names() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that name is either the text mode or a registered formatter
+func Validate(name string) error {
+	if name == Text {
+		return nil
+	}
+	_, err := Get(name)
+	return err
+}
+
+// Write formats records with the formatter registered under name
+func Write(w io.Writer, name string, records []Record) error {
+	formatter, err := Get(name)
+	if err != nil {
+		return err
+	}
+	return formatter.Format(w, records)
+}
+
+// Columns returns the field names of the first record, used as the header of tabular formats
+func Columns(records []Record) []string {
+	if len(records) == 0 {
+		return nil
+	}
+	columns := make([]string, len(records[0]))
+	for i, field := range records[0] {
+		columns[i] = field.Name
+	}
+	return columns
+}
+
+// MarshalJSON encodes the record as a JSON object with its keys in field order
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// FormatValue renders a field value as a plain string for tabular formats. Nil values and nil
+// pointers are empty, times are RFC3339.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case *float64:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	want := []string{"csv", "json", "ndjson", "table", "tsv", "yaml"}
+	if got := Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: Text},
+		{name: "json"},
+		{name: "csv"},
+		{name: "xml", wantErr: true},
+		{name: "JSON", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := Validate(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	_, err := Get("xml")
+	if want := `unknown output format "xml", must be text or one of csv, json, ndjson, table, tsv, yaml`; err == nil || err.Error() != want {
+		t.Errorf("Get() error = %v, want %q", err, want)
+	}
+	if err := Write(io.Discard, "xml", nil); err == nil {
+		t.Error("Write() with an unknown format error = nil")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	saved := formatters["csv"]
+	t.Cleanup(func() {
+		formatters["csv"] = saved
+		delete(formatters, "count")
+	})
+
+	count := FormatterFunc(func(w io.Writer, records []Record) error {
+		_, err := io.WriteString(w, strings.Repeat("*", len(records)))
+		return err
+	})
+	Register("count", count)
+	Register("csv", count)
+
+	for _, name := range []string{"count", "csv"} {
+		var buf bytes.Buffer
+		if err := Write(&buf, name, make([]Record, 3)); err != nil {
+			t.Fatalf("Write(%s) error = %v", name, err)
+		}
+		if buf.String() != "***" {
+			t.Errorf("Write(%s) = %q, want the registered formatter's output", name, buf.String())
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	at := time.Date(2026, 10, 17, 14, 30, 0, 0, time.FixedZone("MDT", -6*60*60))
+	temperature := 21.5
+	chance := 40
+	var nilTime *time.Time
+	var nilFloat *float64
+	var nilInt *int
+
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"Sunny", "Sunny"},
+		{at, "2026-10-17T14:30:00-06:00"},
+		{&at, "2026-10-17T14:30:00-06:00"},
+		{nilTime, ""},
+		{21.0, "21"},
+		{&temperature, "21.5"},
+		{nilFloat, ""},
+		{&chance, "40"},
+		{nilInt, ""},
+		{72, "72"},
+		{true, "true"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatValue(tt.value); got != tt.want {
+			t.Errorf("FormatValue(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRecordMarshalJSON(t *testing.T) {
+	record := Record{{"zeta", 1}, {"alpha", "a \"quoted\" value"}, {"missing", nil}, {"nested", []int{1, 2}}}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"zeta":1,"alpha":"a \"quoted\" value","missing":null,"nested":[1,2]}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	if _, err := json.Marshal(Record{{"bad", func() {}}}); err == nil {
+		t.Error("Marshal() of an unsupported value error = nil")
+	}
+}
+
+func TestColumns(t *testing.T) {
+	if got := Columns(nil); got != nil {
+		t.Errorf("Columns(nil) = %v, want nil", got)
+	}
+
+	records := []Record{{{"name", "Today"}, {"temperature", 61}}, {{"other", 1}}}
+	if got := Columns(records); !reflect.DeepEqual(got, []string{"name", "temperature"}) {
+		t.Errorf("Columns() = %v, want the fields of the first record", got)
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds the number of live responses cached at once
+const maxCacheEntries = 1000
+
+// cache holds live responses until they expire, evicting the entries closest to expiry once it
+// holds maxEntries
+type cache struct {
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	maxEntries int
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newCache(maxEntries int) *cache {
+	return &cache{entries: make(map[string]cacheEntry), maxEntries: maxEntries}
+}
+
+// get returns the cached value for key if it has not expired
+func (c *cache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// set caches value under key for ttl, dropping any other expired entries and, when the cache is
+// full, the entry closest to expiry
+func (c *cache) set(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	if _, ok := c.entries[key]; !ok && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		oldest := ""
+		for k, entry := range c.entries {
+			if oldest == "" || entry.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+
+	c.entries[key] = cacheEntry{value: value, expires: now.Add(ttl)}
+}
@@ -0,0 +1,474 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// defaultHistoryRange is how far back /v1/history goes when from is not given
+const defaultHistoryRange = 7 * 24 * time.Hour
+
+// maxHistoryRange bounds the range of one /v1/history request, so a single request cannot load
+// the whole forecast table
+const maxHistoryRange = 31 * 24 * time.Hour
+
+// Response sources
+const (
+	sourceDatabase = "database"
+	sourceLive     = "live"
+)
+
+// forecastResponse is the response of /v1/forecast
+type forecastResponse struct {
+	Location    string                  `json:"location,omitempty"`
+	Latitude    float64                 `json:"latitude"`
+	Longitude   float64                 `json:"longitude"`
+	Hourly      bool                    `json:"hourly"`
+	Source      string                  `json:"source"` // "database" or "live"
+	Provider    string                  `json:"provider,omitempty"`
+	RetrievedAt *time.Time              `json:"retrieved_at,omitempty"`
+	Periods     []types.WeatherForecast `json:"periods"`
+}
+
+// historyResponse is the response of /v1/history
+type historyResponse struct {
+	Location  string                  `json:"location,omitempty"`
+	Latitude  float64                 `json:"latitude"`
+	Longitude float64                 `json:"longitude"`
+	Hourly    bool                    `json:"hourly"`
+	From      time.Time               `json:"from"`
+	To        time.Time               `json:"to"`
+	Periods   []types.WeatherForecast `json:"periods"`
+}
+
+// alertsResponse is the response of /v1/alerts
+type alertsResponse struct {
+	Source string               `json:"source"` // "database" or "live"
+	Alerts []types.WeatherAlert `json:"alerts"`
+}
+
+// locationsResponse is the response of /v1/locations
+type locationsResponse struct {
+	Locations []types.Location `json:"locations"`
+}
+
+// target is the place a request is for
+type target struct {
+	Location  *types.Location // Nil when coordinates were given directly
+	Latitude  float64
+	Longitude float64
+}
+
+func (t *target) name() string {
+	if t.Location == nil {
+		return ""
+	}
+	return t.Location.Name
+}
+
+// forecast serves the latest saved forecast run, or a live forecast with live=true.
+// Parameters: location or lat and lon, hourly, periods, live and provider.
+func (s *Server) forecast(r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	t, err := s.target(query.Get("location"), query.Get("lat"), query.Get("lon"))
+	if err != nil {
+		return nil, err
+	}
+
+	hourly, err := boolParam(query.Get("hourly"), "hourly")
+	if err != nil {
+		return nil, err
+	}
+	live, err := boolParam(query.Get("live"), "live")
+	if err != nil {
+		return nil, err
+	}
+
+	periods := 0
+	if value := query.Get("periods"); value != "" {
+		if periods, err = strconv.Atoi(value); err != nil || periods < 0 {
+			return nil, badRequest(fmt.Errorf("invalid periods %q", value))
+		}
+	}
+
+	var response *forecastResponse
+	if live {
+		response, err = s.liveForecast(r.Context(), t, hourly, query.Get("provider"))
+	} else {
+		response, err = s.storedForecast(r.Context(), t, hourly)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if periods > 0 && len(response.Periods) > periods {
+		limited := *response
+		limited.Periods = response.Periods[:periods]
+		response = &limited
+	}
+
+	return response, nil
+}
+
+// storedForecast returns the latest saved run, by location ID for stored locations
+func (s *Server) storedForecast(ctx context.Context, t *target, hourly bool) (*forecastResponse, error) {
+	var forecasts []types.WeatherForecast
+	var err error
+	if t.Location != nil && t.Location.ID != 0 {
+		forecasts, err = types.GetLatestForecastForLocationContext(ctx, t.Location.ID, 0, hourly)
+	} else {
+		forecasts, err = types.GetLatestForecastContext(ctx, t.Latitude, t.Longitude, 0, hourly)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved forecast: %w", err)
+	}
+
+	if len(forecasts) == 0 {
+		return nil, &httpError{
+			status: http.StatusNotFound,
+			err:    fmt.Errorf("no saved forecast for %.4f, %.4f; use live=true to fetch one", t.Latitude, t.Longitude),
+		}
+	}
+
+	retrievedAt := forecasts[0].ForecastDate
+	return &forecastResponse{
+		Location:    t.name(),
+		Latitude:    t.Latitude,
+		Longitude:   t.Longitude,
+		Hourly:      hourly,
+		Source:      sourceDatabase,
+		Provider:    forecasts[0].Provider,
+		RetrievedAt: &retrievedAt,
+		Periods:     forecasts,
+	}, nil
+}
+
+// liveCoordinatePrecision is the number of decimals coordinates of live requests are rounded to,
+// about a kilometer, so nearby requests share a cache entry and provider request
+const liveCoordinatePrecision = 2
+
+// rounded returns the target with its coordinates rounded for a live request. Named locations
+// keep their exact coordinates, there is only a limited number of them.
+func (t *target) rounded() *target {
+	if t.Location != nil {
+		return t
+	}
+	scale := math.Pow(10, liveCoordinatePrecision)
+	return &target{Latitude: math.Round(t.Latitude*scale) / scale, Longitude: math.Round(t.Longitude*scale) / scale}
+}
+
+// liveForecast fetches a forecast from the provider, serving it from the cache while it is fresh
+func (s *Server) liveForecast(ctx context.Context, t *target, hourly bool, providerName string) (*forecastResponse, error) {
+	t = t.rounded()
+	if providerName == "" && t.Location != nil {
+		providerName = t.Location.ProviderName()
+	}
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("forecast|%s|%s|%.4f|%.4f|%t", provider.Name(), t.name(), t.Latitude, t.Longitude, hourly)
+	if cached, ok := s.cache.get(key); ok {
+		return cached.(*forecastResponse), nil
+	}
+
+	forecast, err := types.GetProviderForecast(ctx, provider, t.Latitude, t.Longitude, hourly)
+	if err != nil {
+		return nil, providerError(fmt.Errorf("failed to get weather forecast: %w", err))
+	}
+
+	run := &types.ForecastRun{
+		Latitude:    t.Latitude,
+		Longitude:   t.Longitude,
+		IsHourly:    hourly,
+		Provider:    provider.Name(),
+		RetrievedAt: time.Now(),
+	}
+	if t.Location != nil {
+		run.LocationID = t.Location.ID
+	}
+
+	periods, err := types.ForecastPeriods(run, forecast)
+	if err != nil {
+		return nil, &httpError{status: http.StatusBadGateway, err: fmt.Errorf("invalid forecast: %w", err)}
+	}
+
+	response := &forecastResponse{
+		Location:    t.name(),
+		Latitude:    t.Latitude,
+		Longitude:   t.Longitude,
+		Hourly:      hourly,
+		Source:      sourceLive,
+		Provider:    provider.Name(),
+		RetrievedAt: &run.RetrievedAt,
+		Periods:     periods,
+	}
+	s.cache.set(key, response, s.CacheTTL)
+
+	return response, nil
+}
+
+// history serves the saved forecast periods from every run starting within a time range.
+// Parameters: location or lat and lon, from, to and hourly. The range defaults to the last 7 days
+// and can be at most 31 days.
+func (s *Server) history(r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	t, err := s.target(query.Get("location"), query.Get("lat"), query.Get("lon"))
+	if err != nil {
+		return nil, err
+	}
+
+	hourly, err := boolParam(query.Get("hourly"), "hourly")
+	if err != nil {
+		return nil, err
+	}
+
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		if to, err = parseTime(value); err != nil {
+			return nil, badRequest(fmt.Errorf("invalid to: %w", err))
+		}
+	}
+
+	from := to.Add(-defaultHistoryRange)
+	if value := query.Get("from"); value != "" {
+		if from, err = parseTime(value); err != nil {
+			return nil, badRequest(fmt.Errorf("invalid from: %w", err))
+		}
+	}
+
+	if !from.Before(to) {
+		return nil, badRequest(fmt.Errorf("from must be before to"))
+	}
+	if to.Sub(from) > maxHistoryRange {
+		return nil, badRequest(fmt.Errorf("from and to can be at most %d days apart", int(maxHistoryRange/(24*time.Hour))))
+	}
+
+	// Stored locations are matched by ID, so runs saved for the location are found even if its
+	// coordinates were changed since
+	var forecasts []types.WeatherForecast
+	if t.Location != nil && t.Location.ID != 0 {
+		forecasts, err = types.GetForecastsInRangeForLocationContext(r.Context(), t.Location.ID, from, to, hourly)
+	} else {
+		forecasts, err = types.GetForecastsInRangeContext(r.Context(), t.Latitude, t.Longitude, from, to, hourly)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved forecasts: %w", err)
+	}
+	if forecasts == nil {
+		forecasts = []types.WeatherForecast{}
+	}
+
+	return &historyResponse{
+		Location:  t.name(),
+		Latitude:  t.Latitude,
+		Longitude: t.Longitude,
+		Hourly:    hourly,
+		From:      from,
+		To:        to,
+		Periods:   forecasts,
+	}, nil
+}
+
+// locations serves every named location
+func (s *Server) locations(r *http.Request) (interface{}, error) {
+	locations, err := s.Locations.Locations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locations: %w", err)
+	}
+	if locations == nil {
+		locations = []types.Location{}
+	}
+
+	return &locationsResponse{Locations: locations}, nil
+}
+
+// alerts serves the saved alerts that are still active, optionally only those for a zone or a
+// resolved location's zones. With live=true and a location or coordinates, the active alerts
+// are fetched from the provider instead.
+func (s *Server) alerts(r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	live, err := boolParam(query.Get("live"), "live")
+	if err != nil {
+		return nil, err
+	}
+	zone := query.Get("zone")
+
+	var t *target
+	if query.Get("location") != "" || query.Get("lat") != "" || query.Get("lon") != "" {
+		if zone != "" {
+			return nil, badRequest(fmt.Errorf("zone cannot be combined with location or coordinates"))
+		}
+		if t, err = s.target(query.Get("location"), query.Get("lat"), query.Get("lon")); err != nil {
+			return nil, err
+		}
+	}
+
+	if live {
+		if t == nil {
+			return nil, badRequest(fmt.Errorf("live alerts need a location or lat and lon"))
+		}
+		return s.liveAlerts(r.Context(), t, query.Get("provider"))
+	}
+
+	var zones []string
+	switch {
+	case zone != "":
+		zones = []string{zone}
+	case t != nil && t.Location != nil && t.Location.ForecastZone != "":
+		zones = []string{t.Location.ForecastZone, t.Location.County}
+	case t != nil:
+		return nil, badRequest(fmt.Errorf("saved alerts can only be filtered by zone or by a location with NWS zones; use live=true for coordinates"))
+	}
+
+	stored, err := types.GetActiveStoredAlertsContext(r.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved alerts: %w", err)
+	}
+
+	alerts := []types.WeatherAlert{}
+	for _, alert := range stored {
+		if len(zones) == 0 || affectsZone(&alert, zones) {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return &alertsResponse{Source: sourceDatabase, Alerts: alerts}, nil
+}
+
+// liveAlerts fetches the active alerts from the provider, serving them from the cache while they are fresh
+func (s *Server) liveAlerts(ctx context.Context, t *target, providerName string) (*alertsResponse, error) {
+	t = t.rounded()
+	if providerName == "" && t.Location != nil {
+		providerName = t.Location.ProviderName()
+	}
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("alerts|%s|%.4f|%.4f", provider.Name(), t.Latitude, t.Longitude)
+	if cached, ok := s.cache.get(key); ok {
+		return cached.(*alertsResponse), nil
+	}
+
+	fetched, err := types.GetProviderAlerts(ctx, provider, t.Latitude, t.Longitude)
+	if err != nil {
+		return nil, providerError(fmt.Errorf("failed to get alerts: %w", err))
+	}
+
+	alerts := make([]types.WeatherAlert, 0, len(fetched))
+	for i := range fetched {
+		alerts = append(alerts, types.NewWeatherAlert(&fetched[i]))
+	}
+
+	response := &alertsResponse{Source: sourceLive, Alerts: alerts}
+	s.cache.set(key, response, s.CacheTTL)
+
+	return response, nil
+}
+
+// target resolves the location or lat and lon parameters of a request
+func (s *Server) target(locationName, latValue, lonValue string) (*target, error) {
+	if locationName != "" {
+		if latValue != "" || lonValue != "" {
+			return nil, badRequest(fmt.Errorf("give either location or lat and lon, not both"))
+		}
+
+		loc, err := s.Locations.Location(locationName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up location %q: %w", locationName, err)
+		}
+		if loc == nil {
+			return nil, &httpError{status: http.StatusNotFound, err: fmt.Errorf("location %q not found", locationName)}
+		}
+		return &target{Location: loc, Latitude: loc.Latitude, Longitude: loc.Longitude}, nil
+	}
+
+	if latValue == "" || lonValue == "" {
+		return nil, badRequest(fmt.Errorf("location or lat and lon must be given"))
+	}
+
+	lat, err := strconv.ParseFloat(latValue, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil, badRequest(fmt.Errorf("latitude must be between -90 and 90 degrees"))
+	}
+	lon, err := strconv.ParseFloat(lonValue, 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return nil, badRequest(fmt.Errorf("longitude must be between -180 and 180 degrees"))
+	}
+
+	return &target{Latitude: lat, Longitude: lon}, nil
+}
+
+// provider returns the provider for live requests with the given name, NWS when the name is empty
+func (s *Server) provider(name string) (types.Provider, error) {
+	if len(s.Providers) == 0 {
+		return nil, badRequest(fmt.Errorf("live requests are disabled on this server"))
+	}
+	if name == "" {
+		name = types.ProviderNWS
+	}
+
+	provider, ok := s.Providers[name]
+	if !ok {
+		return nil, badRequest(fmt.Errorf("unknown provider %q", name))
+	}
+	return provider, nil
+}
+
+// providerError reports a failed live request as 400 Bad Request if the provider does not
+// support it and as 502 Bad Gateway otherwise
+func providerError(err error) error {
+	if errors.Is(err, types.ErrNotSupported) {
+		return badRequest(err)
+	}
+	return &httpError{status: http.StatusBadGateway, err: err}
+}
+
+// affectsZone reports whether an alert covers any of the zones
+func affectsZone(alert *types.WeatherAlert, zones []string) bool {
+	for _, affected := range strings.Split(alert.AffectedZones, ",") {
+		for _, zone := range zones {
+			if zone != "" && strings.EqualFold(affected, zone) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func boolParam(value, name string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, badRequest(fmt.Errorf("invalid %s %q, must be true or false", name, value))
+	}
+	return b, nil
+}
+
+// parseTime parses an RFC3339 time or a date, which is taken as midnight local time
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC3339 time or YYYY-MM-DD date", value)
+	}
+	return t, nil
+}
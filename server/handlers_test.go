@@ -0,0 +1,323 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// saveRun saves a daily run for the coordinates with one period starting at start
+func saveRun(t *testing.T, locationID uint, lat, lon float64, start time.Time) {
+	t.Helper()
+
+	run := &types.ForecastRun{LocationID: locationID, Latitude: lat, Longitude: lon, Provider: types.ProviderNWS}
+	forecast := &types.ForecastResponse{Properties: types.ForecastProperties{Periods: []types.ForecastPeriod{
+		{Number: 1, Name: "Today", StartTime: start.Format(time.RFC3339), EndTime: start.Add(12 * time.Hour).Format(time.RFC3339), Temperature: 55, TemperatureUnit: "F"},
+	}}}
+	if _, err := types.SaveForecastRun(run, forecast); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	start := time.Date(2026, 9, 1, 6, 0, 0, 0, time.UTC)
+	saveRun(t, 0, 41.2565, -95.9345, start)
+	saveRun(t, 0, 41.2565, -95.9345, start.Add(40*24*time.Hour))
+
+	// The location was saved at other coordinates before it moved
+	saveRun(t, 970, 41.1400, -96.0000, start)
+	locations := &fakeLocations{locations: map[string]*types.Location{
+		"omaha": {ID: 970, Name: "omaha", Latitude: 41.2565, Longitude: -95.9345},
+	}}
+	handler := newTestServer(locations, nil, &bytes.Buffer{})
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantPeriods int
+		wantError   string
+	}{
+		{
+			name:        "coordinates",
+			query:       "lat=41.2565&lon=-95.9345&from=2026-08-30&to=2026-09-30",
+			wantStatus:  http.StatusOK,
+			wantPeriods: 1,
+		},
+		{
+			name:        "location by ID",
+			query:       "location=omaha&from=2026-08-30&to=2026-09-30",
+			wantStatus:  http.StatusOK,
+			wantPeriods: 1,
+		},
+		{
+			name:        "RFC3339 range",
+			query:       "lat=41.2565&lon=-95.9345&from=2026-10-11T00:00:00Z&to=2026-10-11T12:00:00Z",
+			wantStatus:  http.StatusOK,
+			wantPeriods: 1,
+		},
+		{
+			name:        "nothing saved",
+			query:       "lat=41.2565&lon=-95.9345&from=2026-01-01&to=2026-01-02",
+			wantStatus:  http.StatusOK,
+			wantPeriods: 0,
+		},
+		{
+			name:       "range too long",
+			query:      "lat=41.2565&lon=-95.9345&from=2026-08-01&to=2026-09-02",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "from and to can be at most 31 days apart",
+		},
+		{
+			name:       "from after to",
+			query:      "lat=41.2565&lon=-95.9345&from=2026-09-02&to=2026-09-01",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "from must be before to",
+		},
+		{
+			name:       "empty range",
+			query:      "lat=41.2565&lon=-95.9345&from=2026-09-01&to=2026-09-01",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "from must be before to",
+		},
+		{
+			name:       "invalid from",
+			query:      "lat=41.2565&lon=-95.9345&from=yesterday",
+			wantStatus: http.StatusBadRequest,
+			wantError:  `invalid from: "yesterday" is not an RFC3339 time or YYYY-MM-DD date`,
+		},
+		{
+			name:       "invalid hourly",
+			query:      "lat=41.2565&lon=-95.9345&hourly=maybe",
+			wantStatus: http.StatusBadRequest,
+			wantError:  `invalid hourly "maybe", must be true or false`,
+		},
+		{
+			name:       "unknown location",
+			query:      "location=lincoln",
+			wantStatus: http.StatusNotFound,
+			wantError:  `location "lincoln" not found`,
+		},
+		{
+			name:       "location and coordinates",
+			query:      "location=omaha&lat=41.2565&lon=-95.9345",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "give either location or lat and lon, not both",
+		},
+		{
+			name:       "missing longitude",
+			query:      "lat=41.2565",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "location or lat and lon must be given",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				historyResponse
+				Error string `json:"error"`
+			}
+			if status := get(t, handler, "/v1/history?"+tt.query, &body); status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", status, tt.wantStatus, body.Error)
+			}
+			if body.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantError)
+			}
+			if tt.wantStatus == http.StatusOK && len(body.Periods) != tt.wantPeriods {
+				t.Errorf("got %d periods, want %d", len(body.Periods), tt.wantPeriods)
+			}
+		})
+	}
+}
+
+func TestHistoryDefaultRange(t *testing.T) {
+	handler := newTestServer(&fakeLocations{}, nil, &bytes.Buffer{})
+
+	var body historyResponse
+	if status := get(t, handler, "/v1/history?lat=0&lon=0&to=2026-10-17T00:00:00Z", &body); status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if got := body.To.Sub(body.From); got != defaultHistoryRange {
+		t.Errorf("range = %s, want %s", got, defaultHistoryRange)
+	}
+	if body.Periods == nil {
+		t.Error("periods = null, want an empty list")
+	}
+}
+
+func TestForecast(t *testing.T) {
+	saveRun(t, 0, 43.6150, -116.2023, time.Now().Truncate(time.Hour))
+	handler := newTestServer(&fakeLocations{}, nil, &bytes.Buffer{})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "saved", query: "lat=43.6150&lon=-116.2023", wantStatus: http.StatusOK},
+		{name: "limited periods", query: "lat=43.6150&lon=-116.2023&periods=1", wantStatus: http.StatusOK},
+		{
+			name:       "nothing saved",
+			query:      "lat=43.6&lon=-116.2",
+			wantStatus: http.StatusNotFound,
+			wantError:  "no saved forecast for 43.6000, -116.2000; use live=true to fetch one",
+		},
+		{
+			name:       "invalid periods",
+			query:      "lat=43.6150&lon=-116.2023&periods=-1",
+			wantStatus: http.StatusBadRequest,
+			wantError:  `invalid periods "-1"`,
+		},
+		{
+			name:       "live disabled",
+			query:      "lat=43.6150&lon=-116.2023&live=true",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "live requests are disabled on this server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				forecastResponse
+				Error string `json:"error"`
+			}
+			if status := get(t, handler, "/v1/forecast?"+tt.query, &body); status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", status, tt.wantStatus, body.Error)
+			}
+			if body.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantError)
+			}
+			if tt.wantStatus == http.StatusOK && (body.Source != sourceDatabase || len(body.Periods) != 1) {
+				t.Errorf("got %d periods from %q, want 1 from the database", len(body.Periods), body.Source)
+			}
+		})
+	}
+}
+
+func TestLiveForecast(t *testing.T) {
+	provider := &fakeProvider{}
+	failing := &fakeProvider{err: errors.New("api.example.com: 503 Service Unavailable")}
+	var logged bytes.Buffer
+	handler := newTestServer(&fakeLocations{}, map[string]types.Provider{types.ProviderNWS: provider, "failing": failing}, &logged)
+
+	// Coordinates are rounded to 2 decimals, so nearby requests share one provider request
+	for _, coordinates := range []string{"lat=47.60621&lon=-122.33207", "lat=47.6058&lon=-122.3349"} {
+		var body forecastResponse
+		if status := get(t, handler, "/v1/forecast?live=true&"+coordinates, &body); status != http.StatusOK {
+			t.Fatalf("status = %d, want 200", status)
+		}
+		if body.Source != sourceLive || body.Latitude != 47.61 || body.Longitude != -122.33 || len(body.Periods) != 1 {
+			t.Errorf("response = %+v, want one live period at 47.61, -122.33", body)
+		}
+	}
+	if got := provider.requests.Load(); got != 1 {
+		t.Errorf("provider requested %d times, want 1", got)
+	}
+	if provider.lastLat != 47.61 || provider.lastLon != -122.33 {
+		t.Errorf("provider asked for %v, %v, want the rounded coordinates", provider.lastLat, provider.lastLon)
+	}
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantError  string
+	}{
+		{query: "hourly=true", wantStatus: http.StatusBadRequest, wantError: "failed to get weather forecast: hourly forecasts not supported by provider fake"},
+		{query: "provider=other", wantStatus: http.StatusBadRequest, wantError: `unknown provider "other"`},
+		{query: "provider=failing", wantStatus: http.StatusBadGateway, wantError: "bad gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			logged.Reset()
+
+			var body map[string]string
+			target := fmt.Sprintf("/v1/forecast?live=true&lat=47.6&lon=-122.3&%s", tt.query)
+			if status := get(t, handler, target, &body); status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if body["error"] != tt.wantError {
+				t.Errorf("error = %q, want %q", body["error"], tt.wantError)
+			}
+		})
+	}
+
+	if !bytes.Contains(logged.Bytes(), []byte("503 Service Unavailable")) {
+		t.Errorf("provider failure was not logged: %q", logged.String())
+	}
+}
+
+func TestAlertsParameters(t *testing.T) {
+	handler := newTestServer(&fakeLocations{locations: map[string]*types.Location{
+		"unresolved": {ID: 980, Name: "unresolved", Latitude: 40, Longitude: -100},
+	}}, nil, &bytes.Buffer{})
+
+	tests := []struct {
+		query      url.Values
+		wantStatus int
+		wantError  string
+	}{
+		{query: url.Values{}, wantStatus: http.StatusOK},
+		{query: url.Values{"zone": {"COZ040"}}, wantStatus: http.StatusOK},
+		{
+			query:      url.Values{"zone": {"COZ040"}, "location": {"unresolved"}},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "zone cannot be combined with location or coordinates",
+		},
+		{
+			query:      url.Values{"location": {"unresolved"}},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "saved alerts can only be filtered by zone or by a location with NWS zones; use live=true for coordinates",
+		},
+		{
+			query:      url.Values{"live": {"true"}},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "live alerts need a location or lat and lon",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query.Encode(), func(t *testing.T) {
+			var body struct {
+				alertsResponse
+				Error string `json:"error"`
+			}
+			if status := get(t, handler, "/v1/alerts?"+tt.query.Encode(), &body); status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", status, tt.wantStatus, body.Error)
+			}
+			if body.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestAffectsZone(t *testing.T) {
+	alert := &types.WeatherAlert{AffectedZones: "COZ039,COZ040"}
+
+	tests := []struct {
+		zones []string
+		want  bool
+	}{
+		{[]string{"COZ040"}, true},
+		{[]string{"coz039"}, true},
+		{[]string{"COZ041", "COC031"}, false},
+		{[]string{"COZ04"}, false},
+		{[]string{""}, false},
+	}
+
+	for _, tt := range tests {
+		if got := affectsZone(alert, tt.zones); got != tt.want {
+			t.Errorf("affectsZone(%v) = %v, want %v", tt.zones, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package server serves the saved weather data, and optionally live forecasts and alerts, as a
+// read-only JSON API so other tools can use it without database credentials.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dwburke/weather/types"
+)
+
+// shutdownTimeout is how long in-flight requests get to finish when the server stops
+const shutdownTimeout = 10 * time.Second
+
+// LocationStore looks up named locations
+type LocationStore interface {
+	// Location returns the named location, or nil if there is none
+	Location(name string) (*types.Location, error)
+
+	// Locations returns every named location
+	Locations() ([]types.Location, error)
+}
+
+// Server is the HTTP API. Forecasts, history and alerts are read from the database; requests
+// with live=true are fetched from a provider instead and cached for CacheTTL.
+type Server struct {
+	Locations LocationStore
+	Providers map[string]types.Provider // Providers for live requests by name, empty to disable them
+	CacheTTL  time.Duration
+	Logger    *log.Logger
+	Metrics   http.Handler // Served at /metrics if set
+
+	cache *cache
+}
+
+// NewServer creates an API server
+func NewServer(locations LocationStore, providers map[string]types.Provider, cacheTTL time.Duration, logger *log.Logger) *Server {
+	return &Server{
+		Locations: locations,
+		Providers: providers,
+		CacheTTL:  cacheTTL,
+		Logger:    logger,
+		cache:     newCache(maxCacheEntries),
+	}
+}
+
+// Handler returns the API routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/forecast", s.handle(s.forecast))
+	mux.HandleFunc("GET /v1/history", s.handle(s.history))
+	mux.HandleFunc("GET /v1/locations", s.handle(s.locations))
+	mux.HandleFunc("GET /v1/alerts", s.handle(s.alerts))
+	if s.Metrics != nil {
+		mux.Handle("GET /metrics", s.Metrics)
+	}
+	return s.logRequests(mux)
+}
+
+// ListenAndServe serves the API on addr until ctx is done, then waits for in-flight requests
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	return Serve(ctx, addr, s.Handler())
+}
+
+// Serve serves handler on addr until ctx is done, then waits for in-flight requests
+func Serve(ctx context.Context, addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Requests keep running after ctx is done so Shutdown can let them finish
+		BaseContext: func(_ net.Listener) context.Context { return context.WithoutCancel(ctx) },
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// httpError is an error with the HTTP status it should be reported with
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func (e *httpError) Unwrap() error {
+	return e.err
+}
+
+// badRequest returns an error reported as 400 Bad Request
+func badRequest(err error) error {
+	return &httpError{status: http.StatusBadRequest, err: err}
+}
+
+// handle adapts an endpoint returning a JSON response value to an http.HandlerFunc
+func (s *Server) handle(endpoint func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := endpoint(r)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var httpErr *httpError
+			if errors.As(err, &httpErr) {
+				status = httpErr.status
+			}
+			// Server errors may carry database or provider details, so clients only get the status
+			message := err.Error()
+			if status >= http.StatusInternalServerError {
+				s.Logger.Printf("%s %s: %v", r.Method, r.URL.RequestURI(), err)
+				message = strings.ToLower(http.StatusText(status))
+			}
+			writeJSON(w, status, map[string]string{"error": message})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// statusRecorder remembers the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs each request with its status and duration
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		s.Logger.Printf("%s %s %d %s", r.Method, r.URL.RequestURI(), recorder.status, time.Since(start).Round(time.Millisecond))
+	})
+}
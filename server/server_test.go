@@ -0,0 +1,198 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/db"
+	"github.com/dwburke/weather/types"
+)
+
+// TestMain runs the tests against a migrated in-memory sqlite database
+func TestMain(m *testing.M) {
+	viper.Set("db.driver", db.DriverSQLite)
+	viper.Set("db.path", ":memory:")
+
+	if _, err := db.GetDB().MigrateUp(0); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate test database: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeLocations is a LocationStore backed by a map, failing every lookup with err if it is set
+type fakeLocations struct {
+	locations map[string]*types.Location
+	err       error
+}
+
+func (f *fakeLocations) Location(name string) (*types.Location, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.locations[name], nil
+}
+
+func (f *fakeLocations) Locations() ([]types.Location, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var locations []types.Location
+	for _, loc := range f.locations {
+		locations = append(locations, *loc)
+	}
+	return locations, nil
+}
+
+// fakeProvider serves a one period daily forecast, or fails with err, counting its requests.
+// It has no hourly forecasts or alerts.
+type fakeProvider struct {
+	err      error
+	requests atomic.Int32
+	lastLat  float64
+	lastLon  float64
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) Forecast(ctx context.Context, lat, lon float64) (*types.ForecastResponse, error) {
+	p.requests.Add(1)
+	p.lastLat, p.lastLon = lat, lon
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	start := time.Now().Truncate(time.Hour)
+	return &types.ForecastResponse{Properties: types.ForecastProperties{Periods: []types.ForecastPeriod{
+		{Number: 1, Name: "Today", StartTime: start.Format(time.RFC3339), EndTime: start.Add(12 * time.Hour).Format(time.RFC3339), Temperature: 61, TemperatureUnit: "F"},
+	}}}, nil
+}
+
+// newTestServer returns a server for locations and providers, logging to logged
+func newTestServer(locations LocationStore, providers map[string]types.Provider, logged *bytes.Buffer) http.Handler {
+	return NewServer(locations, providers, time.Minute, log.New(logged, "", 0)).Handler()
+}
+
+// get requests target from handler, decoding the JSON response into v if it is not nil
+func get(t *testing.T, handler http.Handler, target string, v interface{}) int {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("GET %s Content-Type = %q, want application/json", target, got)
+	}
+	if v != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: invalid JSON %q: %v", target, recorder.Body.String(), err)
+		}
+	}
+	return recorder.Code
+}
+
+func TestServerErrors(t *testing.T) {
+	var logged bytes.Buffer
+	handler := newTestServer(&fakeLocations{err: errors.New("dial tcp 10.0.0.5:3306: connection refused")}, nil, &logged)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantError  string
+		wantLogged bool
+	}{
+		{
+			name:       "client error",
+			target:     "/v1/forecast?lat=100&lon=0",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "latitude must be between -90 and 90 degrees",
+		},
+		{
+			name:       "server error details are only logged",
+			target:     "/v1/forecast?location=home",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "internal server error",
+			wantLogged: true,
+		},
+		{
+			name:       "locations",
+			target:     "/v1/locations",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "internal server error",
+			wantLogged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged.Reset()
+
+			var body map[string]string
+			if status := get(t, handler, tt.target, &body); status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if body["error"] != tt.wantError {
+				t.Errorf("error = %q, want %q", body["error"], tt.wantError)
+			}
+			if got := strings.Contains(logged.String(), "connection refused"); got != tt.wantLogged {
+				t.Errorf("details logged = %v, want %v: %q", got, tt.wantLogged, logged.String())
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := newCache(2)
+
+	c.set("a", 1, time.Minute)
+	c.set("b", 2, 2*time.Minute)
+	c.set("ignored", 3, 0)
+	if _, ok := c.get("ignored"); ok {
+		t.Error("value cached without a TTL")
+	}
+
+	// A full cache drops the entry closest to expiry
+	c.set("c", 3, 3*time.Minute)
+	if _, ok := c.get("a"); ok {
+		t.Error("entry closest to expiry was kept in a full cache")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("entry %s was evicted", key)
+		}
+	}
+
+	// Replacing an entry does not evict another
+	c.set("c", 4, 3*time.Minute)
+	if value, _ := c.get("c"); value != 4 {
+		t.Errorf("get(c) = %v, want 4", value)
+	}
+	if _, ok := c.get("b"); !ok {
+		t.Error("replacing an entry evicted another")
+	}
+
+	c.entries["b"] = cacheEntry{value: 2, expires: time.Now().Add(-time.Second)}
+	if _, ok := c.get("b"); ok {
+		t.Error("get() returned an expired entry")
+	}
+	if len(c.entries) != 1 {
+		t.Errorf("cache has %d entries, want the expired one dropped", len(c.entries))
+	}
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// AlertCollection is the response of the /alerts/active endpoints
+type AlertCollection struct {
+	Features []AlertFeature `json:"features"`
+}
+
+type AlertFeature struct {
+	Geometry   *Geometry `json:"geometry"`
+	Properties Alert     `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry. Coordinates are kept raw since their shape depends on the type.
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// AlertReference identifies an earlier alert that an update or cancellation refers to
+type AlertReference struct {
+	ID         string `json:"@id"`
+	Identifier string `json:"identifier"`
+	Sender     string `json:"sender"`
+	Sent       string `json:"sent"`
+}
+
+// Alert is an NWS weather alert (watch, warning, advisory, statement)
+type Alert struct {
+	ID            string           `json:"id"`
+	AreaDesc      string           `json:"areaDesc"`
+	AffectedZones []string         `json:"affectedZones"`
+	References    []AlertReference `json:"references"`
+	Sent          string           `json:"sent"`
+	Effective     string           `json:"effective"`
+	Onset         string           `json:"onset"`
+	Expires       string           `json:"expires"`
+	Ends          string           `json:"ends"`
+	Status        string           `json:"status"`
+	MessageType   string           `json:"messageType"` // Alert, Update or Cancel
+	Category      string           `json:"category"`
+	Severity      string           `json:"severity"`
+	Certainty     string           `json:"certainty"`
+	Urgency       string           `json:"urgency"`
+	Event         string           `json:"event"`
+	SenderName    string           `json:"senderName"`
+	Headline      string           `json:"headline"`
+	Description   string           `json:"description"`
+	Instruction   string           `json:"instruction"`
+
+	// Polygon is the outer ring of the alert area as [longitude, latitude] pairs, empty when
+	// the alert only covers zones
+	Polygon [][2]float64 `json:"polygon,omitempty"`
+}
+
+// ZoneIDs returns the affected zone identifiers, e.g. "COZ039", from the affected zone URLs
+func (a *Alert) ZoneIDs() []string {
+	zones := make([]string, 0, len(a.AffectedZones))
+	for _, zone := range a.AffectedZones {
+		zones = append(zones, path.Base(zone))
+	}
+	return zones
+}
+
+// Alerts returns the alerts in the collection with their polygons filled in from the feature geometry
+func (c *AlertCollection) Alerts() []Alert {
+	alerts := make([]Alert, 0, len(c.Features))
+	for _, feature := range c.Features {
+		alert := feature.Properties
+		if feature.Geometry != nil && feature.Geometry.Type == "Polygon" {
+			var rings [][][2]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &rings); err == nil && len(rings) > 0 {
+				alert.Polygon = rings[0]
+			}
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// GetActiveAlertsByPoint gets the active alerts covering the given latitude and longitude
+func (w *WeatherClient) GetActiveAlertsByPoint(lat, lon float64) ([]Alert, error) {
+	return w.GetActiveAlertsByPointContext(context.Background(), lat, lon)
+}
+
+// GetActiveAlertsByPointContext is like GetActiveAlertsByPoint but uses ctx for the API requests
+func (w *WeatherClient) GetActiveAlertsByPointContext(ctx context.Context, lat, lon float64) ([]Alert, error) {
+	alertsURL := fmt.Sprintf("%s/alerts/active?point=%.4f,%.4f", w.BaseURL, lat, lon)
+
+	var alerts AlertCollection
+	if err := w.getJSON(ctx, alertsURL, &alerts); err != nil {
+		return nil, fmt.Errorf("failed to get active alerts: %w", err)
+	}
+
+	return alerts.Alerts(), nil
+}
+
+// GetActiveAlertsByZone gets the active alerts for a forecast or county zone, e.g. "COZ039"
+func (w *WeatherClient) GetActiveAlertsByZone(zone string) ([]Alert, error) {
+	return w.GetActiveAlertsByZoneContext(context.Background(), zone)
+}
+
+// GetActiveAlertsByZoneContext is like GetActiveAlertsByZone but uses ctx for the API requests
+func (w *WeatherClient) GetActiveAlertsByZoneContext(ctx context.Context, zone string) ([]Alert, error) {
+	alertsURL := fmt.Sprintf("%s/alerts/active/zone/%s", w.BaseURL, url.PathEscape(zone))
+
+	var alerts AlertCollection
+	if err := w.getJSON(ctx, alertsURL, &alerts); err != nil {
+		return nil, fmt.Errorf("failed to get active alerts: %w", err)
+	}
+
+	return alerts.Alerts(), nil
+}
+
+// FormatAlert returns a formatted string representation of the alert
+func (a *Alert) FormatAlert() string {
+	result := fmt.Sprintf("⚠️  %s (%s, %s, %s)\n", a.Event, a.Severity, a.Urgency, a.Certainty)
+	if a.Headline != "" {
+		result += fmt.Sprintf("📰 %s\n", a.Headline)
+	}
+	if a.MessageType != "" && a.MessageType != "Alert" {
+		result += fmt.Sprintf("🔁 Message type: %s\n", a.MessageType)
+	}
+	result += fmt.Sprintf("⏰ Onset: %s, expires: %s\n", a.Onset, a.Expires)
+	result += fmt.Sprintf("🗺️  Area: %s\n", a.AreaDesc)
+	if zones := a.ZoneIDs(); len(zones) > 0 {
+		result += fmt.Sprintf("📍 Zones: %s\n", strings.Join(zones, ", "))
+	}
+	if a.Description != "" {
+		result += fmt.Sprintf("📝 Details: %s\n", a.Description)
+	}
+	if a.Instruction != "" {
+		result += fmt.Sprintf("👉 Instructions: %s\n", a.Instruction)
+	}
+	return result
+}
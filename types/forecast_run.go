@@ -0,0 +1,88 @@
+package types
+
+import (
+	"time"
+
+	"github.com/dwburke/weather/db"
+)
+
+// ForecastRun represents one update of a forecast fetched from the NWS API.
+// Every update is stored as a new row, and the periods it contained are stored
+// in weather_forecasts referencing the run, so the history of how a forecast
+// for a given hour evolved is preserved. Fetching the same update again reuses
+// the run, which is unique per location, type, provider and update time.
+type ForecastRun struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Location information
+	LocationID uint    `json:"location_id" gorm:"column:location_id;index"` // Named location, 0 for bare coordinates
+	Latitude   float64 `json:"latitude" gorm:"column:latitude;not null"`
+	Longitude  float64 `json:"longitude" gorm:"column:longitude;not null"`
+	PlaceName  string  `json:"place_name" gorm:"column:place_name"` // Geocoded place the coordinates were resolved from, e.g. "Boulder, CO"
+
+	// Issuance information reported by NWS
+	GeneratedAt *time.Time `json:"generated_at" gorm:"column:generated_at"`
+	UpdateTime  *time.Time `json:"update_time" gorm:"column:update_time"`
+
+	// Metadata
+	Provider    string    `json:"provider" gorm:"column:provider;index"`                  // Provider the forecast came from, e.g. "nws"
+	RetrievedAt time.Time `json:"retrieved_at" gorm:"column:retrieved_at;not null;index"` // When this forecast was retrieved
+	IsHourly    bool      `json:"is_hourly" gorm:"column:is_hourly;index"`
+	PeriodCount int       `json:"period_count" gorm:"column:period_count"`
+}
+
+func (ForecastRun) TableName() string {
+	return "forecast_runs"
+}
+
+// Create saves a new forecast run record to the database
+func (r *ForecastRun) Create() error {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return err
+	}
+
+	if err := gdbh.Create(&r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetForecastRuns retrieves the most recent forecast runs for given coordinates and type, newest first
+func GetForecastRuns(lat, lon float64, limit int, isHourly bool) ([]ForecastRun, error) {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []ForecastRun
+
+	query := gdbh.Where("latitude = ? AND longitude = ? AND is_hourly = ?", lat, lon, isHourly).
+		Order("retrieved_at DESC, id DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// parseNWSTime parses an optional NWS timestamp, returning nil when it is empty or invalid
+func parseNWSTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
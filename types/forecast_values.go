@@ -0,0 +1,190 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dwburke/weather/db"
+)
+
+// kmhPerMph converts speeds in mph to km/h
+const kmhPerMph = 1.609344
+
+// DefaultBackfillBatchSize is the default number of rows updated in one transaction by a backfill
+const DefaultBackfillBatchSize = 1000
+
+var (
+	windSpeedPattern = regexp.MustCompile(`(\d+)(?:\s*to\s*(\d+))?\s*(mph|km/h)`)
+	windGustPattern  = regexp.MustCompile(`gusts?\s+(?:as high as\s+|up to\s+|to\s+|of\s+|near\s+|around\s+)?(\d+)\s*(mph|km/h)`)
+)
+
+// ParseWindSpeed parses wind speed text such as "5 mph", "5 to 10 mph" or "20 km/h" into the
+// lowest and highest speed in km/h. "Calm" is parsed as 0.
+func ParseWindSpeed(text string) (min, max float64, ok bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "calm" {
+		return 0, 0, true
+	}
+
+	match := windSpeedPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, 0, false
+	}
+
+	min, _ = strconv.ParseFloat(match[1], 64)
+	max = min
+	if match[2] != "" {
+		max, _ = strconv.ParseFloat(match[2], 64)
+	}
+
+	if match[3] == "mph" {
+		min *= kmhPerMph
+		max *= kmhPerMph
+	}
+	return min, max, true
+}
+
+// ParseWindGust finds a gust speed in forecast text such as "Gusts as high as 25 mph" and returns
+// it in km/h
+func ParseWindGust(text string) (float64, bool) {
+	match := windGustPattern.FindStringSubmatch(strings.ToLower(text))
+	if match == nil {
+		return 0, false
+	}
+
+	gust, _ := strconv.ParseFloat(match[1], 64)
+	if match[2] == "mph" {
+		gust *= kmhPerMph
+	}
+	return gust, true
+}
+
+// ParseWindDirection converts a 16-point compass direction such as "NNW" to degrees
+func ParseWindDirection(direction string) (float64, bool) {
+	direction = strings.ToUpper(strings.TrimSpace(direction))
+	for i, point := range compassPoints {
+		if point == direction {
+			return float64(i) * 22.5, true
+		}
+	}
+	return 0, false
+}
+
+// ConvertTemperature returns a temperature in both Celsius and Fahrenheit given its unit, "C" or "F"
+func ConvertTemperature(value float64, unit string) (celsius, fahrenheit float64, ok bool) {
+	switch strings.ToUpper(strings.TrimSpace(unit)) {
+	case "F":
+		return (value - 32) * 5 / 9, value, true
+	case "C":
+		return value, value*9/5 + 32, true
+	}
+	return 0, 0, false
+}
+
+// SetNumericValues parses the period's temperature and wind text into its numeric fields, leaving
+// the fields that cannot be parsed nil
+func (w *WeatherForecast) SetNumericValues() {
+	w.TemperatureCelsius, w.TemperatureFahrenheit = nil, nil
+	if celsius, fahrenheit, ok := ConvertTemperature(float64(w.Temperature), w.TemperatureUnit); ok {
+		w.TemperatureCelsius, w.TemperatureFahrenheit = &celsius, &fahrenheit
+	}
+
+	w.WindSpeedMinKmh, w.WindSpeedMaxKmh = nil, nil
+	if min, max, ok := ParseWindSpeed(w.WindSpeed); ok {
+		w.WindSpeedMinKmh, w.WindSpeedMaxKmh = &min, &max
+	}
+
+	w.WindGustKmh = nil
+	if gust, ok := ParseWindGust(w.WindSpeed + " " + w.DetailedForecast); ok {
+		w.WindGustKmh = &gust
+	}
+
+	w.WindDirectionDeg = nil
+	if degrees, ok := ParseWindDirection(w.WindDirection); ok {
+		w.WindDirectionDeg = &degrees
+	}
+}
+
+// WindSpeedKmh returns the midpoint of the forecast wind speed range in km/h
+func (w *WeatherForecast) WindSpeedKmh() (float64, bool) {
+	if w.WindSpeedMinKmh != nil && w.WindSpeedMaxKmh != nil {
+		return (*w.WindSpeedMinKmh + *w.WindSpeedMaxKmh) / 2, true
+	}
+
+	min, max, ok := ParseWindSpeed(w.WindSpeed)
+	return (min + max) / 2, ok
+}
+
+// BackfillForecastValues fills in the numeric fields of forecast periods saved before they existed,
+// or of every period if all is set, batchSize rows at a time. It returns the number of rows updated.
+func BackfillForecastValues(all bool, batchSize int) (int, error) {
+	return BackfillForecastValuesContext(context.Background(), all, batchSize)
+}
+
+// BackfillForecastValuesContext is like BackfillForecastValues but stops between batches once ctx
+// is done. Each batch is committed on its own, so an interrupted backfill keeps its progress.
+func BackfillForecastValuesContext(ctx context.Context, all bool, batchSize int) (int, error) {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return 0, err
+	}
+
+	if batchSize <= 0 {
+		batchSize = DefaultBackfillBatchSize
+	}
+
+	var lastID uint
+	var updated int
+	for {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+
+		query := gdbh.Where("id > ?", lastID)
+		if !all {
+			query = query.Where("temperature_c IS NULL")
+		}
+
+		var forecasts []WeatherForecast
+		if err := query.Order("id ASC").Limit(batchSize).Find(&forecasts).Error; err != nil {
+			return updated, err
+		}
+		if len(forecasts) == 0 {
+			return updated, nil
+		}
+
+		tx := gdbh.Begin()
+		if tx.Error != nil {
+			return updated, tx.Error
+		}
+
+		for i := range forecasts {
+			forecast := &forecasts[i]
+			forecast.SetNumericValues()
+
+			if err := tx.Model(forecast).UpdateColumns(map[string]interface{}{
+				"temperature_c":      forecast.TemperatureCelsius,
+				"temperature_f":      forecast.TemperatureFahrenheit,
+				"wind_speed_min_kmh": forecast.WindSpeedMinKmh,
+				"wind_speed_max_kmh": forecast.WindSpeedMaxKmh,
+				"wind_gust_kmh":      forecast.WindGustKmh,
+				"wind_direction_deg": forecast.WindDirectionDeg,
+			}).Error; err != nil {
+				tx.Rollback()
+				return updated, err
+			}
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			return updated, err
+		}
+
+		updated += len(forecasts)
+		lastID = forecasts[len(forecasts)-1].ID
+		fmt.Fprintf(os.Stderr, "📊 Backfilled %d forecast periods (up to ID %d)\n", updated, lastID)
+	}
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseWindSpeed(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max float64
+		ok       bool
+	}{
+		{text: "5 mph", min: 5 * kmhPerMph, max: 5 * kmhPerMph, ok: true},
+		{text: "5 to 10 mph", min: 5 * kmhPerMph, max: 10 * kmhPerMph, ok: true},
+		{text: "20 km/h", min: 20, max: 20, ok: true},
+		{text: "10 to 15 km/h", min: 10, max: 15, ok: true},
+		{text: "  15 MPH ", min: 15 * kmhPerMph, max: 15 * kmhPerMph, ok: true},
+		{text: "5to10mph", min: 5 * kmhPerMph, max: 10 * kmhPerMph, ok: true},
+		{text: "0 mph", min: 0, max: 0, ok: true},
+		{text: "Calm", min: 0, max: 0, ok: true},
+		{text: "", ok: false},
+		{text: "breezy", ok: false},
+		{text: "10 knots", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			min, max, ok := ParseWindSpeed(tt.text)
+			if ok != tt.ok {
+				t.Fatalf("ParseWindSpeed(%q) ok = %v, want %v", tt.text, ok, tt.ok)
+			}
+			if math.Abs(min-tt.min) > 1e-9 || math.Abs(max-tt.max) > 1e-9 {
+				t.Errorf("ParseWindSpeed(%q) = %v, %v, want %v, %v", tt.text, min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestParseWindGust(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{text: "Sunny. West wind 10 to 15 mph, with gusts as high as 25 mph.", want: 25 * kmhPerMph, ok: true},
+		{text: "Gusts up to 40 km/h", want: 40, ok: true},
+		{text: "gust to 30 mph", want: 30 * kmhPerMph, ok: true},
+		{text: "with gusts of 20 mph", want: 20 * kmhPerMph, ok: true},
+		{text: "gusts near 35 km/h", want: 35, ok: true},
+		{text: "gusts around 18 mph", want: 18 * kmhPerMph, ok: true},
+		{text: "Gusts 22 mph", want: 22 * kmhPerMph, ok: true},
+		{text: "North wind 5 to 10 mph.", ok: false},
+		{text: "gusty winds", ok: false},
+		{text: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := ParseWindGust(tt.text)
+			if ok != tt.ok {
+				t.Fatalf("ParseWindGust(%q) ok = %v, want %v", tt.text, ok, tt.ok)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ParseWindGust(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWindDirection(t *testing.T) {
+	tests := []struct {
+		direction string
+		want      float64
+		ok        bool
+	}{
+		{direction: "N", want: 0, ok: true},
+		{direction: "NNE", want: 22.5, ok: true},
+		{direction: "E", want: 90, ok: true},
+		{direction: "SSW", want: 202.5, ok: true},
+		{direction: "W", want: 270, ok: true},
+		{direction: "NNW", want: 337.5, ok: true},
+		{direction: " nw ", want: 315, ok: true},
+		{direction: "", ok: false},
+		{direction: "NORTH", ok: false},
+		{direction: "NNNW", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.direction, func(t *testing.T) {
+			got, ok := ParseWindDirection(tt.direction)
+			if ok != tt.ok {
+				t.Fatalf("ParseWindDirection(%q) ok = %v, want %v", tt.direction, ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("ParseWindDirection(%q) = %v, want %v", tt.direction, got, tt.want)
+			}
+		})
+	}
+}
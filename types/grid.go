@@ -0,0 +1,297 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GridDataResponse is the response of /gridpoints/{wfo}/{x},{y}
+type GridDataResponse struct {
+	Properties GridDataProperties `json:"properties"`
+}
+
+// GridDataProperties holds the numeric forecast layers of a grid point. Each layer is a
+// series of values valid over ISO-8601 intervals such as "2024-06-01T12:00:00+00:00/PT3H".
+type GridDataProperties struct {
+	UpdateTime                 string    `json:"updateTime"`
+	ValidTimes                 string    `json:"validTimes"`
+	Temperature                GridLayer `json:"temperature"`
+	Dewpoint                   GridLayer `json:"dewpoint"`
+	MaxTemperature             GridLayer `json:"maxTemperature"`
+	MinTemperature             GridLayer `json:"minTemperature"`
+	RelativeHumidity           GridLayer `json:"relativeHumidity"`
+	ApparentTemperature        GridLayer `json:"apparentTemperature"`
+	SkyCover                   GridLayer `json:"skyCover"`
+	WindDirection              GridLayer `json:"windDirection"`
+	WindSpeed                  GridLayer `json:"windSpeed"`
+	WindGust                   GridLayer `json:"windGust"`
+	ProbabilityOfPrecipitation GridLayer `json:"probabilityOfPrecipitation"`
+	QuantitativePrecipitation  GridLayer `json:"quantitativePrecipitation"`
+	SnowfallAmount             GridLayer `json:"snowfallAmount"`
+	IceAccumulation            GridLayer `json:"iceAccumulation"`
+}
+
+// GridLayer is a single numeric forecast series with its unit of measure
+type GridLayer struct {
+	Uom    string      `json:"uom"`
+	Values []GridValue `json:"values"`
+}
+
+type GridValue struct {
+	ValidTime string   `json:"validTime"`
+	Value     *float64 `json:"value"`
+}
+
+// GridHour is one hour of the expanded grid forecast. Temperatures are in °C, speeds in km/h,
+// directions in degrees, percentages 0-100 and precipitation amounts in mm. Accumulated amounts
+// are the share of the source interval's total falling in this hour.
+type GridHour struct {
+	Time                       time.Time `json:"time"`
+	Temperature                *float64  `json:"temperature"`
+	Dewpoint                   *float64  `json:"dewpoint"`
+	RelativeHumidity           *float64  `json:"relative_humidity"`
+	ApparentTemperature        *float64  `json:"apparent_temperature"`
+	SkyCover                   *float64  `json:"sky_cover"`
+	WindDirection              *float64  `json:"wind_direction"`
+	WindSpeed                  *float64  `json:"wind_speed"`
+	WindGust                   *float64  `json:"wind_gust"`
+	ProbabilityOfPrecipitation *float64  `json:"probability_of_precipitation"`
+	QuantitativePrecipitation  *float64  `json:"quantitative_precipitation"`
+	SnowfallAmount             *float64  `json:"snowfall_amount"`
+	IceAccumulation            *float64  `json:"ice_accumulation"`
+}
+
+// GetGridData gets the raw numeric gridpoint forecast for given latitude and longitude
+func (w *WeatherClient) GetGridData(lat, lon float64) (*GridDataResponse, error) {
+	return w.GetGridDataContext(context.Background(), lat, lon)
+}
+
+// GetGridDataContext is like GetGridData but uses ctx for the API requests
+func (w *WeatherClient) GetGridDataContext(ctx context.Context, lat, lon float64) (*GridDataResponse, error) {
+	var grid GridDataResponse
+	if err := w.getFromPoints(ctx, lat, lon, func(p *PointsProperties) string { return p.ForecastGridData }, &grid); err != nil {
+		return nil, fmt.Errorf("failed to get gridpoint data: %w", err)
+	}
+
+	return &grid, nil
+}
+
+// GetGridDataByGridpoint gets the raw numeric gridpoint forecast for a forecast office grid point
+func (w *WeatherClient) GetGridDataByGridpoint(wfo string, x, y int) (*GridDataResponse, error) {
+	return w.GetGridDataByGridpointContext(context.Background(), wfo, x, y)
+}
+
+// GetGridDataByGridpointContext is like GetGridDataByGridpoint but uses ctx for the API requests
+func (w *WeatherClient) GetGridDataByGridpointContext(ctx context.Context, wfo string, x, y int) (*GridDataResponse, error) {
+	gridURL := fmt.Sprintf("%s/gridpoints/%s/%d,%d", w.BaseURL, wfo, x, y)
+
+	var grid GridDataResponse
+	if err := w.getJSON(ctx, gridURL, &grid); err != nil {
+		return nil, fmt.Errorf("failed to get gridpoint data: %w", err)
+	}
+
+	return &grid, nil
+}
+
+// Hourly expands the grid layers into an hourly series covering every hour any layer has a value
+// for, sorted by time. Hours a layer does not cover leave its field nil.
+func (g *GridDataProperties) Hourly() ([]GridHour, error) {
+	type layerSpec struct {
+		layer      GridLayer
+		accumulate bool
+		set        func(h *GridHour, v *float64)
+	}
+
+	specs := []layerSpec{
+		{g.Temperature, false, func(h *GridHour, v *float64) { h.Temperature = v }},
+		{g.Dewpoint, false, func(h *GridHour, v *float64) { h.Dewpoint = v }},
+		{g.RelativeHumidity, false, func(h *GridHour, v *float64) { h.RelativeHumidity = v }},
+		{g.ApparentTemperature, false, func(h *GridHour, v *float64) { h.ApparentTemperature = v }},
+		{g.SkyCover, false, func(h *GridHour, v *float64) { h.SkyCover = v }},
+		{g.WindDirection, false, func(h *GridHour, v *float64) { h.WindDirection = v }},
+		{g.WindSpeed, false, func(h *GridHour, v *float64) { h.WindSpeed = v }},
+		{g.WindGust, false, func(h *GridHour, v *float64) { h.WindGust = v }},
+		{g.ProbabilityOfPrecipitation, false, func(h *GridHour, v *float64) { h.ProbabilityOfPrecipitation = v }},
+		{g.QuantitativePrecipitation, true, func(h *GridHour, v *float64) { h.QuantitativePrecipitation = v }},
+		{g.SnowfallAmount, true, func(h *GridHour, v *float64) { h.SnowfallAmount = v }},
+		{g.IceAccumulation, true, func(h *GridHour, v *float64) { h.IceAccumulation = v }},
+	}
+
+	hours := make(map[time.Time]*GridHour)
+	for _, spec := range specs {
+		values, err := spec.layer.expandHourly(spec.accumulate)
+		if err != nil {
+			return nil, err
+		}
+
+		for t, value := range values {
+			hour, ok := hours[t]
+			if !ok {
+				hour = &GridHour{Time: t}
+				hours[t] = hour
+			}
+			v := value
+			spec.set(hour, &v)
+		}
+	}
+
+	result := make([]GridHour, 0, len(hours))
+	for _, hour := range hours {
+		result = append(result, *hour)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+
+	return result, nil
+}
+
+// expandHourly returns the layer's values keyed by hour, converted to the units documented on GridHour.
+// Accumulated layers have each interval's total split evenly across its hours.
+func (l *GridLayer) expandHourly(accumulate bool) (map[time.Time]float64, error) {
+	values := make(map[time.Time]float64)
+
+	for _, v := range l.Values {
+		if v.Value == nil {
+			continue
+		}
+
+		start, duration, err := parseValidTime(v.ValidTime)
+		if err != nil {
+			return nil, err
+		}
+
+		hourCount := int(duration / time.Hour)
+		if hourCount < 1 {
+			hourCount = 1
+		}
+
+		value := convertGridValue(*v.Value, l.Uom)
+		if accumulate {
+			value /= float64(hourCount)
+		}
+
+		start = start.UTC().Truncate(time.Hour)
+		for i := 0; i < hourCount; i++ {
+			values[start.Add(time.Duration(i)*time.Hour)] = value
+		}
+	}
+
+	return values, nil
+}
+
+// convertGridValue converts grid values reported in non-SI units to °C, km/h and mm
+func convertGridValue(value float64, uom string) float64 {
+	switch uom {
+	case "wmoUnit:degF":
+		return (value - 32) * 5 / 9
+	case "wmoUnit:m_s-1":
+		return value * 3.6
+	case "wmoUnit:cm":
+		return value * 10
+	case "wmoUnit:m":
+		return value * 1000
+	}
+	return value
+}
+
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseValidTime parses an ISO-8601 interval of the form "<start>/<duration>"
+func parseValidTime(validTime string) (time.Time, time.Duration, error) {
+	parts := strings.SplitN(validTime, "/", 2)
+	if len(parts) != 2 {
+		return time.Time{}, 0, fmt.Errorf("invalid validTime %q", validTime)
+	}
+
+	start, err := time.Parse(time.RFC3339, parts[0])
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid validTime %q: %w", validTime, err)
+	}
+
+	duration, err := parseISODuration(parts[1])
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid validTime %q: %w", validTime, err)
+	}
+
+	return start, duration, nil
+}
+
+// parseISODuration parses the day/time subset of ISO-8601 durations used by NWS, e.g. "P1DT6H"
+func parseISODuration(value string) (time.Duration, error) {
+	match := isoDurationPattern.FindStringSubmatch(value)
+	if match == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+	var duration time.Duration
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(n) * unit
+	}
+
+	return duration, nil
+}
+
+// FormatGridHours returns a formatted table of the first hours of an expanded grid forecast in the
+// given units
+func FormatGridHours(hours []GridHour, limit int, units UnitSystem) string {
+	if limit <= 0 || limit > len(hours) {
+		limit = len(hours)
+	}
+
+	precipitationPrecision := 1
+	if units.PrecipitationUnit() == "in" {
+		precipitationPrecision = 2
+	}
+
+	result := fmt.Sprintf("Gridpoint Forecast (%s, %s, %s):\n", units.TemperatureUnit(), units.SpeedUnit(), units.PrecipitationUnit())
+	result += "==================================\n\n"
+	result += fmt.Sprintf("%-16s %6s %6s %5s %5s %6s %6s %5s %6s %6s %6s\n",
+		"Time", "Temp", "Dew", "RH%", "Sky%", "Wind", "Gust", "PoP%", "QPF", "Snow", "Ice")
+
+	for _, h := range hours[:limit] {
+		result += fmt.Sprintf("%-16s %6s %6s %5s %5s %6s %6s %5s %6s %6s %6s\n",
+			h.Time.Local().Format("Jan 2 3:04 PM"),
+			formatGridValue(convertOptional(h.Temperature, units.Temperature), 1),
+			formatGridValue(convertOptional(h.Dewpoint, units.Temperature), 1),
+			formatGridValue(h.RelativeHumidity, 0),
+			formatGridValue(h.SkyCover, 0),
+			formatGridValue(convertOptional(h.WindSpeed, units.Speed), 1),
+			formatGridValue(convertOptional(h.WindGust, units.Speed), 1),
+			formatGridValue(h.ProbabilityOfPrecipitation, 0),
+			formatGridValue(convertOptional(h.QuantitativePrecipitation, units.Precipitation), 2),
+			formatGridValue(convertOptional(h.SnowfallAmount, units.Precipitation), precipitationPrecision),
+			formatGridValue(convertOptional(h.IceAccumulation, units.Precipitation), precipitationPrecision),
+		)
+	}
+
+	return result
+}
+
+// convertOptional converts a value that may be missing with one of the UnitSystem conversions
+func convertOptional(value *float64, convert func(float64) float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	converted := convert(*value)
+	return &converted
+}
+
+func formatGridValue(value *float64, precision int) string {
+	if value == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*value, 'f', precision, 64)
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dwburke/weather/db"
+)
+
+// GridForecast represents one hour of a raw gridpoint forecast stored in the database.
+// Like forecast runs, every fetch is stored, so how the numeric forecast evolved is preserved.
+type GridForecast struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Location information
+	Latitude  float64 `json:"latitude" gorm:"column:latitude;not null"`
+	Longitude float64 `json:"longitude" gorm:"column:longitude;not null"`
+
+	// Hour this row is valid for
+	ValidTime time.Time `json:"valid_time" gorm:"column:valid_time;not null;index"`
+
+	// Forecast values, see GridHour for units
+	Temperature                *float64 `json:"temperature" gorm:"column:temperature"`
+	Dewpoint                   *float64 `json:"dewpoint" gorm:"column:dewpoint"`
+	RelativeHumidity           *float64 `json:"relative_humidity" gorm:"column:relative_humidity"`
+	ApparentTemperature        *float64 `json:"apparent_temperature" gorm:"column:apparent_temperature"`
+	SkyCover                   *float64 `json:"sky_cover" gorm:"column:sky_cover"`
+	WindDirection              *float64 `json:"wind_direction" gorm:"column:wind_direction"`
+	WindSpeed                  *float64 `json:"wind_speed" gorm:"column:wind_speed"`
+	WindGust                   *float64 `json:"wind_gust" gorm:"column:wind_gust"`
+	ProbabilityOfPrecipitation *float64 `json:"probability_of_precipitation" gorm:"column:probability_of_precipitation"`
+	QuantitativePrecipitation  *float64 `json:"quantitative_precipitation" gorm:"column:quantitative_precipitation"`
+	SnowfallAmount             *float64 `json:"snowfall_amount" gorm:"column:snowfall_amount"`
+	IceAccumulation            *float64 `json:"ice_accumulation" gorm:"column:ice_accumulation"`
+
+	// Metadata
+	UpdateTime   *time.Time `json:"update_time" gorm:"column:update_time"`           // When NWS last updated the grid
+	ForecastDate time.Time  `json:"forecast_date" gorm:"column:forecast_date;index"` // When this forecast was retrieved
+}
+
+func (GridForecast) TableName() string {
+	return "grid_forecasts"
+}
+
+// GridSaveResult reports how many hourly grid values were saved
+type GridSaveResult struct {
+	Saved int
+}
+
+// String summarizes the result, e.g. "156 hourly grid values saved"
+func (r *GridSaveResult) String() string {
+	return fmt.Sprintf("%d hourly grid values saved", r.Saved)
+}
+
+// SaveGridDataToDB expands a gridpoint forecast into hours and saves them to the database
+func SaveGridDataToDB(grid *GridDataResponse, lat, lon float64) (*GridSaveResult, error) {
+	return SaveGridDataToDBContext(context.Background(), grid, lat, lon)
+}
+
+// SaveGridDataToDBContext is like SaveGridDataToDB but stops between hours once ctx is done
+func SaveGridDataToDBContext(ctx context.Context, grid *GridDataResponse, lat, lon float64) (*GridSaveResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	hours, err := grid.Properties.Hourly()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GridSaveResult{}
+
+	forecastDate := time.Now()
+	updateTime := parseNWSTime(grid.Properties.UpdateTime)
+
+	for _, hour := range hours {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		row := GridForecast{
+			Latitude:                   lat,
+			Longitude:                  lon,
+			ValidTime:                  hour.Time,
+			Temperature:                hour.Temperature,
+			Dewpoint:                   hour.Dewpoint,
+			RelativeHumidity:           hour.RelativeHumidity,
+			ApparentTemperature:        hour.ApparentTemperature,
+			SkyCover:                   hour.SkyCover,
+			WindDirection:              hour.WindDirection,
+			WindSpeed:                  hour.WindSpeed,
+			WindGust:                   hour.WindGust,
+			ProbabilityOfPrecipitation: hour.ProbabilityOfPrecipitation,
+			QuantitativePrecipitation:  hour.QuantitativePrecipitation,
+			SnowfallAmount:             hour.SnowfallAmount,
+			IceAccumulation:            hour.IceAccumulation,
+			UpdateTime:                 updateTime,
+			ForecastDate:               forecastDate,
+		}
+
+		if err := gdbh.Create(&row).Error; err != nil {
+			return result, err
+		}
+		result.Saved++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,195 @@
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "PT1H", want: time.Hour},
+		{value: "PT3H", want: 3 * time.Hour},
+		{value: "P1D", want: 24 * time.Hour},
+		{value: "P1DT6H", want: 30 * time.Hour},
+		{value: "P7DT12H30M15S", want: 7*24*time.Hour + 12*time.Hour + 30*time.Minute + 15*time.Second},
+		{value: "PT45M", want: 45 * time.Minute},
+		{value: "PT0S", want: 0},
+		{value: "P", wantErr: true},
+		{value: "PT", wantErr: true},
+		{value: "", wantErr: true},
+		{value: "1H", wantErr: true},
+		{value: "P1H", wantErr: true},
+		{value: "P1W", wantErr: true},
+		{value: "PT1.5H", wantErr: true},
+		{value: "-PT1H", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseISODuration(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseISODuration(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseISODuration(%q) error = %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseISODuration(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestHourly(t *testing.T) {
+	base := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	hour := func(i int) time.Time { return base.Add(time.Duration(i) * time.Hour) }
+
+	tests := []struct {
+		name    string
+		grid    GridDataProperties
+		want    map[time.Time]GridHour // Fields checked: Temperature, WindSpeed, QuantitativePrecipitation
+		wantErr bool
+	}{
+		{
+			name: "intervals expanded to hours",
+			grid: GridDataProperties{
+				Temperature: GridLayer{Uom: "wmoUnit:degC", Values: []GridValue{
+					{ValidTime: "2026-06-01T12:00:00+00:00/PT2H", Value: floatPtr(20)},
+					{ValidTime: "2026-06-01T14:00:00+00:00/PT1H", Value: floatPtr(21)},
+				}},
+			},
+			want: map[time.Time]GridHour{
+				hour(0): {Temperature: floatPtr(20)},
+				hour(1): {Temperature: floatPtr(20)},
+				hour(2): {Temperature: floatPtr(21)},
+			},
+		},
+		{
+			name: "units converted",
+			grid: GridDataProperties{
+				Temperature: GridLayer{Uom: "wmoUnit:degF", Values: []GridValue{
+					{ValidTime: "2026-06-01T12:00:00+00:00/PT1H", Value: floatPtr(50)},
+				}},
+				WindSpeed: GridLayer{Uom: "wmoUnit:m_s-1", Values: []GridValue{
+					{ValidTime: "2026-06-01T12:00:00+00:00/PT1H", Value: floatPtr(5)},
+				}},
+			},
+			want: map[time.Time]GridHour{
+				hour(0): {Temperature: floatPtr(10), WindSpeed: floatPtr(18)},
+			},
+		},
+		{
+			name: "accumulated amounts split across hours",
+			grid: GridDataProperties{
+				QuantitativePrecipitation: GridLayer{Uom: "wmoUnit:mm", Values: []GridValue{
+					{ValidTime: "2026-06-01T12:00:00+00:00/PT3H", Value: floatPtr(6)},
+				}},
+				WindSpeed: GridLayer{Uom: "wmoUnit:km_h-1", Values: []GridValue{
+					{ValidTime: "2026-06-01T12:00:00+00:00/PT3H", Value: floatPtr(6)},
+				}},
+			},
+			want: map[time.Time]GridHour{
+				hour(0): {QuantitativePrecipitation: floatPtr(2), WindSpeed: floatPtr(6)},
+				hour(1): {QuantitativePrecipitation: floatPtr(2), WindSpeed: floatPtr(6)},
+				hour(2): {QuantitativePrecipitation: floatPtr(2), WindSpeed: floatPtr(6)},
+			},
+		},
+		{
+			name: "layers covering different hours",
+			grid: GridDataProperties{
+				Temperature: GridLayer{Uom: "wmoUnit:degC", Values: []GridValue{
+					{ValidTime: "2026-06-01T12:00:00+00:00/PT1H", Value: floatPtr(20)},
+					{ValidTime: "2026-06-01T13:00:00+00:00/PT1H", Value: nil},
+				}},
+				WindSpeed: GridLayer{Uom: "wmoUnit:km_h-1", Values: []GridValue{
+					{ValidTime: "2026-06-01T14:00:00+00:00/PT1H", Value: floatPtr(10)},
+				}},
+			},
+			want: map[time.Time]GridHour{
+				hour(0): {Temperature: floatPtr(20)},
+				hour(2): {WindSpeed: floatPtr(10)},
+			},
+		},
+		{
+			name: "offsets normalized to UTC hours",
+			grid: GridDataProperties{
+				Temperature: GridLayer{Uom: "wmoUnit:degC", Values: []GridValue{
+					{ValidTime: "2026-06-01T06:30:00-06:00/PT30M", Value: floatPtr(15)},
+				}},
+			},
+			want: map[time.Time]GridHour{
+				hour(0): {Temperature: floatPtr(15)},
+			},
+		},
+		{
+			name: "invalid interval",
+			grid: GridDataProperties{
+				Temperature: GridLayer{Uom: "wmoUnit:degC", Values: []GridValue{
+					{ValidTime: "2026-06-01T12:00:00+00:00", Value: floatPtr(20)},
+				}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid duration",
+			grid: GridDataProperties{
+				Temperature: GridLayer{Uom: "wmoUnit:degC", Values: []GridValue{
+					{ValidTime: "2026-06-01T12:00:00+00:00/P1W", Value: floatPtr(20)},
+				}},
+			},
+			wantErr: true,
+		},
+	}
+
+	sameValue := func(got, want *float64) bool {
+		if got == nil || want == nil {
+			return got == want
+		}
+		return math.Abs(*got-*want) < 1e-9
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hours, err := tt.grid.Hourly()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Hourly() = %d hours, want an error", len(hours))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Hourly() error = %v", err)
+			}
+
+			if len(hours) != len(tt.want) {
+				t.Fatalf("Hourly() = %d hours, want %d", len(hours), len(tt.want))
+			}
+			for i, got := range hours {
+				if i > 0 && !hours[i-1].Time.Before(got.Time) {
+					t.Errorf("hours not sorted: %s before %s", hours[i-1].Time, got.Time)
+				}
+				want, ok := tt.want[got.Time]
+				if !ok {
+					t.Errorf("unexpected hour %s", got.Time)
+					continue
+				}
+				if !sameValue(got.Temperature, want.Temperature) || !sameValue(got.WindSpeed, want.WindSpeed) ||
+					!sameValue(got.QuantitativePrecipitation, want.QuantitativePrecipitation) {
+					t.Errorf("hour %s = %+v, want %+v", got.Time, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dwburke/weather/db"
+)
+
+// maxLocationStations is the number of nearest observation stations stored for a location
+const maxLocationStations = 5
+
+// Location is a named place weather data is collected for, with its resolved NWS metadata
+type Location struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name      string  `json:"name" gorm:"column:name;not null;unique_index" validate:"required"`
+	Latitude  float64 `json:"latitude" gorm:"column:latitude;not null" validate:"min=-90,max=90"`
+	Longitude float64 `json:"longitude" gorm:"column:longitude;not null" validate:"min=-180,max=180"`
+	TimeZone  string  `json:"timezone" gorm:"column:timezone"`
+	Provider  string  `json:"provider" gorm:"column:provider"` // Forecast provider, empty for NWS
+
+	// NWS metadata resolved from /points
+	GridID       string `json:"grid_id" gorm:"column:grid_id"`
+	GridX        int    `json:"grid_x" gorm:"column:grid_x"`
+	GridY        int    `json:"grid_y" gorm:"column:grid_y"`
+	ForecastZone string `json:"forecast_zone" gorm:"column:forecast_zone"`
+	County       string `json:"county" gorm:"column:county"`
+	Stations     string `json:"stations" gorm:"column:stations"` // Comma separated station IDs, nearest first
+}
+
+func (Location) TableName() string {
+	return "locations"
+}
+
+// StationIDs returns the location's observation station IDs, nearest first
+func (l *Location) StationIDs() []string {
+	if l.Stations == "" {
+		return nil
+	}
+	return strings.Split(l.Stations, ",")
+}
+
+// ProviderName returns the location's forecast provider, defaulting to NWS
+func (l *Location) ProviderName() string {
+	if l.Provider == "" {
+		return ProviderNWS
+	}
+	return l.Provider
+}
+
+// IsResolved reports whether the location's NWS grid metadata has been looked up
+func (l *Location) IsResolved() bool {
+	return l.GridID != ""
+}
+
+// SetCoordinates moves the location. Moving it clears the resolved NWS metadata, which belongs to
+// the old coordinates, so it is looked up again rather than kept stale.
+func (l *Location) SetCoordinates(lat, lon float64) {
+	if l.Latitude == lat && l.Longitude == lon {
+		return
+	}
+
+	l.Latitude = lat
+	l.Longitude = lon
+
+	l.TimeZone = ""
+	l.GridID = ""
+	l.GridX = 0
+	l.GridY = 0
+	l.ForecastZone = ""
+	l.County = ""
+	l.Stations = ""
+}
+
+// Save creates or updates the location record in the database
+func (l *Location) Save() error {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return err
+	}
+
+	if err := gdbh.Save(&l).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes the location record from the database
+func (l *Location) Delete() error {
+	if gdbh, err := db.GetDB().DB(); err != nil {
+		return err
+	} else {
+		return gdbh.Delete(&l).Error
+	}
+}
+
+// FindLocationByName retrieves a stored location by name, returning nil if it does not exist
+func FindLocationByName(name string) (*Location, error) {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []Location
+	if err := gdbh.Where("name = ?", name).Limit(1).Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	if len(locations) == 0 {
+		return nil, nil
+	}
+
+	return &locations[0], nil
+}
+
+// GetLocations retrieves all stored locations ordered by name
+func GetLocations() ([]Location, error) {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []Location
+	if err := gdbh.Order("name ASC").Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+// ResolveLocation looks up the NWS grid point, time zone, forecast zone, county and nearest
+// observation stations for the location's coordinates
+func (w *WeatherClient) ResolveLocation(loc *Location) error {
+	return w.ResolveLocationContext(context.Background(), loc)
+}
+
+// ResolveLocationContext is like ResolveLocation but uses ctx for the API requests
+func (w *WeatherClient) ResolveLocationContext(ctx context.Context, loc *Location) error {
+	points, err := w.RefreshPointsContext(ctx, loc.Latitude, loc.Longitude)
+	if err != nil {
+		return err
+	}
+
+	loc.TimeZone = points.TimeZone
+	loc.GridID = points.GridID
+	loc.GridX = points.GridX
+	loc.GridY = points.GridY
+	loc.ForecastZone = path.Base(points.ForecastZone)
+	loc.County = path.Base(points.County)
+
+	stations, err := w.getObservationStations(ctx, points)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for i := 0; i < len(stations) && i < maxLocationStations; i++ {
+		ids = append(ids, stations[i].Properties.StationIdentifier)
+	}
+	loc.Stations = strings.Join(ids, ",")
+
+	return nil
+}
+
+// FormatLocation returns a formatted string representation of the location
+func (l *Location) FormatLocation() string {
+	result := fmt.Sprintf("📍 %s\n", l.Name)
+	result += fmt.Sprintf("🌐 Coordinates: %.4f, %.4f\n", l.Latitude, l.Longitude)
+	if l.ProviderName() != ProviderNWS {
+		result += fmt.Sprintf("🛰️  Provider: %s\n", l.ProviderName())
+		return result
+	}
+	if !l.IsResolved() {
+		result += "ℹ️  NWS metadata not resolved\n"
+		return result
+	}
+	result += fmt.Sprintf("🕒 Time zone: %s\n", l.TimeZone)
+	result += fmt.Sprintf("🗺️  Grid: %s %d,%d\n", l.GridID, l.GridX, l.GridY)
+	result += fmt.Sprintf("📍 Forecast zone: %s, county: %s\n", l.ForecastZone, l.County)
+	if l.Stations != "" {
+		result += fmt.Sprintf("📡 Stations: %s\n", strings.ReplaceAll(l.Stations, ",", ", "))
+	}
+	return result
+}
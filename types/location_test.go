@@ -0,0 +1,54 @@
+package types
+
+import "testing"
+
+func TestSetCoordinates(t *testing.T) {
+	resolved := Location{
+		Name:         "denver",
+		Latitude:     39.7391,
+		Longitude:    -104.9847,
+		TimeZone:     "America/Denver",
+		GridID:       "BOU",
+		GridX:        63,
+		GridY:        62,
+		ForecastZone: "COZ040",
+		County:       "COC031",
+		Stations:     "KDEN,KBKF",
+	}
+
+	tests := []struct {
+		name         string
+		lat, lon     float64
+		wantResolved bool
+	}{
+		{name: "same coordinates", lat: 39.7391, lon: -104.9847, wantResolved: true},
+		{name: "new latitude", lat: 39.7400, lon: -104.9847},
+		{name: "new longitude", lat: 39.7391, lon: -105.0000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := resolved
+			loc.SetCoordinates(tt.lat, tt.lon)
+
+			if loc.Latitude != tt.lat || loc.Longitude != tt.lon {
+				t.Errorf("coordinates = %v, %v, want %v, %v", loc.Latitude, loc.Longitude, tt.lat, tt.lon)
+			}
+			if loc.IsResolved() != tt.wantResolved {
+				t.Errorf("IsResolved() = %v, want %v", loc.IsResolved(), tt.wantResolved)
+			}
+			if tt.wantResolved {
+				if loc != resolved {
+					t.Errorf("metadata changed without moving: %+v", loc)
+				}
+				return
+			}
+			if loc.TimeZone != "" || loc.GridX != 0 || loc.GridY != 0 || loc.ForecastZone != "" || loc.County != "" || loc.Stations != "" {
+				t.Errorf("metadata of the old coordinates kept: %+v", loc)
+			}
+			if loc.Name != "denver" {
+				t.Errorf("Name = %q, want it kept", loc.Name)
+			}
+		})
+	}
+}
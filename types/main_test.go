@@ -0,0 +1,47 @@
+package types
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/dwburke/weather/db"
+)
+
+// fixturesDir holds NWS responses recorded with --record, served by NewReplayClient
+const fixturesDir = "testdata/fixtures"
+
+// TestMain runs the tests against a migrated in-memory sqlite database
+func TestMain(m *testing.M) {
+	viper.Set("db.driver", db.DriverSQLite)
+	viper.Set("db.path", ":memory:")
+
+	if _, err := db.GetDB().MigrateUp(0); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate test database: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// replayForecast returns the recorded daily or hourly forecast for the fixture coordinates
+func replayForecast(t *testing.T, hourly bool) *ForecastResponse {
+	t.Helper()
+
+	client := NewReplayClient(fixturesDir)
+	client.Units = UnitsUS
+
+	var forecast *ForecastResponse
+	var err error
+	if hourly {
+		forecast, err = client.GetHourlyForecastByCoordinates(39.7456, -97.0892)
+	} else {
+		forecast, err = client.GetForecastByCoordinates(39.7456, -97.0892)
+	}
+	if err != nil {
+		t.Fatalf("failed to replay forecast: %v", err)
+	}
+	return forecast
+}
@@ -0,0 +1,357 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/dwburke/weather/db"
+)
+
+// Schema migrations, applied in version order by `weather db migrate up`. A migration must never
+// change once released; later schema changes are new migrations. Migrations that create tables
+// use the frozen copies of the models below rather than the models themselves, so they keep
+// creating the same schema when the models gain fields.
+func init() {
+	db.RegisterMigrations(
+		db.Migration{
+			Version:     1,
+			Description: "create forecast, observation, alert, grid and location tables",
+			// AutoMigrate also adopts databases created before migrations existed, when every
+			// save created or extended its own table
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&locationV1{}, &forecastRunV1{}, &weatherForecastV1{},
+					&observationV1{}, &weatherAlertV1{}, &gridForecastV1{}).Error
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.DropTableIfExists(&gridForecastV1{}, &weatherAlertV1{}, &observationV1{},
+					&weatherForecastV1{}, &forecastRunV1{}, &locationV1{}).Error
+			},
+		},
+		db.Migration{
+			Version:     2,
+			Description: "add unique keys to forecast runs and forecast periods",
+			Up: func(tx *gorm.DB) error {
+				if err := mergeDuplicateForecastRuns(tx); err != nil {
+					return err
+				}
+				if err := tx.Model(&forecastRunV1{}).AddUniqueIndex("uix_forecast_runs_update",
+					"location_id", "latitude", "longitude", "is_hourly", "provider", "update_time").Error; err != nil {
+					return err
+				}
+				return tx.Model(&weatherForecastV1{}).AddUniqueIndex("uix_weather_forecasts_run_period",
+					"run_id", "period_number", "forecast_date").Error
+			},
+			Down: func(tx *gorm.DB) error {
+				if err := tx.Model(&weatherForecastV1{}).RemoveIndex("uix_weather_forecasts_run_period").Error; err != nil {
+					return err
+				}
+				return tx.Model(&forecastRunV1{}).RemoveIndex("uix_forecast_runs_update").Error
+			},
+		},
+		db.Migration{
+			Version:     3,
+			Description: "add numeric temperature and wind columns to forecast periods",
+			// Existing periods are filled in by `weather db backfill`
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&weatherForecastValuesV3{}).Error
+			},
+			Down: func(tx *gorm.DB) error {
+				for _, column := range weatherForecastValuesV3Columns {
+					if err := tx.Model(&weatherForecastV1{}).DropColumn(column).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		db.Migration{
+			Version:     4,
+			Description: "add precipitation chance, dewpoint and humidity columns to forecast periods",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&weatherForecastQuantitiesV4{}).Error
+			},
+			Down: func(tx *gorm.DB) error {
+				for _, column := range weatherForecastQuantitiesV4Columns {
+					if err := tx.Model(&weatherForecastV1{}).DropColumn(column).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		db.Migration{
+			Version:     5,
+			Description: "add a unique key to observations",
+			Up: func(tx *gorm.DB) error {
+				if err := deleteDuplicateObservations(tx); err != nil {
+					return err
+				}
+				return tx.Model(&observationV1{}).AddUniqueIndex("uix_weather_observations_station_time",
+					"station_id", "timestamp", "latitude", "longitude").Error
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Model(&observationV1{}).RemoveIndex("uix_weather_observations_station_time").Error
+			},
+		},
+		db.Migration{
+			Version:     6,
+			Description: "add location IDs to observations",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&observationLocationV6{}).Error
+			},
+			Down: func(tx *gorm.DB) error {
+				// SQLite cannot drop an indexed column
+				if err := tx.Model(&observationLocationV6{}).RemoveIndex("idx_weather_observations_location_id").Error; err != nil {
+					return err
+				}
+				return tx.Model(&observationLocationV6{}).DropColumn("location_id").Error
+			},
+		},
+	)
+
+	db.RegisterHypertable(WeatherForecast{}.TableName(), "forecast_date")
+	db.RegisterHypertable(Observation{}.TableName(), "timestamp")
+}
+
+// mergeDuplicateForecastRuns deletes the runs, and their periods, that repeat the location, type,
+// provider and update time of an earlier run. Before runs were unique, every fetch created a run
+// even when NWS had not updated the forecast since the previous one.
+func mergeDuplicateForecastRuns(tx *gorm.DB) error {
+	var runs []forecastRunV1
+	if err := tx.Where("update_time IS NOT NULL").Order("id ASC").Find(&runs).Error; err != nil {
+		return err
+	}
+
+	type runKey struct {
+		locationID uint
+		lat, lon   float64
+		isHourly   bool
+		provider   string
+		updateTime int64
+	}
+
+	seen := make(map[runKey]bool)
+	var duplicates []uint
+	for _, run := range runs {
+		key := runKey{run.LocationID, run.Latitude, run.Longitude, run.IsHourly, run.Provider, run.UpdateTime.UnixNano()}
+		if seen[key] {
+			duplicates = append(duplicates, run.ID)
+			continue
+		}
+		seen[key] = true
+	}
+
+	for start := 0; start < len(duplicates); start += 500 {
+		batch := duplicates[start:min(start+500, len(duplicates))]
+		if err := tx.Where("run_id IN (?)", batch).Delete(&weatherForecastV1{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN (?)", batch).Delete(&forecastRunV1{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteDuplicateObservations keeps the first of the observations stored more than once for the
+// same station, time and coordinates, which concurrent saves could insert before observations
+// had a unique key
+func deleteDuplicateObservations(tx *gorm.DB) error {
+	scope := tx.NewScope(&observationV1{})
+	table := scope.QuotedTableName()
+
+	var key []string
+	for _, column := range []string{"station_id", "timestamp", "latitude", "longitude"} {
+		key = append(key, scope.Quote(column))
+	}
+
+	// The derived table lets MySQL delete from the table the subquery reads
+	return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM %s GROUP BY %s) AS first_observations)",
+		table, table, strings.Join(key, ", "))).Error
+}
+
+type locationV1 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Name         string  `gorm:"column:name;not null;unique_index"`
+	Latitude     float64 `gorm:"column:latitude;not null"`
+	Longitude    float64 `gorm:"column:longitude;not null"`
+	TimeZone     string  `gorm:"column:timezone"`
+	Provider     string  `gorm:"column:provider"`
+	GridID       string  `gorm:"column:grid_id"`
+	GridX        int     `gorm:"column:grid_x"`
+	GridY        int     `gorm:"column:grid_y"`
+	ForecastZone string  `gorm:"column:forecast_zone"`
+	County       string  `gorm:"column:county"`
+	Stations     string  `gorm:"column:stations"`
+}
+
+func (locationV1) TableName() string { return "locations" }
+
+type forecastRunV1 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+
+	LocationID  uint       `gorm:"column:location_id;index"`
+	Latitude    float64    `gorm:"column:latitude;not null"`
+	Longitude   float64    `gorm:"column:longitude;not null"`
+	PlaceName   string     `gorm:"column:place_name"`
+	GeneratedAt *time.Time `gorm:"column:generated_at"`
+	UpdateTime  *time.Time `gorm:"column:update_time"`
+	Provider    string     `gorm:"column:provider;index"`
+	RetrievedAt time.Time  `gorm:"column:retrieved_at;not null;index"`
+	IsHourly    bool       `gorm:"column:is_hourly;index"`
+	PeriodCount int        `gorm:"column:period_count"`
+}
+
+func (forecastRunV1) TableName() string { return "forecast_runs" }
+
+type weatherForecastV1 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	LocationID       uint      `gorm:"column:location_id;index"`
+	Latitude         float64   `gorm:"column:latitude;not null"`
+	Longitude        float64   `gorm:"column:longitude;not null"`
+	PlaceName        string    `gorm:"column:place_name"`
+	PeriodNumber     int       `gorm:"column:period_number;not null"`
+	Name             string    `gorm:"column:name;not null"`
+	StartTime        time.Time `gorm:"column:start_time;not null"`
+	EndTime          time.Time `gorm:"column:end_time;not null"`
+	IsDaytime        bool      `gorm:"column:is_daytime"`
+	Temperature      int       `gorm:"column:temperature"`
+	TemperatureUnit  string    `gorm:"column:temperature_unit"`
+	TemperatureTrend string    `gorm:"column:temperature_trend"`
+	WindSpeed        string    `gorm:"column:wind_speed"`
+	WindDirection    string    `gorm:"column:wind_direction"`
+	Icon             string    `gorm:"column:icon"`
+	ShortForecast    string    `gorm:"column:short_forecast"`
+	DetailedForecast string    `gorm:"column:detailed_forecast;type:text"`
+	Provider         string    `gorm:"column:provider"`
+	RunID            uint      `gorm:"column:run_id;index"`
+	ForecastDate     time.Time `gorm:"column:forecast_date;index"`
+	IsHourly         bool      `gorm:"column:is_hourly;index"`
+}
+
+func (weatherForecastV1) TableName() string { return "weather_forecasts" }
+
+type observationV1 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+
+	Latitude                float64   `gorm:"column:latitude;not null"`
+	Longitude               float64   `gorm:"column:longitude;not null"`
+	StationID               string    `gorm:"column:station_id;index"`
+	Timestamp               time.Time `gorm:"column:timestamp;not null;index"`
+	TextDescription         string    `gorm:"column:text_description"`
+	TemperatureC            *float64  `gorm:"column:temperature_c"`
+	DewpointC               *float64  `gorm:"column:dewpoint_c"`
+	RelativeHumidity        *float64  `gorm:"column:relative_humidity"`
+	WindDirectionDeg        *float64  `gorm:"column:wind_direction_deg"`
+	WindSpeedKmh            *float64  `gorm:"column:wind_speed_kmh"`
+	WindGustKmh             *float64  `gorm:"column:wind_gust_kmh"`
+	BarometricPressurePa    *float64  `gorm:"column:barometric_pressure_pa"`
+	SeaLevelPressurePa      *float64  `gorm:"column:sea_level_pressure_pa"`
+	VisibilityM             *float64  `gorm:"column:visibility_m"`
+	PrecipitationLastHourMm *float64  `gorm:"column:precipitation_last_hour_mm"`
+}
+
+func (observationV1) TableName() string { return "weather_observations" }
+
+type weatherAlertV1 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	AlertID       string     `gorm:"column:alert_id;not null;unique_index"`
+	MessageType   string     `gorm:"column:message_type"`
+	Status        string     `gorm:"column:status"`
+	References    string     `gorm:"column:reference_ids;type:text"`
+	Event         string     `gorm:"column:event;index"`
+	Severity      string     `gorm:"column:severity;index"`
+	Urgency       string     `gorm:"column:urgency"`
+	Certainty     string     `gorm:"column:certainty"`
+	Headline      string     `gorm:"column:headline;type:text"`
+	Description   string     `gorm:"column:description;type:text"`
+	Instruction   string     `gorm:"column:instruction;type:text"`
+	SenderName    string     `gorm:"column:sender_name"`
+	AreaDesc      string     `gorm:"column:area_desc;type:text"`
+	AffectedZones string     `gorm:"column:affected_zones;type:text"`
+	Polygon       string     `gorm:"column:polygon;type:text"`
+	Sent          *time.Time `gorm:"column:sent"`
+	Effective     *time.Time `gorm:"column:effective"`
+	Onset         *time.Time `gorm:"column:onset"`
+	Expires       *time.Time `gorm:"column:expires;index"`
+	Ends          *time.Time `gorm:"column:ends"`
+	SupersededBy  string     `gorm:"column:superseded_by"`
+	Cancelled     bool       `gorm:"column:cancelled"`
+	FirstSeenAt   time.Time  `gorm:"column:first_seen_at"`
+	LastSeenAt    time.Time  `gorm:"column:last_seen_at;index"`
+}
+
+func (weatherAlertV1) TableName() string { return "weather_alerts" }
+
+type gridForecastV1 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+
+	Latitude                   float64    `gorm:"column:latitude;not null"`
+	Longitude                  float64    `gorm:"column:longitude;not null"`
+	ValidTime                  time.Time  `gorm:"column:valid_time;not null;index"`
+	Temperature                *float64   `gorm:"column:temperature"`
+	Dewpoint                   *float64   `gorm:"column:dewpoint"`
+	RelativeHumidity           *float64   `gorm:"column:relative_humidity"`
+	ApparentTemperature        *float64   `gorm:"column:apparent_temperature"`
+	SkyCover                   *float64   `gorm:"column:sky_cover"`
+	WindDirection              *float64   `gorm:"column:wind_direction"`
+	WindSpeed                  *float64   `gorm:"column:wind_speed"`
+	WindGust                   *float64   `gorm:"column:wind_gust"`
+	ProbabilityOfPrecipitation *float64   `gorm:"column:probability_of_precipitation"`
+	QuantitativePrecipitation  *float64   `gorm:"column:quantitative_precipitation"`
+	SnowfallAmount             *float64   `gorm:"column:snowfall_amount"`
+	IceAccumulation            *float64   `gorm:"column:ice_accumulation"`
+	UpdateTime                 *time.Time `gorm:"column:update_time"`
+	ForecastDate               time.Time  `gorm:"column:forecast_date;index"`
+}
+
+func (gridForecastV1) TableName() string { return "grid_forecasts" }
+
+// weatherForecastValuesV3 holds only the columns migration 3 adds to weather_forecasts
+type weatherForecastValuesV3 struct {
+	TemperatureCelsius    *float64 `gorm:"column:temperature_c"`
+	TemperatureFahrenheit *float64 `gorm:"column:temperature_f"`
+	WindSpeedMinKmh       *float64 `gorm:"column:wind_speed_min_kmh"`
+	WindSpeedMaxKmh       *float64 `gorm:"column:wind_speed_max_kmh"`
+	WindGustKmh           *float64 `gorm:"column:wind_gust_kmh"`
+	WindDirectionDeg      *float64 `gorm:"column:wind_direction_deg"`
+}
+
+func (weatherForecastValuesV3) TableName() string { return "weather_forecasts" }
+
+var weatherForecastValuesV3Columns = []string{"temperature_c", "temperature_f", "wind_speed_min_kmh",
+	"wind_speed_max_kmh", "wind_gust_kmh", "wind_direction_deg"}
+
+// weatherForecastQuantitiesV4 holds only the columns migration 4 adds to weather_forecasts
+type weatherForecastQuantitiesV4 struct {
+	PrecipitationProbability *float64 `gorm:"column:precipitation_probability"`
+	DewpointC                *float64 `gorm:"column:dewpoint_c"`
+	RelativeHumidity         *float64 `gorm:"column:relative_humidity"`
+}
+
+func (weatherForecastQuantitiesV4) TableName() string { return "weather_forecasts" }
+
+var weatherForecastQuantitiesV4Columns = []string{"precipitation_probability", "dewpoint_c", "relative_humidity"}
+
+// observationLocationV6 holds only the column migration 6 adds to weather_observations
+type observationLocationV6 struct {
+	LocationID uint `gorm:"column:location_id;index"`
+}
+
+func (observationLocationV6) TableName() string { return "weather_observations" }
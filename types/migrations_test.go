@@ -0,0 +1,139 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dwburke/weather/db"
+)
+
+func TestDeleteDuplicateObservations(t *testing.T) {
+	// DB refuses to return an outdated database, so get the connection first
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Duplicates can only be stored before migration 5 adds the unique key
+	if _, err := db.GetDB().MigrateDown(4); err != nil {
+		t.Fatalf("MigrateDown(4) error = %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.GetDB().MigrateUp(0); err != nil {
+			t.Fatalf("MigrateUp() error = %v", err)
+		}
+	})
+
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	first := 5.0
+	rows := []observationV1{
+		{StationID: "KPUB", Timestamp: at, Latitude: 38.2544, Longitude: -104.6091, TemperatureC: &first},
+		{StationID: "KPUB", Timestamp: at, Latitude: 38.2544, Longitude: -104.6091},
+		{StationID: "KPUB", Timestamp: at, Latitude: 38.2544, Longitude: -104.6091},
+		{StationID: "KPUB", Timestamp: at.Add(time.Hour), Latitude: 38.2544, Longitude: -104.6091},
+		{StationID: "KPUB", Timestamp: at, Latitude: 38.2700, Longitude: -104.6091},
+		{StationID: "KCOS", Timestamp: at, Latitude: 38.2544, Longitude: -104.6091},
+	}
+	for i := range rows {
+		if err := gdbh.Create(&rows[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := db.GetDB().MigrateUp(5); err != nil {
+		t.Fatalf("MigrateUp(5) error = %v", err)
+	}
+
+	var stored []observationV1
+	if err := gdbh.Where("station_id IN (?)", []string{"KPUB", "KCOS"}).Order("id").Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 4 {
+		t.Fatalf("kept %d observations, want 4", len(stored))
+	}
+	if stored[0].ID != rows[0].ID || stored[0].TemperatureC == nil {
+		t.Errorf("kept observation %d, want the first one stored (%d)", stored[0].ID, rows[0].ID)
+	}
+
+	// The unique key now rejects another copy
+	duplicate := observationV1{StationID: "KPUB", Timestamp: at, Latitude: 38.2544, Longitude: -104.6091}
+	if err := gdbh.Create(&duplicate).Error; err == nil {
+		t.Error("stored a duplicate observation after migration 5")
+	}
+}
+
+func TestMergeDuplicateForecastRuns(t *testing.T) {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Duplicate runs can only be stored before migration 2 adds the unique keys
+	if _, err := db.GetDB().MigrateDown(1); err != nil {
+		t.Fatalf("MigrateDown(1) error = %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.GetDB().MigrateUp(0); err != nil {
+			t.Fatalf("MigrateUp() error = %v", err)
+		}
+	})
+
+	updated := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	later := updated.Add(time.Hour)
+	runs := []forecastRunV1{
+		{Latitude: 37.2753, Longitude: -107.8801, IsHourly: true, Provider: "nws", UpdateTime: &updated},
+		{Latitude: 37.2753, Longitude: -107.8801, IsHourly: true, Provider: "nws", UpdateTime: &updated},
+		{Latitude: 37.2753, Longitude: -107.8801, IsHourly: false, Provider: "nws", UpdateTime: &updated},
+		{Latitude: 37.2753, Longitude: -107.8801, IsHourly: true, Provider: "nws", UpdateTime: &later},
+		{Latitude: 37.2753, Longitude: -107.8801, IsHourly: true, Provider: "openmeteo", UpdateTime: &updated},
+		{Latitude: 37.2753, Longitude: -107.8801, IsHourly: true, Provider: "nws"},
+		{Latitude: 37.2753, Longitude: -107.8801, IsHourly: true, Provider: "nws"},
+		{Latitude: 37.2753, Longitude: -107.8801, IsHourly: true, Provider: "nws", UpdateTime: &updated},
+	}
+	for i := range runs {
+		runs[i].RetrievedAt = updated.Add(time.Duration(i) * time.Minute)
+		if err := gdbh.Create(&runs[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		period := weatherForecastV1{
+			Latitude: 37.2753, Longitude: -107.8801, PeriodNumber: 1, Name: "Tonight",
+			StartTime: updated, EndTime: updated.Add(time.Hour), ForecastDate: updated, RunID: runs[i].ID,
+		}
+		if err := gdbh.Create(&period).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := db.GetDB().MigrateUp(2); err != nil {
+		t.Fatalf("MigrateUp(2) error = %v", err)
+	}
+
+	// The second and last runs repeat the first; runs without an update time are all kept
+	want := []uint{runs[0].ID, runs[2].ID, runs[3].ID, runs[4].ID, runs[5].ID, runs[6].ID}
+
+	var kept []forecastRunV1
+	if err := gdbh.Where("latitude = ? AND longitude = ?", 37.2753, -107.8801).Order("id").Find(&kept).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %d runs, want %d", len(kept), len(want))
+	}
+	for i, run := range kept {
+		if run.ID != want[i] {
+			t.Errorf("kept run %d, want %d", run.ID, want[i])
+		}
+	}
+
+	var periods []weatherForecastV1
+	if err := gdbh.Where("latitude = ? AND longitude = ?", 37.2753, -107.8801).Find(&periods).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) != len(want) {
+		t.Errorf("kept %d periods, want the %d of the kept runs", len(periods), len(want))
+	}
+	for _, period := range periods {
+		if period.RunID == runs[1].ID || period.RunID == runs[7].ID {
+			t.Errorf("kept period %d of merged run %d", period.ID, period.RunID)
+		}
+	}
+}
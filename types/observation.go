@@ -0,0 +1,273 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/dwburke/weather/db"
+)
+
+// Observation represents a measured weather observation stored in the database.
+// Values are stored in SI units and are nil when the station did not report them.
+type Observation struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Location information (the coordinates the observation was collected for)
+	LocationID uint    `json:"location_id" gorm:"column:location_id;index"` // Named location, 0 for bare coordinates
+	Latitude   float64 `json:"latitude" gorm:"column:latitude;not null"`
+	Longitude  float64 `json:"longitude" gorm:"column:longitude;not null"`
+	StationID  string  `json:"station_id" gorm:"column:station_id;index"`
+
+	// Observation time
+	Timestamp time.Time `json:"timestamp" gorm:"column:timestamp;not null;index"`
+
+	// Measured values
+	TextDescription         string   `json:"text_description" gorm:"column:text_description"`
+	TemperatureC            *float64 `json:"temperature_c" gorm:"column:temperature_c"`
+	DewpointC               *float64 `json:"dewpoint_c" gorm:"column:dewpoint_c"`
+	RelativeHumidity        *float64 `json:"relative_humidity" gorm:"column:relative_humidity"`
+	WindDirectionDeg        *float64 `json:"wind_direction_deg" gorm:"column:wind_direction_deg"`
+	WindSpeedKmh            *float64 `json:"wind_speed_kmh" gorm:"column:wind_speed_kmh"`
+	WindGustKmh             *float64 `json:"wind_gust_kmh" gorm:"column:wind_gust_kmh"`
+	BarometricPressurePa    *float64 `json:"barometric_pressure_pa" gorm:"column:barometric_pressure_pa"`
+	SeaLevelPressurePa      *float64 `json:"sea_level_pressure_pa" gorm:"column:sea_level_pressure_pa"`
+	VisibilityM             *float64 `json:"visibility_m" gorm:"column:visibility_m"`
+	PrecipitationLastHourMm *float64 `json:"precipitation_last_hour_mm" gorm:"column:precipitation_last_hour_mm"`
+}
+
+func (Observation) TableName() string {
+	return "weather_observations"
+}
+
+// observationKey is the unique key of a stored observation. The same station observation is kept
+// once for every set of coordinates it was collected for, so locations sharing their nearest
+// station each find it by their own coordinates.
+var observationKey = []string{"station_id", "timestamp", "latitude", "longitude"}
+
+// Create saves a new observation record to the database
+func (o *Observation) Create() error {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return err
+	}
+
+	if err := gdbh.Create(&o).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetObservations retrieves observations for given coordinates within [from, to), oldest first
+func GetObservations(lat, lon float64, from, to time.Time) ([]Observation, error) {
+	return GetObservationsContext(context.Background(), lat, lon, from, to)
+}
+
+// GetObservationsContext is like GetObservations but returns early once ctx is done
+func GetObservationsContext(ctx context.Context, lat, lon float64, from, to time.Time) ([]Observation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var observations []Observation
+
+	if err := gdbh.Where("latitude = ? AND longitude = ? AND timestamp >= ? AND timestamp < ?", lat, lon, from, to).
+		Order("timestamp ASC").
+		Find(&observations).Error; err != nil {
+		return nil, err
+	}
+
+	return observations, nil
+}
+
+// GetLatestObservation retrieves the most recent observation for given coordinates, or nil if there is none
+func GetLatestObservation(lat, lon float64) (*Observation, error) {
+	return GetLatestObservationContext(context.Background(), lat, lon)
+}
+
+// GetLatestObservationContext is like GetLatestObservation but returns early once ctx is done
+func GetLatestObservationContext(ctx context.Context, lat, lon float64) (*Observation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var observations []Observation
+
+	if err := gdbh.Where("latitude = ? AND longitude = ?", lat, lon).
+		Order("timestamp DESC").
+		Limit(1).
+		Find(&observations).Error; err != nil {
+		return nil, err
+	}
+
+	if len(observations) == 0 {
+		return nil, nil
+	}
+
+	return &observations[0], nil
+}
+
+// ObservationSaveResult reports how saving observations changed the database
+type ObservationSaveResult struct {
+	Inserted int // Observations that were not stored yet
+	Skipped  int // Observations already stored for the same station and time
+}
+
+// String summarizes the result, e.g. "3 new observations saved, 21 already stored"
+func (r *ObservationSaveResult) String() string {
+	return fmt.Sprintf("%d new observations saved, %d already stored", r.Inserted, r.Skipped)
+}
+
+// SaveObservationsToDB saves station observations collected for the given coordinates to the database.
+// Observations already stored for the same station, time and coordinates are skipped.
+func SaveObservationsToDB(observations []ObservationResponse, lat, lon float64) (*ObservationSaveResult, error) {
+	return SaveObservationsToDBContext(context.Background(), observations, lat, lon)
+}
+
+// SaveObservationsToDBContext is like SaveObservationsToDB but stops between batches once ctx is done
+func SaveObservationsToDBContext(ctx context.Context, observations []ObservationResponse, lat, lon float64) (*ObservationSaveResult, error) {
+	return saveObservations(ctx, observations, 0, lat, lon)
+}
+
+// SaveObservationsForLocation saves station observations collected for a named location, keeping its ID
+func SaveObservationsForLocation(observations []ObservationResponse, loc *Location) (*ObservationSaveResult, error) {
+	return SaveObservationsForLocationContext(context.Background(), observations, loc)
+}
+
+// SaveObservationsForLocationContext is like SaveObservationsForLocation but stops between batches once ctx is done
+func SaveObservationsForLocationContext(ctx context.Context, observations []ObservationResponse, loc *Location) (*ObservationSaveResult, error) {
+	return saveObservations(ctx, observations, loc.ID, loc.Latitude, loc.Longitude)
+}
+
+func saveObservations(ctx context.Context, observations []ObservationResponse, locationID uint, lat, lon float64) (*ObservationSaveResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Observation, 0, len(observations))
+	for _, response := range observations {
+		p := response.Properties
+
+		timestamp, err := time.Parse(time.RFC3339, p.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, Observation{
+			LocationID:              locationID,
+			Latitude:                lat,
+			Longitude:               lon,
+			StationID:               p.StationID(),
+			Timestamp:               timestamp,
+			TextDescription:         p.TextDescription,
+			TemperatureC:            celsiusValue(p.Temperature),
+			DewpointC:               celsiusValue(p.Dewpoint),
+			RelativeHumidity:        p.RelativeHumidity.Value,
+			WindDirectionDeg:        p.WindDirection.Value,
+			WindSpeedKmh:            kmhValue(p.WindSpeed),
+			WindGustKmh:             kmhValue(p.WindGust),
+			BarometricPressurePa:    p.BarometricPressure.Value,
+			SeaLevelPressurePa:      p.SeaLevelPressure.Value,
+			VisibilityM:             p.Visibility.Value,
+			PrecipitationLastHourMm: p.PrecipitationLastHour.Value,
+		})
+	}
+
+	result := &ObservationSaveResult{}
+
+	// The unique key skips observations that are already stored, also when another process
+	// saves the same observations at the same time
+	for start := 0; start < len(rows); start += upsertBatchSize {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		batch := rows[start:min(start+upsertBatchSize, len(rows))]
+		inserted, err := insertObservations(gdbh, batch)
+		if err != nil {
+			return result, err
+		}
+		result.Inserted += int(inserted)
+		result.Skipped += len(batch) - int(inserted)
+	}
+
+	return result, nil
+}
+
+// insertObservations writes observations with one multi-row INSERT, skipping those that are
+// already stored, and returns how many were inserted
+func insertObservations(tx *gorm.DB, observations []Observation) (int64, error) {
+	now := time.Now()
+	scope := tx.NewScope(&Observation{})
+
+	var quoted []string
+	rows := make([][]interface{}, 0, len(observations))
+	for i := range observations {
+		observation := &observations[i]
+		observation.CreatedAt = now
+
+		var row []interface{}
+		for _, field := range tx.NewScope(observation).Fields() {
+			if !field.IsNormal || field.IsIgnored || field.IsPrimaryKey {
+				continue
+			}
+			if i == 0 {
+				quoted = append(quoted, scope.Quote(field.DBName))
+			}
+			row = append(row, field.Field.Interface())
+		}
+		rows = append(rows, row)
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES ? %s", scope.QuotedTableName(), strings.Join(quoted, ", "),
+		db.UpsertClause(scope.Quote, observationKey, nil))
+
+	result := tx.Exec(sql, rows)
+	return result.RowsAffected, result.Error
+}
+
+// celsiusValue returns a temperature value in °C
+func celsiusValue(q QuantitativeValue) *float64 {
+	if q.Value == nil {
+		return nil
+	}
+
+	value := *q.Value
+	if q.UnitCode == "wmoUnit:degF" {
+		value = (value - 32) * 5 / 9
+	}
+	return &value
+}
+
+// kmhValue returns a speed value in km/h
+func kmhValue(q QuantitativeValue) *float64 {
+	if q.Value == nil {
+		return nil
+	}
+
+	value := *q.Value
+	if q.UnitCode == "wmoUnit:m_s-1" {
+		value *= 3.6
+	}
+	return &value
+}
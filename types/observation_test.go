@@ -0,0 +1,178 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// stationObservations returns hourly observations from a station, the first at start
+func stationObservations(station string, start time.Time, count int) []ObservationResponse {
+	observations := make([]ObservationResponse, count)
+	for i := range observations {
+		temperature := 10.0 + float64(i)
+		observations[i].Properties = ObservationProperties{
+			Station:     "https://api.weather.gov/stations/" + station,
+			Timestamp:   start.Add(time.Duration(i) * time.Hour).Format(time.RFC3339),
+			Temperature: QuantitativeValue{Value: &temperature, UnitCode: "wmoUnit:degC"},
+		}
+	}
+	return observations
+}
+
+func TestSaveObservations(t *testing.T) {
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	// Each step saves into the same database, so later steps see earlier saves
+	steps := []struct {
+		name         string
+		observations []ObservationResponse
+		lat, lon     float64
+		want         ObservationSaveResult
+	}{
+		{
+			name:         "first save",
+			observations: stationObservations("KDEN", start, 3),
+			lat:          39.7391, lon: -104.9847,
+			want: ObservationSaveResult{Inserted: 3},
+		},
+		{
+			name:         "same observations again",
+			observations: stationObservations("KDEN", start, 3),
+			lat:          39.7391, lon: -104.9847,
+			want: ObservationSaveResult{Skipped: 3},
+		},
+		{
+			name:         "one new observation",
+			observations: stationObservations("KDEN", start, 4),
+			lat:          39.7391, lon: -104.9847,
+			want: ObservationSaveResult{Inserted: 1, Skipped: 3},
+		},
+		{
+			name:         "same station for other coordinates",
+			observations: stationObservations("KDEN", start, 2),
+			lat:          39.8561, lon: -104.6737,
+			want: ObservationSaveResult{Inserted: 2},
+		},
+		{
+			name:         "other station at the same time",
+			observations: stationObservations("KBJC", start, 2),
+			lat:          39.7391, lon: -104.9847,
+			want: ObservationSaveResult{Inserted: 2},
+		},
+		{
+			name:         "more than one batch",
+			observations: stationObservations("KAPA", start, upsertBatchSize+5),
+			lat:          39.7391, lon: -104.9847,
+			want: ObservationSaveResult{Inserted: upsertBatchSize + 5},
+		},
+		{
+			name:         "nothing to save",
+			observations: nil,
+			lat:          39.7391, lon: -104.9847,
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			result, err := SaveObservationsToDB(step.observations, step.lat, step.lon)
+			if err != nil {
+				t.Fatalf("SaveObservationsToDB() error = %v", err)
+			}
+			if *result != step.want {
+				t.Errorf("SaveObservationsToDB() = %s, want %s", result, &step.want)
+			}
+		})
+	}
+
+	stored, err := GetObservations(39.7391, -104.9847, start, start.Add(4*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kden := 0
+	for _, observation := range stored {
+		if observation.StationID == "KDEN" {
+			kden++
+		}
+	}
+	if kden != 4 {
+		t.Errorf("stored %d KDEN observations, want 4", kden)
+	}
+	if first := stored[0]; first.TemperatureC == nil || *first.TemperatureC != 10 {
+		t.Errorf("first observation temperature = %v, want 10", first.TemperatureC)
+	}
+}
+
+func TestSaveObservationsErrors(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	bad := stationObservations("KCOS", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), 2)
+	bad[1].Properties.Timestamp = "yesterday"
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		observations []ObservationResponse
+		wantErr      error
+	}{
+		{name: "cancelled", ctx: cancelled, observations: stationObservations("KCOS", time.Now(), 2), wantErr: context.Canceled},
+		{name: "invalid timestamp", ctx: context.Background(), observations: bad},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SaveObservationsToDBContext(tt.ctx, tt.observations, 38.8339, -104.8214)
+			if err == nil {
+				t.Fatal("SaveObservationsToDBContext() error = nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("SaveObservationsToDBContext() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// Nothing is saved when an observation cannot be read
+			stored, err := GetObservations(38.8339, -104.8214, time.Time{}, time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != 0 {
+				t.Errorf("stored %d observations, want none", len(stored))
+			}
+		})
+	}
+}
+
+func TestObservationSaveResultString(t *testing.T) {
+	result := ObservationSaveResult{Inserted: 3, Skipped: 21}
+	if got, want := result.String(), fmt.Sprintf("%d new observations saved, %d already stored", 3, 21); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestSaveObservationsForLocation(t *testing.T) {
+	start := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	loc := &Location{ID: 960, Latitude: 40.0150, Longitude: -105.2705}
+
+	result, err := SaveObservationsForLocation(stationObservations("KBDU", start, 2), loc)
+	if err != nil {
+		t.Fatalf("SaveObservationsForLocation() error = %v", err)
+	}
+	if result.Inserted != 2 {
+		t.Errorf("SaveObservationsForLocation() = %s, want 2 inserted", result)
+	}
+
+	stored, err := GetObservations(loc.Latitude, loc.Longitude, start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("stored %d observations, want 2", len(stored))
+	}
+	for _, observation := range stored {
+		if observation.LocationID != loc.ID {
+			t.Errorf("observation stored for location %d, want %d", observation.LocationID, loc.ID)
+		}
+	}
+}
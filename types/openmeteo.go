@@ -0,0 +1,346 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// openMeteoForecastDays is how many days of forecast are requested from Open-Meteo
+const openMeteoForecastDays = 7
+
+// maxHourlyPeriods matches the length of the NWS hourly forecast
+const maxHourlyPeriods = 156
+
+// OpenMeteoClient gets forecasts from the Open-Meteo API (open-meteo.com), which covers the
+// whole world. Forecasts are returned in °C and km/h, normalized into the NWS period layout:
+// daily forecasts are split into 6am-6pm day and 6pm-6am night periods.
+type OpenMeteoClient struct {
+	BaseURL     string
+	HTTPClient  *http.Client
+	RetryPolicy RetryPolicy   // How failed requests are retried
+	Limiter     *rate.Limiter // Optional request rate limiter
+}
+
+// NewOpenMeteoClient returns a client for the public Open-Meteo API
+func NewOpenMeteoClient() *OpenMeteoClient {
+	return &OpenMeteoClient{
+		BaseURL: "https://api.open-meteo.com/v1",
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		RetryPolicy: DefaultRetryPolicy(),
+	}
+}
+
+// openMeteoResponse is the subset of the /forecast response that is used
+type openMeteoResponse struct {
+	Timezone         string `json:"timezone"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+
+	Hourly struct {
+		Time                     []string   `json:"time"`
+		Temperature              []*float64 `json:"temperature_2m"`
+		WeatherCode              []*int     `json:"weather_code"`
+		WindSpeed                []*float64 `json:"wind_speed_10m"`
+		WindDirection            []*float64 `json:"wind_direction_10m"`
+		PrecipitationProbability []*float64 `json:"precipitation_probability"`
+		IsDay                    []*int     `json:"is_day"`
+	} `json:"hourly"`
+
+	Daily struct {
+		Time                     []string   `json:"time"`
+		WeatherCode              []*int     `json:"weather_code"`
+		TemperatureMax           []*float64 `json:"temperature_2m_max"`
+		TemperatureMin           []*float64 `json:"temperature_2m_min"`
+		PrecipitationProbability []*float64 `json:"precipitation_probability_max"`
+		WindSpeedMax             []*float64 `json:"wind_speed_10m_max"`
+		WindDirection            []*float64 `json:"wind_direction_10m_dominant"`
+	} `json:"daily"`
+}
+
+// Name returns the provider name of the Open-Meteo client
+func (o *OpenMeteoClient) Name() string {
+	return ProviderOpenMeteo
+}
+
+// Forecast gets the daily forecast as day and night periods, implementing ForecastProvider
+func (o *OpenMeteoClient) Forecast(ctx context.Context, lat, lon float64) (*ForecastResponse, error) {
+	query := url.Values{}
+	query.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max,wind_speed_10m_max,wind_direction_10m_dominant")
+
+	resp, err := o.getForecast(ctx, lat, lon, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forecast data: %w", err)
+	}
+
+	loc := resp.location()
+	now := time.Now()
+	daily := resp.Daily
+
+	var periods []ForecastPeriod
+	for i, date := range daily.Time {
+		day, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid forecast date %q: %w", date, err)
+		}
+
+		dayStart := day.Add(6 * time.Hour)
+		nightStart := day.Add(18 * time.Hour)
+		nightEnd := day.AddDate(0, 0, 1).Add(6 * time.Hour)
+
+		code := intAt(daily.WeatherCode, i)
+		conditions := weatherCodeDescription(code)
+		pop := floatAt(daily.PrecipitationProbability, i)
+		windSpeed := floatAt(daily.WindSpeedMax, i)
+		windDirection := compassDirection(floatAt(daily.WindDirection, i))
+
+		// The night low is the minimum of the following morning when it is available
+		low := floatAt(daily.TemperatureMin, i)
+		if i+1 < len(daily.Time) && floatAt(daily.TemperatureMin, i+1) != nil {
+			low = floatAt(daily.TemperatureMin, i+1)
+		}
+		high := floatAt(daily.TemperatureMax, i)
+
+		dayName, nightName := day.Weekday().String(), day.Weekday().String()+" Night"
+		if i == 0 {
+			dayName, nightName = "Today", "Tonight"
+		}
+
+		if nightStart.After(now) && high != nil {
+			periods = append(periods, ForecastPeriod{
+				Name:                       dayName,
+				StartTime:                  dayStart.Format(time.RFC3339),
+				EndTime:                    nightStart.Format(time.RFC3339),
+				IsDaytime:                  true,
+				Temperature:                roundInt(*high),
+				TemperatureUnit:            "C",
+				WindSpeed:                  formatKmh(windSpeed),
+				WindDirection:              windDirection,
+				ShortForecast:              conditions,
+				DetailedForecast:           openMeteoDetails(conditions, "high", *high, windDirection, windSpeed, pop),
+				ProbabilityOfPrecipitation: percentValue(pop),
+			})
+		}
+
+		if nightEnd.After(now) && low != nil {
+			periods = append(periods, ForecastPeriod{
+				Name:                       nightName,
+				StartTime:                  nightStart.Format(time.RFC3339),
+				EndTime:                    nightEnd.Format(time.RFC3339),
+				IsDaytime:                  false,
+				Temperature:                roundInt(*low),
+				TemperatureUnit:            "C",
+				WindSpeed:                  formatKmh(windSpeed),
+				WindDirection:              windDirection,
+				ShortForecast:              conditions,
+				DetailedForecast:           openMeteoDetails(conditions, "low", *low, windDirection, windSpeed, pop),
+				ProbabilityOfPrecipitation: percentValue(pop),
+			})
+		}
+	}
+
+	for i := range periods {
+		periods[i].Number = i + 1
+	}
+
+	return &ForecastResponse{Provider: ProviderOpenMeteo, Properties: ForecastProperties{Periods: periods}}, nil
+}
+
+// HourlyForecast gets the hourly forecast, implementing HourlyForecastProvider
+func (o *OpenMeteoClient) HourlyForecast(ctx context.Context, lat, lon float64) (*ForecastResponse, error) {
+	query := url.Values{}
+	query.Set("hourly", "temperature_2m,weather_code,wind_speed_10m,wind_direction_10m,precipitation_probability,is_day")
+
+	resp, err := o.getForecast(ctx, lat, lon, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forecast data: %w", err)
+	}
+
+	loc := resp.location()
+	now := time.Now()
+	hourly := resp.Hourly
+
+	var periods []ForecastPeriod
+	for i, value := range hourly.Time {
+		start, err := time.ParseInLocation("2006-01-02T15:04", value, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid forecast time %q: %w", value, err)
+		}
+		end := start.Add(time.Hour)
+
+		temperature := floatAt(hourly.Temperature, i)
+		if !end.After(now) || temperature == nil {
+			continue
+		}
+
+		isDay := intAt(hourly.IsDay, i)
+
+		periods = append(periods, ForecastPeriod{
+			Number:          len(periods) + 1,
+			StartTime:       start.Format(time.RFC3339),
+			EndTime:         end.Format(time.RFC3339),
+			IsDaytime:       isDay != nil && *isDay == 1,
+			Temperature:     roundInt(*temperature),
+			TemperatureUnit: "C",
+			WindSpeed:       formatKmh(floatAt(hourly.WindSpeed, i)),
+			WindDirection:   compassDirection(floatAt(hourly.WindDirection, i)),
+			ShortForecast:   weatherCodeDescription(intAt(hourly.WeatherCode, i)),
+
+			ProbabilityOfPrecipitation: percentValue(floatAt(hourly.PrecipitationProbability, i)),
+		})
+
+		if len(periods) == maxHourlyPeriods {
+			break
+		}
+	}
+
+	return &ForecastResponse{Provider: ProviderOpenMeteo, Properties: ForecastProperties{Periods: periods}}, nil
+}
+
+// getForecast requests /forecast for the coordinates with the given series in the location's time zone
+func (o *OpenMeteoClient) getForecast(ctx context.Context, lat, lon float64, query url.Values) (*openMeteoResponse, error) {
+	query.Set("latitude", fmt.Sprintf("%.4f", lat))
+	query.Set("longitude", fmt.Sprintf("%.4f", lon))
+	query.Set("timezone", "auto")
+	query.Set("forecast_days", fmt.Sprint(openMeteoForecastDays))
+
+	resp, err := getWithRetry(ctx, o.HTTPClient, &o.RetryPolicy, o.Limiter, o.BaseURL+"/forecast?"+query.Encode(), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+
+		// Errors are reported as {"error": true, "reason": "..."}
+		var apiErr struct {
+			Reason string `json:"reason"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Reason != "" {
+			return nil, fmt.Errorf("open-meteo API error: %s - %s", resp.Status, apiErr.Reason)
+		}
+		return nil, fmt.Errorf("open-meteo API error: %s - %s", resp.Status, string(body))
+	}
+
+	var forecast openMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&forecast); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &forecast, nil
+}
+
+// location returns the time zone the response times are given in
+func (r *openMeteoResponse) location() *time.Location {
+	if loc, err := time.LoadLocation(r.Timezone); err == nil {
+		return loc
+	}
+	return time.FixedZone(r.Timezone, r.UTCOffsetSeconds)
+}
+
+// openMeteoDetails builds an NWS style detailed forecast sentence for a daily period
+func openMeteoDetails(conditions, extreme string, temperature float64, windDirection string, windSpeed, pop *float64) string {
+	result := fmt.Sprintf("%s, with a %s near %d°C.", conditions, extreme, roundInt(temperature))
+	if windSpeed != nil {
+		result += fmt.Sprintf(" %s wind up to %s.", windDirection, formatKmh(windSpeed))
+	}
+	if pop != nil && *pop > 0 {
+		result += fmt.Sprintf(" Chance of precipitation is %d%%.", roundInt(*pop))
+	}
+	return result
+}
+
+// weatherCodes describes the WMO weather interpretation codes used by Open-Meteo
+var weatherCodes = map[int]string{
+	0:  "Clear",
+	1:  "Mostly Clear",
+	2:  "Partly Cloudy",
+	3:  "Cloudy",
+	45: "Fog",
+	48: "Freezing Fog",
+	51: "Light Drizzle",
+	53: "Drizzle",
+	55: "Heavy Drizzle",
+	56: "Light Freezing Drizzle",
+	57: "Freezing Drizzle",
+	61: "Light Rain",
+	63: "Rain",
+	65: "Heavy Rain",
+	66: "Light Freezing Rain",
+	67: "Freezing Rain",
+	71: "Light Snow",
+	73: "Snow",
+	75: "Heavy Snow",
+	77: "Snow Grains",
+	80: "Light Rain Showers",
+	81: "Rain Showers",
+	82: "Heavy Rain Showers",
+	85: "Light Snow Showers",
+	86: "Snow Showers",
+	95: "Thunderstorms",
+	96: "Thunderstorms With Hail",
+	99: "Severe Thunderstorms With Hail",
+}
+
+func weatherCodeDescription(code *int) string {
+	if code == nil {
+		return ""
+	}
+	if description, ok := weatherCodes[*code]; ok {
+		return description
+	}
+	return fmt.Sprintf("Weather code %d", *code)
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// compassDirection converts a direction in degrees to a 16-point compass direction
+func compassDirection(degrees *float64) string {
+	if degrees == nil {
+		return ""
+	}
+	index := int(math.Round(math.Mod(*degrees, 360)/22.5)) % len(compassPoints)
+	if index < 0 {
+		index += len(compassPoints)
+	}
+	return compassPoints[index]
+}
+
+func formatKmh(speed *float64) string {
+	if speed == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d km/h", roundInt(*speed))
+}
+
+func roundInt(value float64) int {
+	return int(math.Round(value))
+}
+
+func floatAt(values []*float64, i int) *float64 {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
+
+// percentValue returns a percentage as a quantitative value
+func percentValue(value *float64) QuantitativeValue {
+	return QuantitativeValue{Value: value, UnitCode: "wmoUnit:percent"}
+}
+
+func intAt(values []*int, i int) *int {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
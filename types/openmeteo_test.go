@@ -0,0 +1,230 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// openMeteoServer serves resp from /forecast, recording the query of the last request
+func openMeteoServer(t *testing.T, resp *openMeteoResponse, query *string) *OpenMeteoClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/forecast" {
+			http.NotFound(w, r)
+			return
+		}
+		if query != nil {
+			*query = r.URL.RawQuery
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewOpenMeteoClient()
+	client.BaseURL = server.URL
+	client.HTTPClient = server.Client()
+	return client
+}
+
+func TestOpenMeteoForecast(t *testing.T) {
+	// An abbreviation time.LoadLocation doesn't know falls back to the UTC offset
+	zone := time.FixedZone("MST", -7*60*60)
+	first := time.Now().In(zone).AddDate(0, 0, 1)
+
+	resp := &openMeteoResponse{Timezone: "MST", UTCOffsetSeconds: -7 * 60 * 60}
+	for i := 0; i < 3; i++ {
+		resp.Daily.Time = append(resp.Daily.Time, first.AddDate(0, 0, i).Format("2006-01-02"))
+	}
+	resp.Daily.WeatherCode = []*int{intPtr(61), intPtr(0), intPtr(200)}
+	resp.Daily.TemperatureMax = []*float64{floatPtr(20.4), floatPtr(22.5), nil}
+	resp.Daily.TemperatureMin = []*float64{floatPtr(8.6), floatPtr(10.2), floatPtr(5)}
+	resp.Daily.PrecipitationProbability = []*float64{floatPtr(70), floatPtr(0), nil}
+	resp.Daily.WindSpeedMax = []*float64{floatPtr(19.6), nil, floatPtr(5)}
+	resp.Daily.WindDirection = []*float64{floatPtr(350), floatPtr(225), nil}
+
+	var query string
+	client := openMeteoServer(t, resp, &query)
+
+	forecast, err := client.Forecast(context.Background(), 39.74561, -104.99)
+	if err != nil {
+		t.Fatalf("Forecast() error = %v", err)
+	}
+	if !strings.Contains(query, "latitude=39.7456") || !strings.Contains(query, "longitude=-104.9900") {
+		t.Errorf("query = %s, want the coordinates rounded to 4 decimals", query)
+	}
+	if forecast.Provider != ProviderOpenMeteo {
+		t.Errorf("Provider = %q, want %q", forecast.Provider, ProviderOpenMeteo)
+	}
+
+	second := first.AddDate(0, 0, 1).Weekday().String()
+	third := first.AddDate(0, 0, 2).Weekday().String()
+
+	// The last day has no high, so only its night is forecast
+	want := []struct {
+		name          string
+		isDaytime     bool
+		temperature   int
+		wind          string
+		direction     string
+		shortForecast string
+		startHour     int
+	}{
+		{"Today", true, 20, "20 km/h", "N", "Light Rain", 6},
+		{"Tonight", false, 10, "20 km/h", "N", "Light Rain", 18},
+		{second, true, 23, "", "SW", "Clear", 6},
+		{second + " Night", false, 5, "", "SW", "Clear", 18},
+		{third + " Night", false, 5, "5 km/h", "", "Weather code 200", 18},
+	}
+
+	periods := forecast.Properties.Periods
+	if len(periods) != len(want) {
+		t.Fatalf("got %d periods, want %d", len(periods), len(want))
+	}
+	for i, w := range want {
+		p := periods[i]
+		if p.Number != i+1 || p.Name != w.name || p.IsDaytime != w.isDaytime || p.Temperature != w.temperature ||
+			p.TemperatureUnit != "C" || p.WindSpeed != w.wind || p.WindDirection != w.direction || p.ShortForecast != w.shortForecast {
+			t.Errorf("period %d = %+v, want %+v", i+1, p, w)
+		}
+
+		start, err := time.Parse(time.RFC3339, p.StartTime)
+		if err != nil {
+			t.Fatalf("period %d start %q: %v", i+1, p.StartTime, err)
+		}
+		if _, offset := start.Zone(); start.Hour() != w.startHour || offset != -7*60*60 {
+			t.Errorf("period %d starts at %s, want %02d:00 at -07:00", i+1, p.StartTime, w.startHour)
+		}
+	}
+
+	if want := "Light Rain, with a high near 20°C. N wind up to 20 km/h. Chance of precipitation is 70%."; periods[0].DetailedForecast != want {
+		t.Errorf("DetailedForecast = %q, want %q", periods[0].DetailedForecast, want)
+	}
+	if want := "Clear, with a high near 23°C."; periods[2].DetailedForecast != want {
+		t.Errorf("DetailedForecast = %q, want %q", periods[2].DetailedForecast, want)
+	}
+	if pop := periods[0].ProbabilityOfPrecipitation; pop.Value == nil || *pop.Value != 70 || pop.UnitCode != "wmoUnit:percent" {
+		t.Errorf("ProbabilityOfPrecipitation = %+v, want 70 percent", pop)
+	}
+}
+
+func TestOpenMeteoHourlyForecast(t *testing.T) {
+	start := time.Now().UTC().Truncate(time.Hour).Add(-3 * time.Hour)
+
+	resp := &openMeteoResponse{Timezone: "UTC"}
+	for i := 0; i < 200; i++ {
+		resp.Hourly.Time = append(resp.Hourly.Time, start.Add(time.Duration(i)*time.Hour).Format("2006-01-02T15:04"))
+		resp.Hourly.Temperature = append(resp.Hourly.Temperature, floatPtr(float64(i)+0.4))
+		resp.Hourly.IsDay = append(resp.Hourly.IsDay, intPtr(i%2))
+	}
+	resp.Hourly.Temperature[4] = nil
+	resp.Hourly.WindSpeed = []*float64{nil, nil, nil, floatPtr(12.5)}
+	resp.Hourly.WindDirection = []*float64{nil, nil, nil, floatPtr(-90)}
+	resp.Hourly.WeatherCode = []*int{nil, nil, nil, intPtr(3)}
+
+	forecast, err := openMeteoServer(t, resp, nil).HourlyForecast(context.Background(), 51.5072, -0.1276)
+	if err != nil {
+		t.Fatalf("HourlyForecast() error = %v", err)
+	}
+
+	// Hours that have ended and hours without a temperature are left out, and the forecast is
+	// cut to the length of an NWS hourly forecast
+	periods := forecast.Properties.Periods
+	if len(periods) != maxHourlyPeriods {
+		t.Fatalf("got %d periods, want %d", len(periods), maxHourlyPeriods)
+	}
+
+	current := periods[0]
+	if want := start.Add(3 * time.Hour).Format(time.RFC3339); current.StartTime != want {
+		t.Errorf("first period starts at %s, want the current hour %s", current.StartTime, want)
+	}
+	if current.Number != 1 || current.Temperature != 3 || !current.IsDaytime || current.WindSpeed != "13 km/h" || current.WindDirection != "W" || current.ShortForecast != "Cloudy" {
+		t.Errorf("first period = %+v, want 3°C in the day, 13 km/h W, Cloudy", current)
+	}
+
+	next := periods[1]
+	if next.Number != 2 || next.Temperature != 5 || !next.IsDaytime || next.WindSpeed != "" || next.ShortForecast != "" {
+		t.Errorf("second period = %+v, want the hour without a temperature skipped and 5°C in the day", next)
+	}
+}
+
+func TestOpenMeteoErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "reason",
+			status:  http.StatusBadRequest,
+			body:    `{"error": true, "reason": "Latitude must be in range of -90 to 90°."}`,
+			wantErr: "open-meteo API error: 400 Bad Request - Latitude must be in range of -90 to 90°.",
+		},
+		{
+			name:    "plain body",
+			status:  http.StatusNotFound,
+			body:    "no such endpoint",
+			wantErr: "open-meteo API error: 404 Not Found - no such endpoint",
+		},
+		{
+			name:    "invalid time",
+			status:  http.StatusOK,
+			body:    `{"timezone": "UTC", "daily": {"time": ["tomorrow"]}}`,
+			wantErr: `invalid forecast date "tomorrow"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewOpenMeteoClient()
+			client.BaseURL = server.URL
+			client.HTTPClient = server.Client()
+
+			_, err := client.Forecast(context.Background(), 95, 0)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Forecast() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompassDirection(t *testing.T) {
+	tests := []struct {
+		degrees float64
+		want    string
+	}{
+		{0, "N"},
+		{11, "N"},
+		{12, "NNE"},
+		{180, "S"},
+		{348.75, "N"},
+		{360, "N"},
+		{-22.5, "NNW"},
+		{725, "N"},
+	}
+
+	for _, tt := range tests {
+		if got := compassDirection(&tt.degrees); got != tt.want {
+			t.Errorf("compassDirection(%v) = %q, want %q", tt.degrees, got, tt.want)
+		}
+	}
+	if got := compassDirection(nil); got != "" {
+		t.Errorf("compassDirection(nil) = %q, want empty", got)
+	}
+}
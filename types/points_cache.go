@@ -0,0 +1,163 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultPointsCacheTTL is how long /points metadata is reused before it is looked up again.
+// A point's grid assignment very rarely changes, and stale entries are also dropped when a
+// forecast URL taken from them stops working.
+const DefaultPointsCacheTTL = 24 * time.Hour
+
+// PointsCache caches /points lookups by coordinates. It is safe for concurrent use and can
+// optionally persist its entries to a JSON file so they survive across runs.
+type PointsCache struct {
+	TTL    time.Duration
+	Path   string      // File the cache is persisted to, empty for an in-memory cache
+	Logger *log.Logger // Where failures to write Path are logged, the standard logger if nil
+
+	mu      sync.Mutex
+	entries map[string]pointsCacheEntry
+}
+
+type pointsCacheEntry struct {
+	Points    PointsProperties `json:"points"`
+	FetchedAt time.Time        `json:"fetched_at"`
+}
+
+// NewPointsCache returns a points cache with the given TTL. If path is not empty, existing
+// entries are loaded from that file and changes are written back to it.
+func NewPointsCache(ttl time.Duration, path string) (*PointsCache, error) {
+	cache := &PointsCache{
+		TTL:     ttl,
+		Path:    path,
+		entries: make(map[string]pointsCacheEntry),
+	}
+
+	if path == "" {
+		return cache, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read points cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		// A corrupt cache file is not fatal, it is rebuilt on the next lookup
+		cache.entries = make(map[string]pointsCacheEntry)
+	}
+
+	return cache, nil
+}
+
+// Get returns the cached points metadata for the coordinates if present and not expired
+func (c *PointsCache) Get(lat, lon float64) (*PointsProperties, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[pointsCacheKey(lat, lon)]
+	if !ok || (c.TTL > 0 && time.Since(entry.FetchedAt) > c.TTL) {
+		return nil, false
+	}
+
+	points := entry.Points
+	return &points, true
+}
+
+// Set stores the points metadata for the coordinates
+func (c *PointsCache) Set(lat, lon float64, points *PointsProperties) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[pointsCacheKey(lat, lon)] = pointsCacheEntry{Points: *points, FetchedAt: time.Now()}
+	if err := c.persist(); err != nil {
+		c.logf("points cache: failed to write %s: %v", c.Path, err)
+	}
+}
+
+// Delete removes the points metadata for the coordinates
+func (c *PointsCache) Delete(lat, lon float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := pointsCacheKey(lat, lon)
+	if _, ok := c.entries[key]; !ok {
+		return
+	}
+
+	delete(c.entries, key)
+	if err := c.persist(); err != nil {
+		c.logf("points cache: failed to write %s: %v", c.Path, err)
+	}
+}
+
+// persist writes the entries to the cache file; the caller must hold the lock. Each write goes
+// to its own temporary file that is then renamed over the cache file, so readers and other
+// processes writing the same cache, such as the daemon and a command run alongside it, never
+// see a partial or interleaved file. The last write wins.
+func (c *PointsCache) persist() error {
+	if c.Path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(c.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(c.Path)+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	// CreateTemp makes the file private, the cache holds nothing secret
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.Path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// logf logs a failure to persist the cache, which is not fatal since the cache is only an
+// optimization
+func (c *PointsCache) logf(format string, args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// pointsCacheKey uses the same precision as the /points request
+func pointsCacheKey(lat, lon float64) string {
+	return fmt.Sprintf("%.4f,%.4f", lat, lon)
+}
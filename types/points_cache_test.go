@@ -0,0 +1,290 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPointsCache(t *testing.T) {
+	cache, err := NewPointsCache(time.Hour, "")
+	if err != nil {
+		t.Fatalf("NewPointsCache() error = %v", err)
+	}
+
+	if _, ok := cache.Get(39.7456, -97.0892); ok {
+		t.Fatal("Get() on an empty cache found an entry")
+	}
+
+	cache.Set(39.7456, -97.0892, &PointsProperties{GridID: "TOP", GridX: 32, GridY: 81})
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		wantOK   bool
+	}{
+		{name: "same coordinates", lat: 39.7456, lon: -97.0892, wantOK: true},
+		{name: "rounds to the same key", lat: 39.74561, lon: -97.08919, wantOK: true},
+		{name: "different coordinates", lat: 39.7457, lon: -97.0892, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, ok := cache.Get(tt.lat, tt.lon)
+			if ok != tt.wantOK {
+				t.Fatalf("Get() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && points.GridID != "TOP" {
+				t.Errorf("Get() GridID = %q, want TOP", points.GridID)
+			}
+		})
+	}
+
+	// Changing the returned copy must not change the cached entry
+	points, _ := cache.Get(39.7456, -97.0892)
+	points.GridID = "XXX"
+	if points, _ := cache.Get(39.7456, -97.0892); points.GridID != "TOP" {
+		t.Errorf("cached GridID = %q after changing a copy, want TOP", points.GridID)
+	}
+
+	cache.Delete(39.7456, -97.0892)
+	if _, ok := cache.Get(39.7456, -97.0892); ok {
+		t.Error("Get() found an entry after Delete()")
+	}
+}
+
+func TestPointsCacheTTL(t *testing.T) {
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		age    time.Duration
+		wantOK bool
+	}{
+		{name: "fresh", ttl: time.Hour, age: time.Minute, wantOK: true},
+		{name: "expired", ttl: time.Hour, age: 2 * time.Hour, wantOK: false},
+		{name: "zero ttl never expires", ttl: 0, age: 365 * 24 * time.Hour, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, _ := NewPointsCache(tt.ttl, "")
+			cache.entries[pointsCacheKey(40, -100)] = pointsCacheEntry{
+				Points:    PointsProperties{GridID: "GLD"},
+				FetchedAt: time.Now().Add(-tt.age),
+			}
+
+			if _, ok := cache.Get(40, -100); ok != tt.wantOK {
+				t.Errorf("Get() ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPointsCachePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "points.json")
+
+	cache, err := NewPointsCache(time.Hour, path)
+	if err != nil {
+		t.Fatalf("NewPointsCache() error = %v", err)
+	}
+	cache.Set(39.7456, -97.0892, &PointsProperties{GridID: "TOP", GridX: 32, GridY: 81})
+	cache.Set(40, -100, &PointsProperties{GridID: "GLD"})
+	cache.Delete(40, -100)
+
+	reloaded, err := NewPointsCache(time.Hour, path)
+	if err != nil {
+		t.Fatalf("NewPointsCache() reload error = %v", err)
+	}
+	points, ok := reloaded.Get(39.7456, -97.0892)
+	if !ok {
+		t.Fatal("reloaded cache is missing the entry that was set")
+	}
+	if points.GridID != "TOP" || points.GridX != 32 || points.GridY != 81 {
+		t.Errorf("reloaded entry = %+v, want TOP 32,81", points)
+	}
+	if _, ok := reloaded.Get(40, -100); ok {
+		t.Error("reloaded cache has the entry that was deleted")
+	}
+
+	// No temporary files are left next to the cache file
+	files, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("cache directory has %d files, want only the cache file", len(files))
+	}
+}
+
+func TestPointsCacheCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "points.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewPointsCache(time.Hour, path)
+	if err != nil {
+		t.Fatalf("NewPointsCache() error = %v, want a corrupt file to be ignored", err)
+	}
+
+	cache.Set(40, -100, &PointsProperties{GridID: "GLD"})
+	reloaded, _ := NewPointsCache(time.Hour, path)
+	if _, ok := reloaded.Get(40, -100); !ok {
+		t.Error("corrupt cache file was not rewritten")
+	}
+}
+
+func TestPointsCacheWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewPointsCache(time.Hour, filepath.Join(dir, "file", "points.json"))
+	if err != nil {
+		t.Fatalf("NewPointsCache() error = %v", err)
+	}
+
+	// A regular file where the cache directory should be makes every write fail
+	if err := os.WriteFile(filepath.Join(dir, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var logged bytes.Buffer
+	cache.Logger = log.New(&logged, "", 0)
+
+	cache.Set(40, -100, &PointsProperties{GridID: "GLD"})
+
+	if !strings.Contains(logged.String(), "points cache: failed to write") {
+		t.Errorf("write failure was not logged, got %q", logged.String())
+	}
+	if _, ok := cache.Get(40, -100); !ok {
+		t.Error("entry is missing from memory after a failed write")
+	}
+}
+
+// pointsServer serves /points for one location, pointing at forecastPath until the points are
+// looked up once, and at refreshedPath after that
+type pointsServer struct {
+	*httptest.Server
+	pointsRequests atomic.Int32
+}
+
+func newPointsServer(t *testing.T, forecastPath, refreshedPath string, handler http.Handler) *pointsServer {
+	t.Helper()
+
+	s := &pointsServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/points/", func(w http.ResponseWriter, r *http.Request) {
+		path := forecastPath
+		if s.pointsRequests.Add(1) > 1 {
+			path = refreshedPath
+		}
+		fmt.Fprintf(w, `{"properties": {"gridId": "TOP", "forecast": "%s%s"}}`, s.URL, path)
+	})
+	mux.Handle("/", handler)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestGetFromPointsStaleURL(t *testing.T) {
+	var oldRequests atomic.Int32
+	server := newPointsServer(t, "/gridpoints/OLD/1,1/forecast", "/gridpoints/TOP/32,81/forecast", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gridpoints/OLD/1,1/forecast":
+			oldRequests.Add(1)
+			http.NotFound(w, r)
+		case "/gridpoints/TOP/32,81/forecast":
+			w.Write([]byte(`{"properties": {"periods": [{"number": 1, "name": "Today"}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	client := NewWeatherClient()
+	client.BaseURL = server.URL
+	client.HTTPClient = server.Client()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The first call caches the stale forecast URL, gets a 404 and looks the points up again
+	forecast, err := client.GetForecastByCoordinatesContext(ctx, 39.7456, -97.0892)
+	if err != nil {
+		t.Fatalf("GetForecastByCoordinates() error = %v", err)
+	}
+	if len(forecast.Properties.Periods) != 1 {
+		t.Errorf("got %d periods, want 1", len(forecast.Properties.Periods))
+	}
+	if got := server.pointsRequests.Load(); got != 2 {
+		t.Errorf("points looked up %d times, want 2", got)
+	}
+
+	// The refreshed URL is cached, so the next call neither looks up the points nor hits the stale URL
+	if _, err := client.GetForecastByCoordinatesContext(ctx, 39.7456, -97.0892); err != nil {
+		t.Fatalf("second GetForecastByCoordinates() error = %v", err)
+	}
+	if got := server.pointsRequests.Load(); got != 2 {
+		t.Errorf("points looked up %d times after a cached call, want 2", got)
+	}
+	if got := oldRequests.Load(); got != 1 {
+		t.Errorf("stale URL requested %d times, want 1", got)
+	}
+}
+
+func TestGetFromPointsNotFoundWithoutCache(t *testing.T) {
+	server := newPointsServer(t, "/gridpoints/OLD/1,1/forecast", "/gridpoints/TOP/32,81/forecast", http.NotFoundHandler())
+
+	client := NewWeatherClient()
+	client.BaseURL = server.URL
+	client.HTTPClient = server.Client()
+	client.PointsCache = nil
+
+	if _, err := client.GetForecastByCoordinates(39.7456, -97.0892); err == nil {
+		t.Fatal("GetForecastByCoordinates() error = nil, want the 404")
+	}
+	if got := server.pointsRequests.Load(); got != 1 {
+		t.Errorf("points looked up %d times without a cache, want 1", got)
+	}
+}
+
+func TestGetFromPointsRedirect(t *testing.T) {
+	server := newPointsServer(t, "/gridpoints/OLD/1,1/forecast", "/gridpoints/TOP/32,81/forecast", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gridpoints/OLD/1,1/forecast":
+			http.Redirect(w, r, "/gridpoints/TOP/32,81/forecast", http.StatusMovedPermanently)
+		case "/gridpoints/TOP/32,81/forecast":
+			w.Write([]byte(`{"properties": {"periods": []}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	client := NewWeatherClient()
+	client.BaseURL = server.URL
+	client.HTTPClient = server.Client()
+
+	if _, err := client.GetForecastByCoordinates(39.7456, -97.0892); err != nil {
+		t.Fatalf("GetForecastByCoordinates() error = %v", err)
+	}
+	if _, ok := client.PointsCache.Get(39.7456, -97.0892); ok {
+		t.Error("points still cached after the forecast URL redirected")
+	}
+
+	// The next call looks the points up again and gets the new URL
+	if _, err := client.GetForecastByCoordinates(39.7456, -97.0892); err != nil {
+		t.Fatalf("second GetForecastByCoordinates() error = %v", err)
+	}
+	points, ok := client.PointsCache.Get(39.7456, -97.0892)
+	if !ok {
+		t.Fatal("points not cached after the second call")
+	}
+	if !strings.HasSuffix(points.Forecast, "/gridpoints/TOP/32,81/forecast") {
+		t.Errorf("cached forecast URL = %q, want the new URL", points.Forecast)
+	}
+}
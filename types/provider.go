@@ -0,0 +1,120 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Provider names used in config, on locations and in the provider column of saved forecasts
+const (
+	ProviderNWS       = "nws"
+	ProviderOpenMeteo = "open-meteo"
+)
+
+// ErrNotSupported is returned when a provider lacks the capability a request needs
+var ErrNotSupported = errors.New("not supported by provider")
+
+// Provider is a source of weather data. What a provider can do is given by the capability
+// interfaces it implements: ForecastProvider, HourlyForecastProvider, ObservationProvider and
+// AlertProvider. Forecasts from every provider are normalized into ForecastResponse.
+type Provider interface {
+	Name() string
+}
+
+// ForecastProvider gets daily forecasts split into day and night periods
+type ForecastProvider interface {
+	Provider
+	Forecast(ctx context.Context, lat, lon float64) (*ForecastResponse, error)
+}
+
+// HourlyForecastProvider gets hourly forecasts
+type HourlyForecastProvider interface {
+	Provider
+	HourlyForecast(ctx context.Context, lat, lon float64) (*ForecastResponse, error)
+}
+
+// ObservationProvider gets observations measured near the coordinates since the given time
+type ObservationProvider interface {
+	Provider
+	Observations(ctx context.Context, lat, lon float64, since time.Time) ([]ObservationResponse, error)
+}
+
+// AlertProvider gets the active alerts covering the coordinates
+type AlertProvider interface {
+	Provider
+	ActiveAlerts(ctx context.Context, lat, lon float64) ([]Alert, error)
+}
+
+// GetProviderForecast gets a daily or hourly forecast from the provider, returning an error
+// wrapping ErrNotSupported if it cannot provide that kind of forecast
+func GetProviderForecast(ctx context.Context, provider Provider, lat, lon float64, isHourly bool) (*ForecastResponse, error) {
+	if isHourly {
+		if p, ok := provider.(HourlyForecastProvider); ok {
+			return p.HourlyForecast(ctx, lat, lon)
+		}
+		return nil, fmt.Errorf("hourly forecasts %w %s", ErrNotSupported, provider.Name())
+	}
+
+	if p, ok := provider.(ForecastProvider); ok {
+		return p.Forecast(ctx, lat, lon)
+	}
+	return nil, fmt.Errorf("daily forecasts %w %s", ErrNotSupported, provider.Name())
+}
+
+// GetProviderObservations gets observations from the provider, returning an error wrapping
+// ErrNotSupported if it has none
+func GetProviderObservations(ctx context.Context, provider Provider, lat, lon float64, since time.Time) ([]ObservationResponse, error) {
+	if p, ok := provider.(ObservationProvider); ok {
+		return p.Observations(ctx, lat, lon, since)
+	}
+	return nil, fmt.Errorf("observations %w %s", ErrNotSupported, provider.Name())
+}
+
+// GetProviderAlerts gets active alerts from the provider, returning an error wrapping
+// ErrNotSupported if it has none
+func GetProviderAlerts(ctx context.Context, provider Provider, lat, lon float64) ([]Alert, error) {
+	if p, ok := provider.(AlertProvider); ok {
+		return p.ActiveAlerts(ctx, lat, lon)
+	}
+	return nil, fmt.Errorf("alerts %w %s", ErrNotSupported, provider.Name())
+}
+
+// Name returns the provider name of the NWS client
+func (w *WeatherClient) Name() string {
+	return ProviderNWS
+}
+
+// Forecast gets the daily NWS forecast, implementing ForecastProvider
+func (w *WeatherClient) Forecast(ctx context.Context, lat, lon float64) (*ForecastResponse, error) {
+	return w.GetForecastByCoordinatesContext(ctx, lat, lon)
+}
+
+// HourlyForecast gets the hourly NWS forecast, implementing HourlyForecastProvider
+func (w *WeatherClient) HourlyForecast(ctx context.Context, lat, lon float64) (*ForecastResponse, error) {
+	return w.GetHourlyForecastByCoordinatesContext(ctx, lat, lon)
+}
+
+// Observations gets the observations of the nearest station since the given time, implementing ObservationProvider
+func (w *WeatherClient) Observations(ctx context.Context, lat, lon float64, since time.Time) ([]ObservationResponse, error) {
+	stations, err := w.GetObservationStationsContext(ctx, lat, lon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find observation stations: %w", err)
+	}
+	if len(stations) == 0 {
+		return nil, fmt.Errorf("no observation stations found for coordinates %.4f, %.4f", lat, lon)
+	}
+
+	observations, err := w.GetStationObservationsContext(ctx, stations[0].Properties.StationIdentifier, since, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	return observations.Features, nil
+}
+
+// ActiveAlerts gets the active NWS alerts covering the coordinates, implementing AlertProvider
+func (w *WeatherClient) ActiveAlerts(ctx context.Context, lat, lon float64) ([]Alert, error) {
+	return w.GetActiveAlertsByPointContext(ctx, lat, lon)
+}
@@ -0,0 +1,244 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/dwburke/weather/db"
+)
+
+// DefaultPruneBatchSize is the default number of rows removed by one DELETE statement
+const DefaultPruneBatchSize = 1000
+
+// RetentionPolicy says how long each kind of saved data is kept. A zero duration keeps it forever.
+type RetentionPolicy struct {
+	HourlyForecasts time.Duration // Hourly forecast runs, by retrieval time
+	DailyForecasts  time.Duration // Daily forecast runs, by retrieval time
+	Observations    time.Duration // Observations, by observation time
+	GridForecasts   time.Duration // Gridpoint forecast hours, by retrieval time
+	Alerts          time.Duration // Alerts, by the last time they were active
+
+	// Hourly runs older than this are reduced to the first run of each day, so the evolution of
+	// older forecasts is kept at a coarser resolution
+	DownsampleHourlyAfter time.Duration
+}
+
+// IsEmpty reports whether the policy keeps everything
+func (p RetentionPolicy) IsEmpty() bool {
+	return p == RetentionPolicy{}
+}
+
+// PruneOptions controls how Prune deletes rows
+type PruneOptions struct {
+	DryRun    bool      // Count the rows that would be deleted without deleting them
+	BatchSize int       // Rows removed by one DELETE statement, DefaultPruneBatchSize if 0
+	Now       time.Time // Time retention periods are counted back from, time.Now() if zero
+}
+
+// PruneStats counts the rows of a table removed by one step of Prune, or that would be removed
+type PruneStats struct {
+	Step  string
+	Table string
+	Rows  int64
+}
+
+// Prune deletes saved data older than the policy allows
+func Prune(policy RetentionPolicy, opts PruneOptions) ([]PruneStats, error) {
+	return PruneContext(context.Background(), policy, opts)
+}
+
+// PruneContext is like Prune but stops between batches once ctx is done. Rows are deleted in
+// batches of opts.BatchSize, each its own statement, so no table is locked for long; the periods
+// of a forecast run are deleted before the run itself.
+func PruneContext(ctx context.Context, policy RetentionPolicy, opts PruneOptions) ([]PruneStats, error) {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultPruneBatchSize
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	p := &pruner{ctx: ctx, gdbh: gdbh, opts: opts}
+
+	for _, forecastType := range []struct {
+		name      string
+		isHourly  bool
+		retention time.Duration
+	}{
+		{"hourly", true, policy.HourlyForecasts},
+		{"daily", false, policy.DailyForecasts},
+	} {
+		if forecastType.retention <= 0 {
+			continue
+		}
+		cutoff := opts.Now.Add(-forecastType.retention)
+		step := fmt.Sprintf("%s forecasts older than %s", forecastType.name, formatRetention(forecastType.retention))
+
+		if err := p.deleteWhere(step, &WeatherForecast{}, "is_hourly = ? AND forecast_date < ?", forecastType.isHourly, cutoff); err != nil {
+			return p.stats, err
+		}
+		if err := p.deleteWhere(step, &ForecastRun{}, "is_hourly = ? AND retrieved_at < ?", forecastType.isHourly, cutoff); err != nil {
+			return p.stats, err
+		}
+	}
+
+	if policy.DownsampleHourlyAfter > 0 {
+		step := fmt.Sprintf("hourly forecasts older than %s, downsampled to one run a day", formatRetention(policy.DownsampleHourlyAfter))
+		// Runs past the hourly retention are left out, so a dry run doesn't count them twice
+		var deletedBefore time.Time
+		if policy.HourlyForecasts > 0 {
+			deletedBefore = opts.Now.Add(-policy.HourlyForecasts)
+		}
+		if err := p.downsampleHourlyRuns(step, deletedBefore, opts.Now.Add(-policy.DownsampleHourlyAfter)); err != nil {
+			return p.stats, err
+		}
+	}
+
+	for _, table := range []struct {
+		model     interface{}
+		name      string
+		where     string
+		retention time.Duration
+	}{
+		{&Observation{}, "observations", "timestamp < ?", policy.Observations},
+		{&GridForecast{}, "gridpoint forecasts", "forecast_date < ?", policy.GridForecasts},
+		{&WeatherAlert{}, "alerts", "last_seen_at < ?", policy.Alerts},
+	} {
+		if table.retention <= 0 {
+			continue
+		}
+		step := fmt.Sprintf("%s older than %s", table.name, formatRetention(table.retention))
+		if err := p.deleteWhere(step, table.model, table.where, opts.Now.Add(-table.retention)); err != nil {
+			return p.stats, err
+		}
+	}
+
+	return p.stats, nil
+}
+
+// pruner runs the steps of a prune and collects their stats
+type pruner struct {
+	ctx   context.Context
+	gdbh  *gorm.DB
+	opts  PruneOptions
+	stats []PruneStats
+}
+
+// record adds rows to the stats of a step and table
+func (p *pruner) record(step string, model interface{}, rows int64) {
+	table := p.gdbh.NewScope(model).TableName()
+	for i := range p.stats {
+		if p.stats[i].Step == step && p.stats[i].Table == table {
+			p.stats[i].Rows += rows
+			return
+		}
+	}
+	p.stats = append(p.stats, PruneStats{Step: step, Table: table, Rows: rows})
+}
+
+// deleteWhere deletes the rows of model matching a condition, a batch at a time, or counts them
+// in a dry run
+func (p *pruner) deleteWhere(step string, model interface{}, where string, args ...interface{}) error {
+	if p.opts.DryRun {
+		var count int64
+		if err := p.gdbh.Model(model).Where(where, args...).Count(&count).Error; err != nil {
+			return err
+		}
+		p.record(step, model, count)
+		return nil
+	}
+
+	for {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+
+		var ids []uint
+		if err := p.gdbh.Model(model).Where(where, args...).Order("id ASC").Limit(p.opts.BatchSize).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			p.record(step, model, 0)
+			return nil
+		}
+
+		result := p.gdbh.Where("id IN (?)", ids).Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		p.record(step, model, result.RowsAffected)
+
+		if len(ids) < p.opts.BatchSize {
+			return nil
+		}
+	}
+}
+
+// downsampleHourlyRuns deletes every hourly run retrieved between from and to except the first one
+// of each local day for each location and provider, along with its periods
+func (p *pruner) downsampleHourlyRuns(step string, from, to time.Time) error {
+	var runs []ForecastRun
+	if err := p.gdbh.Select("id, location_id, latitude, longitude, provider, retrieved_at").
+		Where("is_hourly = ? AND retrieved_at >= ? AND retrieved_at < ?", true, from, to).
+		Order("retrieved_at ASC, id ASC").
+		Find(&runs).Error; err != nil {
+		return err
+	}
+
+	type dayKey struct {
+		locationID uint
+		lat, lon   float64
+		provider   string
+		day        string
+	}
+
+	kept := make(map[dayKey]bool)
+	var ids []uint
+	for _, run := range runs {
+		key := dayKey{run.LocationID, run.Latitude, run.Longitude, run.Provider, run.RetrievedAt.Local().Format("2006-01-02")}
+		if kept[key] {
+			ids = append(ids, run.ID)
+			continue
+		}
+		kept[key] = true
+	}
+
+	// An hourly run has up to 156 periods, so fewer runs are handled at a time than rows are
+	// deleted by one statement
+	runsPerBatch := max(1, p.opts.BatchSize/100)
+	for start := 0; start < len(ids); start += runsPerBatch {
+		batch := ids[start:min(start+runsPerBatch, len(ids))]
+		if err := p.deleteWhere(step, &WeatherForecast{}, "run_id IN (?)", batch); err != nil {
+			return err
+		}
+		if err := p.deleteWhere(step, &ForecastRun{}, "id IN (?)", batch); err != nil {
+			return err
+		}
+	}
+
+	if len(ids) == 0 {
+		p.record(step, &WeatherForecast{}, 0)
+		p.record(step, &ForecastRun{}, 0)
+	}
+
+	return nil
+}
+
+// formatRetention formats a retention period in days, or hours if it is shorter than a day
+func formatRetention(d time.Duration) string {
+	if d < 24*time.Hour || d%(24*time.Hour) != 0 {
+		return d.String()
+	}
+	if d == 24*time.Hour {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+}
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/dwburke/weather/db"
+)
+
+// storeRun saves a run retrieved at retrievedAt for the prune test coordinates, with periods
+// periods
+func storeRun(t *testing.T, gdbh *gorm.DB, retrievedAt time.Time, isHourly bool, periods int) *ForecastRun {
+	t.Helper()
+
+	run := &ForecastRun{Latitude: 44.0805, Longitude: -103.2310, Provider: "nws", RetrievedAt: retrievedAt, IsHourly: isHourly, PeriodCount: periods}
+	if err := gdbh.Create(run).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < periods; i++ {
+		period := &WeatherForecast{
+			Latitude: run.Latitude, Longitude: run.Longitude, PeriodNumber: i + 1, Name: "Period",
+			StartTime: retrievedAt.Add(time.Duration(i) * time.Hour), EndTime: retrievedAt.Add(time.Duration(i+1) * time.Hour),
+			RunID: run.ID, ForecastDate: retrievedAt, IsHourly: isHourly, Provider: "nws",
+		}
+		if err := gdbh.Create(period).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return run
+}
+
+func TestPrune(t *testing.T) {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Everything is stored in 2000 so the rows saved by other tests are never old enough to prune
+	now := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d, hour, minute int) time.Time {
+		return time.Date(2000, month, d, hour, minute, 0, 0, time.UTC)
+	}
+
+	storeRun(t, gdbh, day(11, 20, 12, 0), true, 3) // Past the hourly retention
+	first := storeRun(t, gdbh, day(12, 10, 12, 0), true, 2)
+	storeRun(t, gdbh, day(12, 10, 12, 30), true, 2) // Downsampled away
+	storeRun(t, gdbh, day(12, 10, 13, 0), true, 2)  // Downsampled away
+	recent := storeRun(t, gdbh, day(12, 28, 12, 0), true, 2)
+	storeRun(t, gdbh, day(12, 15, 12, 0), false, 2) // Past the daily retention
+	daily := storeRun(t, gdbh, day(12, 23, 12, 0), false, 2)
+
+	if _, err := SaveObservationsToDB(stationObservations("KRAP", day(12, 10, 0, 0), 5), 44.0805, -103.2310); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveObservationsToDB(stationObservations("KRAP", day(12, 20, 0, 0), 2), 44.0805, -103.2310); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := RetentionPolicy{
+		HourlyForecasts:       30 * 24 * time.Hour,
+		DailyForecasts:        10 * 24 * time.Hour,
+		Observations:          14 * 24 * time.Hour,
+		DownsampleHourlyAfter: 7 * 24 * time.Hour,
+	}
+
+	want := []PruneStats{
+		{Step: "hourly forecasts older than 30 days", Table: "weather_forecasts", Rows: 3},
+		{Step: "hourly forecasts older than 30 days", Table: "forecast_runs", Rows: 1},
+		{Step: "daily forecasts older than 10 days", Table: "weather_forecasts", Rows: 2},
+		{Step: "daily forecasts older than 10 days", Table: "forecast_runs", Rows: 1},
+		{Step: "hourly forecasts older than 7 days, downsampled to one run a day", Table: "weather_forecasts", Rows: 4},
+		{Step: "hourly forecasts older than 7 days, downsampled to one run a day", Table: "forecast_runs", Rows: 2},
+		{Step: "observations older than 14 days", Table: "weather_observations", Rows: 5},
+	}
+
+	countRows := func() (runs, periods, observations int) {
+		gdbh.Model(&ForecastRun{}).Where("latitude = ? AND longitude = ?", 44.0805, -103.2310).Count(&runs)
+		gdbh.Model(&WeatherForecast{}).Where("latitude = ? AND longitude = ?", 44.0805, -103.2310).Count(&periods)
+		gdbh.Model(&Observation{}).Where("station_id = ?", "KRAP").Count(&observations)
+		return runs, periods, observations
+	}
+
+	checkStats := func(t *testing.T, stats []PruneStats) {
+		t.Helper()
+		if len(stats) != len(want) {
+			t.Fatalf("got %d stats, want %d: %+v", len(stats), len(want), stats)
+		}
+		for i := range want {
+			if stats[i] != want[i] {
+				t.Errorf("stats[%d] = %+v, want %+v", i, stats[i], want[i])
+			}
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		stats, err := Prune(policy, PruneOptions{DryRun: true, Now: now})
+		if err != nil {
+			t.Fatalf("Prune() error = %v", err)
+		}
+		checkStats(t, stats)
+
+		if runs, periods, observations := countRows(); runs != 7 || periods != 15 || observations != 7 {
+			t.Errorf("dry run left %d runs, %d periods and %d observations, want 7, 15 and 7", runs, periods, observations)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := PruneContext(ctx, policy, PruneOptions{Now: now}); !errors.Is(err, context.Canceled) {
+			t.Fatalf("PruneContext() error = %v, want context.Canceled", err)
+		}
+		if runs, _, _ := countRows(); runs != 7 {
+			t.Errorf("cancelled prune left %d runs, want 7", runs)
+		}
+	})
+
+	t.Run("batched", func(t *testing.T) {
+		// Batches of two split the observations and the downsampled runs over several statements
+		stats, err := Prune(policy, PruneOptions{BatchSize: 2, Now: now})
+		if err != nil {
+			t.Fatalf("Prune() error = %v", err)
+		}
+		checkStats(t, stats)
+
+		if runs, periods, observations := countRows(); runs != 3 || periods != 6 || observations != 2 {
+			t.Errorf("prune left %d runs, %d periods and %d observations, want 3, 6 and 2", runs, periods, observations)
+		}
+
+		var kept []uint
+		gdbh.Model(&ForecastRun{}).Where("latitude = ? AND longitude = ?", 44.0805, -103.2310).Order("id").Pluck("id", &kept)
+		wantKept := []uint{first.ID, recent.ID, daily.ID}
+		for i := range wantKept {
+			if i >= len(kept) || kept[i] != wantKept[i] {
+				t.Errorf("kept runs %v, want %v", kept, wantKept)
+				break
+			}
+		}
+	})
+
+	t.Run("nothing left", func(t *testing.T) {
+		stats, err := Prune(policy, PruneOptions{Now: now})
+		if err != nil {
+			t.Fatalf("Prune() error = %v", err)
+		}
+		for _, s := range stats {
+			if s.Rows != 0 {
+				t.Errorf("second prune removed %d rows from %s for %q", s.Rows, s.Table, s.Step)
+			}
+		}
+	})
+}
+
+func TestFormatRetention(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{90 * 24 * time.Hour, "90 days"},
+		{24 * time.Hour, "1 day"},
+		{36 * time.Hour, "36h0m0s"},
+		{6 * time.Hour, "6h0m0s"},
+	}
+
+	for _, tt := range tests {
+		if got := formatRetention(tt.d); got != tt.want {
+			t.Errorf("formatRetention(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
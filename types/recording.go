@@ -0,0 +1,187 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNotRecorded is returned by ReplayTransport for requests that have no recorded response
+var ErrNotRecorded = errors.New("no recorded response")
+
+// recordedResponse is the fixture file written for each recorded request
+type recordedResponse struct {
+	Method     string          `json:"method"`
+	URL        string          `json:"url"`
+	StatusCode int             `json:"status_code"`
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"` // JSON bodies, kept readable
+	Text       string          `json:"text,omitempty"` // Any other body
+}
+
+// RecordingTransport is an http.RoundTripper that passes requests on to Next and saves every
+// response into a fixture file under Dir, which ReplayTransport can later serve without network
+// access. A later response to the same request replaces the earlier one.
+type RecordingTransport struct {
+	Dir  string
+	Next http.RoundTripper // Transport that makes the real requests, http.DefaultTransport if nil
+}
+
+// RoundTrip makes the request and records its response
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorded := recordedResponse{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+	}
+	// The body may be reformatted, so its recorded length would not match
+	recorded.Header.Del("Content-Length")
+	if json.Valid(body) {
+		recorded.Body = body
+	} else {
+		recorded.Text = string(body)
+	}
+
+	if err := writeFixture(fixturePath(t.Dir, req), &recorded); err != nil {
+		return nil, fmt.Errorf("failed to record response: %w", err)
+	}
+
+	return resp, nil
+}
+
+// ReplayTransport is an http.RoundTripper that serves the responses recorded under Dir by
+// RecordingTransport instead of making requests. Requests that were not recorded fail with an
+// error wrapping ErrNotRecorded.
+type ReplayTransport struct {
+	Dir string
+}
+
+// RoundTrip returns the recorded response to the request
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := fixturePath(t.Dir, req)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s (expected %s)", ErrNotRecorded, req.Method, req.URL, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var recorded recordedResponse
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("invalid recorded response %s: %w", path, err)
+	}
+
+	body := []byte(recorded.Text)
+	if len(recorded.Body) > 0 {
+		body = recorded.Body
+	}
+
+	header := recorded.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+var unsafeFixtureChars = regexp.MustCompile(`[^A-Za-z0-9._,-]+`)
+
+// fixturePath returns the fixture file of a request: <dir>/<host>/<path>.json, with a hash of
+// the method and query appended when the request is not a plain GET, e.g.
+// fixtures/api.weather.gov/gridpoints/BOU/62,60/forecast_3f9a1c2b.json for ?units=si
+func fixturePath(dir string, req *http.Request) string {
+	parts := []string{dir, unsafeFixtureChars.ReplaceAllString(req.URL.Host, "_")}
+	for _, segment := range strings.Split(strings.Trim(req.URL.Path, "/"), "/") {
+		if segment = unsafeFixtureChars.ReplaceAllString(segment, "_"); segment != "" && segment != "." && segment != ".." {
+			parts = append(parts, segment)
+		}
+	}
+	if len(parts) == 2 {
+		parts = append(parts, "index")
+	}
+
+	name := parts[len(parts)-1]
+	if req.URL.RawQuery != "" || req.Method != http.MethodGet {
+		sum := sha256.Sum256([]byte(req.Method + " " + req.URL.RawQuery))
+		name += "_" + hex.EncodeToString(sum[:4])
+	}
+	parts[len(parts)-1] = name + ".json"
+
+	return filepath.Join(parts...)
+}
+
+// writeFixture writes a recorded response, replacing the file atomically
+func writeFixture(path string, recorded *recordedResponse) error {
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".fixture-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// NewReplayClient returns a weather client that serves the responses recorded under dir instead
+// of using the network, e.g. to test formatting and saving against real NWS payloads
+func NewReplayClient(dir string) *WeatherClient {
+	client := NewWeatherClient()
+	client.HTTPClient.Transport = &ReplayTransport{Dir: dir}
+	client.RetryPolicy = RetryPolicy{MaxAttempts: 1}
+	return client
+}
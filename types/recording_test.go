@@ -0,0 +1,137 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixturePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		want   string
+	}{
+		{
+			name: "plain get",
+			url:  "https://api.weather.gov/points/39.7456,-97.0892",
+			want: "fixtures/api.weather.gov/points/39.7456,-97.0892.json",
+		},
+		{
+			name: "query",
+			url:  "https://api.weather.gov/gridpoints/TOP/32,81/forecast?units=us",
+			want: "fixtures/api.weather.gov/gridpoints/TOP/32,81/forecast_e20b1d70.json",
+		},
+		{
+			name: "port in host",
+			url:  "http://127.0.0.1:8089/alerts/active",
+			want: "fixtures/127.0.0.1_8089/alerts/active.json",
+		},
+		{
+			name: "root path",
+			url:  "https://api.weather.gov/",
+			want: "fixtures/api.weather.gov/index.json",
+		},
+		{
+			name: "unsafe characters",
+			url:  "https://api.weather.gov/stations/K DEN/observations%3Flatest",
+			want: "fixtures/api.weather.gov/stations/K_DEN/observations_latest.json",
+		},
+		{
+			name: "dot segments stay inside the directory",
+			url:  "https://api.weather.gov/a/%2E%2E/%2E%2E/b",
+			want: "fixtures/api.weather.gov/a/b.json",
+		},
+		{
+			name:   "other method",
+			method: http.MethodHead,
+			url:    "https://api.weather.gov/points/39.7456,-97.0892",
+			want:   "fixtures/api.weather.gov/points/39.7456,-97.0892_",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := fixturePath("fixtures", req)
+			if tt.method != "" {
+				// Only the prefix is predictable, the rest is a hash of the method
+				if len(got) != len(tt.want)+len("01234567.json") || got[:len(tt.want)] != filepath.FromSlash(tt.want) {
+					t.Errorf("fixturePath() = %q, want %q followed by a hash", got, tt.want)
+				}
+				return
+			}
+			if got != filepath.FromSlash(tt.want) {
+				t.Errorf("fixturePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/geo+json")
+			w.Write([]byte(`{"properties":{"gridId":"TOP"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder := &http.Client{Transport: &RecordingTransport{Dir: dir}}
+	replayer := &http.Client{Transport: &ReplayTransport{Dir: dir}}
+
+	for _, path := range []string{"/json", "/missing?units=si"} {
+		recorded, err := recorder.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("recording %s: %v", path, err)
+		}
+		recordedBody, _ := io.ReadAll(recorded.Body)
+		recorded.Body.Close()
+
+		replayed, err := replayer.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("replaying %s: %v", path, err)
+		}
+		replayedBody, _ := io.ReadAll(replayed.Body)
+		replayed.Body.Close()
+
+		if replayed.StatusCode != recorded.StatusCode {
+			t.Errorf("%s: replayed status %d, recorded %d", path, replayed.StatusCode, recorded.StatusCode)
+		}
+		// JSON bodies are stored indented, so only their content has to match
+		if json.Valid(recordedBody) {
+			var compact bytes.Buffer
+			json.Compact(&compact, replayedBody)
+			replayedBody = compact.Bytes()
+		}
+		if string(replayedBody) != string(recordedBody) {
+			t.Errorf("%s: replayed body %q, recorded %q", path, replayedBody, recordedBody)
+		}
+		if got, want := replayed.Header.Get("Content-Type"), recorded.Header.Get("Content-Type"); got != want {
+			t.Errorf("%s: replayed Content-Type %q, recorded %q", path, got, want)
+		}
+	}
+
+	if _, err := replayer.Get(server.URL + "/never"); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("replaying unrecorded request: error = %v, want ErrNotRecorded", err)
+	}
+}
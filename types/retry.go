@@ -0,0 +1,178 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RetryPolicy controls how failed NWS API requests are retried. Network errors and responses
+// with a retryable status are retried with exponential backoff and full jitter; a Retry-After
+// header on the response takes precedence over the computed backoff, unless it asks for a wait
+// longer than MaxBackoff, in which case the response is returned without retrying.
+type RetryPolicy struct {
+	MaxAttempts     int           // Total attempts including the first, values below 1 mean 1
+	InitialBackoff  time.Duration // Upper bound of the first backoff, doubled on every retry
+	MaxBackoff      time.Duration // Cap on the computed backoff and on Retry-After waits, zero for no cap
+	RetryableStatus []int         // HTTP status codes that are retried
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewWeatherClient
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		RetryableStatus: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// NewRateLimiter returns a token bucket limiter allowing requestsPerSecond with the given burst.
+// The same limiter can be shared by several clients to bound their combined request rate.
+// A requestsPerSecond of zero or less returns nil, which disables rate limiting.
+func NewRateLimiter(requestsPerSecond float64, burst int) *rate.Limiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+}
+
+func (p *RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) retryable(status int) bool {
+	for _, s := range p.RetryableStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns a random delay up to backoffLimit(retry)
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	limit := p.backoffLimit(retry)
+	if limit <= 0 {
+		return 0
+	}
+	if limit == math.MaxInt64 {
+		return time.Duration(rand.Int63())
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+// backoffLimit returns InitialBackoff * 2^retry, capped at MaxBackoff unless MaxBackoff is zero
+func (p *RetryPolicy) backoffLimit(retry int) time.Duration {
+	limit := p.InitialBackoff
+	for i := 0; i < retry && (p.MaxBackoff <= 0 || limit < p.MaxBackoff); i++ {
+		// Stop doubling before the limit overflows when there is no cap
+		if limit > math.MaxInt64/2 {
+			return math.MaxInt64
+		}
+		limit *= 2
+	}
+	if p.MaxBackoff > 0 && limit > p.MaxBackoff {
+		limit = p.MaxBackoff
+	}
+	return limit
+}
+
+// getWithRetry performs a GET request, waiting for the rate limiter before every attempt and
+// retrying network errors and retryable statuses according to the retry policy. The response of
+// the last attempt is returned whatever its status. Cancelling ctx aborts the request in flight
+// as well as any rate limit or backoff wait.
+func getWithRetry(ctx context.Context, client *http.Client, policy *RetryPolicy, limiter *rate.Limiter, url, accept string) (*http.Response, error) {
+	attempts := policy.attempts()
+
+	for attempt := 1; ; attempt++ {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		// NWS API requires a User-Agent header, other APIs get the same one
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Accept", accept)
+
+		resp, err := client.Do(req)
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if attempt >= attempts || ctx.Err() != nil {
+				return nil, err
+			}
+			wait = policy.backoff(attempt - 1)
+		case policy.retryable(resp.StatusCode) && attempt < attempts:
+			wait = policy.backoff(attempt - 1)
+			if after, ok := retryAfter(resp); ok {
+				// Give up rather than hang for however long the server asks
+				if policy.MaxBackoff > 0 && after > policy.MaxBackoff {
+					return resp, nil
+				}
+				wait = after
+			}
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAfter parses a Retry-After header given either as seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		slack  time.Duration // Allowed difference, for HTTP dates relative to now
+		ok     bool
+	}{
+		{name: "missing", header: "", ok: false},
+		{name: "seconds", header: "3", want: 3 * time.Second, ok: true},
+		{name: "zero", header: "0", want: 0, ok: true},
+		{name: "negative", header: "-5", ok: false},
+		{name: "fraction", header: "1.5", ok: false},
+		{name: "garbage", header: "soon", ok: false},
+		{name: "future date", header: now.Add(10 * time.Second).UTC().Format(http.TimeFormat), want: 10 * time.Second, slack: 2 * time.Second, ok: true},
+		{name: "past date", header: now.Add(-time.Minute).UTC().Format(http.TimeFormat), want: 0, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: make(http.Header)}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+
+			got, ok := retryAfter(resp)
+			if ok != tt.ok {
+				t.Fatalf("retryAfter(%q) ok = %v, want %v", tt.header, ok, tt.ok)
+			}
+			if diff := got - tt.want; diff < -tt.slack || diff > tt.slack {
+				t.Errorf("retryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		initial    time.Duration
+		maxBackoff time.Duration
+		retry      int
+		want       time.Duration
+	}{
+		{name: "first retry", initial: time.Second, maxBackoff: 30 * time.Second, retry: 0, want: time.Second},
+		{name: "doubles", initial: time.Second, maxBackoff: 30 * time.Second, retry: 3, want: 8 * time.Second},
+		{name: "capped", initial: time.Second, maxBackoff: 30 * time.Second, retry: 5, want: 30 * time.Second},
+		{name: "cap below initial", initial: time.Second, maxBackoff: 100 * time.Millisecond, retry: 2, want: 100 * time.Millisecond},
+		{name: "zero max means no cap", initial: time.Second, maxBackoff: 0, retry: 6, want: 64 * time.Second},
+		{name: "no cap does not overflow", initial: time.Second, maxBackoff: 0, retry: 100, want: math.MaxInt64},
+		{name: "zero initial", initial: 0, maxBackoff: 30 * time.Second, retry: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := RetryPolicy{InitialBackoff: tt.initial, MaxBackoff: tt.maxBackoff}
+			if got := policy.backoffLimit(tt.retry); got != tt.want {
+				t.Errorf("backoffLimit(%d) = %v, want %v", tt.retry, got, tt.want)
+			}
+			if got := policy.backoff(tt.retry); got < 0 || got > tt.want {
+				t.Errorf("backoff(%d) = %v, want between 0 and %v", tt.retry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWithRetryRetryAfter(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryAfter   string
+		maxBackoff   time.Duration
+		wantStatus   int
+		wantRequests int32
+	}{
+		{name: "within max backoff", retryAfter: "0", maxBackoff: time.Second, wantStatus: http.StatusOK, wantRequests: 2},
+		{name: "above max backoff", retryAfter: "120", maxBackoff: time.Second, wantStatus: http.StatusTooManyRequests, wantRequests: 1},
+		{name: "unparseable uses backoff", retryAfter: "later", maxBackoff: time.Millisecond, wantStatus: http.StatusOK, wantRequests: 2},
+		{name: "no max backoff", retryAfter: "1", maxBackoff: 0, wantStatus: http.StatusOK, wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			policy := DefaultRetryPolicy()
+			policy.InitialBackoff = time.Millisecond
+			policy.MaxBackoff = tt.maxBackoff
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			resp, err := getWithRetry(ctx, server.Client(), &policy, nil, server.URL, "application/json")
+			if err != nil {
+				t.Fatalf("getWithRetry() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if requests.Load() != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", requests.Load(), tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// QuantitativeValue is a measured or forecast value with its WMO unit code, e.g. "wmoUnit:degC"
+type QuantitativeValue struct {
+	Value    *float64 `json:"value"`
+	UnitCode string   `json:"unitCode"`
+}
+
+// StationCollection is the response of the observation stations endpoints
+type StationCollection struct {
+	Features []Station `json:"features"`
+}
+
+type Station struct {
+	Properties StationProperties `json:"properties"`
+}
+
+type StationProperties struct {
+	StationIdentifier string `json:"stationIdentifier"`
+	Name              string `json:"name"`
+	TimeZone          string `json:"timeZone"`
+}
+
+// ObservationCollection is the response of /stations/{id}/observations
+type ObservationCollection struct {
+	Features []ObservationResponse `json:"features"`
+}
+
+// ObservationResponse is a single station observation
+type ObservationResponse struct {
+	Properties ObservationProperties `json:"properties"`
+}
+
+type ObservationProperties struct {
+	Station               string            `json:"station"`
+	Timestamp             string            `json:"timestamp"`
+	TextDescription       string            `json:"textDescription"`
+	Temperature           QuantitativeValue `json:"temperature"`
+	Dewpoint              QuantitativeValue `json:"dewpoint"`
+	WindDirection         QuantitativeValue `json:"windDirection"`
+	WindSpeed             QuantitativeValue `json:"windSpeed"`
+	WindGust              QuantitativeValue `json:"windGust"`
+	BarometricPressure    QuantitativeValue `json:"barometricPressure"`
+	SeaLevelPressure      QuantitativeValue `json:"seaLevelPressure"`
+	Visibility            QuantitativeValue `json:"visibility"`
+	PrecipitationLastHour QuantitativeValue `json:"precipitationLastHour"`
+	RelativeHumidity      QuantitativeValue `json:"relativeHumidity"`
+}
+
+// StationID returns the station identifier from the station URL, e.g. "KDEN"
+func (o *ObservationProperties) StationID() string {
+	return path.Base(o.Station)
+}
+
+// GetObservationStations gets the observation stations for the grid containing the given coordinates,
+// nearest station first
+func (w *WeatherClient) GetObservationStations(lat, lon float64) ([]Station, error) {
+	return w.GetObservationStationsContext(context.Background(), lat, lon)
+}
+
+// GetObservationStationsContext is like GetObservationStations but uses ctx for the API requests
+func (w *WeatherClient) GetObservationStationsContext(ctx context.Context, lat, lon float64) ([]Station, error) {
+	points, err := w.GetPointsContext(ctx, lat, lon)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.getObservationStations(ctx, points)
+}
+
+// getObservationStations gets the observation stations for an already resolved grid point
+func (w *WeatherClient) getObservationStations(ctx context.Context, points *PointsProperties) ([]Station, error) {
+	stationsURL := fmt.Sprintf("%s/gridpoints/%s/%d,%d/stations", w.BaseURL, points.GridID, points.GridX, points.GridY)
+
+	var stations StationCollection
+	if err := w.getJSON(ctx, stationsURL, &stations); err != nil {
+		return nil, fmt.Errorf("failed to get observation stations: %w", err)
+	}
+
+	return stations.Features, nil
+}
+
+// GetStationObservations gets the observations recorded by a station between start and end.
+// Zero start or end times leave that side of the range open.
+func (w *WeatherClient) GetStationObservations(stationID string, start, end time.Time) (*ObservationCollection, error) {
+	return w.GetStationObservationsContext(context.Background(), stationID, start, end)
+}
+
+// GetStationObservationsContext is like GetStationObservations but uses ctx for the API requests
+func (w *WeatherClient) GetStationObservationsContext(ctx context.Context, stationID string, start, end time.Time) (*ObservationCollection, error) {
+	query := url.Values{}
+	if !start.IsZero() {
+		query.Set("start", start.UTC().Format(time.RFC3339))
+	}
+	if !end.IsZero() {
+		query.Set("end", end.UTC().Format(time.RFC3339))
+	}
+
+	observationsURL := fmt.Sprintf("%s/stations/%s/observations", w.BaseURL, url.PathEscape(stationID))
+	if len(query) > 0 {
+		observationsURL += "?" + query.Encode()
+	}
+
+	var observations ObservationCollection
+	if err := w.getJSON(ctx, observationsURL, &observations); err != nil {
+		return nil, fmt.Errorf("failed to get station observations: %w", err)
+	}
+
+	return &observations, nil
+}
+
+// GetLatestObservation gets the most recent observation recorded by a station
+func (w *WeatherClient) GetLatestObservation(stationID string) (*ObservationResponse, error) {
+	return w.GetLatestObservationContext(context.Background(), stationID)
+}
+
+// GetLatestObservationContext is like GetLatestObservation but uses ctx for the API requests
+func (w *WeatherClient) GetLatestObservationContext(ctx context.Context, stationID string) (*ObservationResponse, error) {
+	observationURL := fmt.Sprintf("%s/stations/%s/observations/latest", w.BaseURL, url.PathEscape(stationID))
+
+	var observation ObservationResponse
+	if err := w.getJSON(ctx, observationURL, &observation); err != nil {
+		return nil, fmt.Errorf("failed to get latest observation: %w", err)
+	}
+
+	return &observation, nil
+}
+
+// FormatObservation returns a formatted string representation of the observation in the given units
+func (o *ObservationResponse) FormatObservation(units UnitSystem) string {
+	p := o.Properties
+
+	result := fmt.Sprintf("📍 %s at %s\n", p.StationID(), p.Timestamp)
+	if p.TextDescription != "" {
+		result += fmt.Sprintf("☁️  Conditions: %s\n", p.TextDescription)
+	}
+	result += fmt.Sprintf("🌡️  Temperature: %s (dewpoint %s)\n", units.FormatQuantity(p.Temperature), units.FormatQuantity(p.Dewpoint))
+	result += fmt.Sprintf("💨 Wind: %s from %s", units.FormatQuantity(p.WindSpeed), formatQuantity(p.WindDirection))
+	if p.WindGust.Value != nil {
+		result += fmt.Sprintf(", gusts %s", units.FormatQuantity(p.WindGust))
+	}
+	result += "\n"
+	result += fmt.Sprintf("💧 Humidity: %s, precipitation last hour: %s\n", formatQuantity(p.RelativeHumidity), units.FormatQuantity(p.PrecipitationLastHour))
+	result += fmt.Sprintf("🧭 Pressure: %s, visibility: %s\n", units.FormatQuantity(p.BarometricPressure), units.FormatQuantity(p.Visibility))
+
+	return result
+}
+
+// formatQuantity formats a quantitative value with a short unit label, or "n/a" when missing
+func formatQuantity(q QuantitativeValue) string {
+	if q.Value == nil {
+		return "n/a"
+	}
+
+	units := map[string]string{
+		"wmoUnit:degC":           "°C",
+		"wmoUnit:degF":           "°F",
+		"wmoUnit:km_h-1":         " km/h",
+		"wmoUnit:m_s-1":          " m/s",
+		"wmoUnit:degree_(angle)": "°",
+		"wmoUnit:Pa":             " Pa",
+		"wmoUnit:m":              " m",
+		"wmoUnit:mm":             " mm",
+		"wmoUnit:percent":        "%",
+	}
+
+	unit, ok := units[q.UnitCode]
+	if !ok {
+		unit = " " + strings.TrimPrefix(q.UnitCode, "wmoUnit:")
+	}
+
+	return fmt.Sprintf("%.1f%s", *q.Value, unit)
+}
@@ -0,0 +1,232 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// UnitSystem selects the units forecasts are requested from NWS in and every formatter shows
+// values in. Values are stored in °C, km/h, Pa, m and mm whatever the unit system.
+type UnitSystem string
+
+const (
+	UnitsUS     UnitSystem = "us"     // °F, mph, inHg, mi, in
+	UnitsSI     UnitSystem = "si"     // °C, m/s, hPa, km, mm
+	UnitsMetric UnitSystem = "metric" // °C, km/h, hPa, km, mm
+)
+
+const (
+	paPerInHg = 3386.389
+	mPerMile  = 1609.344
+	mmPerInch = 25.4
+)
+
+// ParseUnitSystem returns the unit system with the given name, "imperial" being accepted for
+// "us". An empty name selects the US units NWS uses by default.
+func ParseUnitSystem(name string) (UnitSystem, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "us", "imperial":
+		return UnitsUS, nil
+	case "si":
+		return UnitsSI, nil
+	case "metric":
+		return UnitsMetric, nil
+	}
+	return "", fmt.Errorf("unknown unit system %q, must be us, si or metric", name)
+}
+
+// NWSUnits returns the value of the NWS forecast units query parameter, which only knows us and si
+func (u UnitSystem) NWSUnits() string {
+	if u == UnitsUS || u == "" {
+		return "us"
+	}
+	return "si"
+}
+
+// Temperature converts a temperature in °C to the unit system
+func (u UnitSystem) Temperature(celsius float64) float64 {
+	if u.isUS() {
+		return celsius*9/5 + 32
+	}
+	return celsius
+}
+
+// TemperatureDifference converts a temperature difference, such as a forecast error, in °C to the
+// unit system
+func (u UnitSystem) TemperatureDifference(celsius float64) float64 {
+	if u.isUS() {
+		return celsius * 9 / 5
+	}
+	return celsius
+}
+
+// Speed converts a speed in km/h to the unit system
+func (u UnitSystem) Speed(kmh float64) float64 {
+	switch {
+	case u.isUS():
+		return kmh / kmhPerMph
+	case u == UnitsSI:
+		return kmh / 3.6
+	}
+	return kmh
+}
+
+// Pressure converts a pressure in Pa to the unit system
+func (u UnitSystem) Pressure(pa float64) float64 {
+	if u.isUS() {
+		return pa / paPerInHg
+	}
+	return pa / 100
+}
+
+// Distance converts a distance in m to the unit system
+func (u UnitSystem) Distance(m float64) float64 {
+	if u.isUS() {
+		return m / mPerMile
+	}
+	return m / 1000
+}
+
+// Precipitation converts a precipitation, snowfall or ice amount in mm to the unit system
+func (u UnitSystem) Precipitation(mm float64) float64 {
+	if u.isUS() {
+		return mm / mmPerInch
+	}
+	return mm
+}
+
+// TemperatureUnit returns the label of the temperature unit
+func (u UnitSystem) TemperatureUnit() string {
+	if u.isUS() {
+		return "°F"
+	}
+	return "°C"
+}
+
+// SpeedUnit returns the label of the speed unit
+func (u UnitSystem) SpeedUnit() string {
+	switch {
+	case u.isUS():
+		return "mph"
+	case u == UnitsSI:
+		return "m/s"
+	}
+	return "km/h"
+}
+
+// PressureUnit returns the label of the pressure unit
+func (u UnitSystem) PressureUnit() string {
+	if u.isUS() {
+		return "inHg"
+	}
+	return "hPa"
+}
+
+// DistanceUnit returns the label of the distance unit
+func (u UnitSystem) DistanceUnit() string {
+	if u.isUS() {
+		return "mi"
+	}
+	return "km"
+}
+
+// PrecipitationUnit returns the label of the precipitation unit
+func (u UnitSystem) PrecipitationUnit() string {
+	if u.isUS() {
+		return "in"
+	}
+	return "mm"
+}
+
+// FormatTemperature formats a temperature in °C in the unit system
+func (u UnitSystem) FormatTemperature(celsius float64, precision int) string {
+	return fmt.Sprintf("%.*f%s", precision, u.Temperature(celsius), u.TemperatureUnit())
+}
+
+// FormatSpeed formats a speed in km/h in the unit system
+func (u UnitSystem) FormatSpeed(kmh float64, precision int) string {
+	return fmt.Sprintf("%.*f %s", precision, u.Speed(kmh), u.SpeedUnit())
+}
+
+// FormatPressure formats a pressure in Pa in the unit system
+func (u UnitSystem) FormatPressure(pa float64) string {
+	precision := 1
+	if u.isUS() {
+		precision = 2
+	}
+	return fmt.Sprintf("%.*f %s", precision, u.Pressure(pa), u.PressureUnit())
+}
+
+// FormatDistance formats a distance in m in the unit system
+func (u UnitSystem) FormatDistance(m float64) string {
+	return fmt.Sprintf("%.1f %s", u.Distance(m), u.DistanceUnit())
+}
+
+// FormatPrecipitation formats a precipitation amount in mm in the unit system
+func (u UnitSystem) FormatPrecipitation(mm float64) string {
+	precision := 1
+	if u.isUS() {
+		precision = 2
+	}
+	return fmt.Sprintf("%.*f %s", precision, u.Precipitation(mm), u.PrecipitationUnit())
+}
+
+// FormatForecastTemperature formats a forecast period temperature given in unit "F" or "C"
+func (u UnitSystem) FormatForecastTemperature(value int, unit string) string {
+	celsius, _, ok := ConvertTemperature(float64(value), unit)
+	if !ok {
+		return fmt.Sprintf("%d°%s", value, unit)
+	}
+	return u.FormatTemperature(celsius, 0)
+}
+
+// FormatWindSpeed formats forecast wind speed text such as "5 to 10 mph" in the unit system. A
+// range collapses to one speed when both ends round to the same value. Text that is already in
+// the unit system, "Calm" or text that cannot be parsed is returned unchanged.
+func (u UnitSystem) FormatWindSpeed(text string) string {
+	if strings.HasSuffix(strings.ToLower(text), " "+u.SpeedUnit()) {
+		return text
+	}
+
+	min, max, ok := ParseWindSpeed(text)
+	if !ok || strings.EqualFold(strings.TrimSpace(text), "calm") {
+		return text
+	}
+	if math.Round(u.Speed(min)) == math.Round(u.Speed(max)) {
+		return u.FormatSpeed(max, 0)
+	}
+	return fmt.Sprintf("%.0f to %s", u.Speed(min), u.FormatSpeed(max, 0))
+}
+
+// FormatQuantity formats a quantitative value from the NWS API in the unit system. Values in
+// units the unit system doesn't change, such as percentages and angles, keep their unit.
+func (u UnitSystem) FormatQuantity(q QuantitativeValue) string {
+	if q.Value == nil {
+		return "n/a"
+	}
+
+	value := *q.Value
+	switch q.UnitCode {
+	case "wmoUnit:degC":
+		return u.FormatTemperature(value, 1)
+	case "wmoUnit:degF":
+		return u.FormatTemperature((value-32)*5/9, 1)
+	case "wmoUnit:km_h-1":
+		return u.FormatSpeed(value, 1)
+	case "wmoUnit:m_s-1":
+		return u.FormatSpeed(value*3.6, 1)
+	case "wmoUnit:Pa":
+		return u.FormatPressure(value)
+	case "wmoUnit:m":
+		return u.FormatDistance(value)
+	case "wmoUnit:mm":
+		return u.FormatPrecipitation(value)
+	}
+	return formatQuantity(q)
+}
+
+// isUS reports whether the unit system is US units, the default
+func (u UnitSystem) isUS() bool {
+	return u == UnitsUS || u == ""
+}
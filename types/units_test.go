@@ -0,0 +1,187 @@
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseUnitSystem(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    UnitSystem
+		wantErr bool
+	}{
+		{name: "", want: UnitsUS},
+		{name: "us", want: UnitsUS},
+		{name: "Imperial", want: UnitsUS},
+		{name: " SI ", want: UnitsSI},
+		{name: "metric", want: UnitsMetric},
+		{name: "kelvin", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUnitSystem(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUnitSystem(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseUnitSystem(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnitConversions(t *testing.T) {
+	tests := []struct {
+		units                                    UnitSystem
+		nwsUnits                                 string
+		temperature, difference, speed, pressure float64 // 20 °C, 10 °C, 36 km/h, 101325 Pa
+		distance, precipitation                  float64 // 16093.44 m, 25.4 mm
+		temperatureUnit, speedUnit, pressureUnit string
+		distanceUnit, precipitationUnit          string
+	}{
+		{
+			units: UnitsUS, nwsUnits: "us",
+			temperature: 68, difference: 18, speed: 36 / kmhPerMph, pressure: 101325 / paPerInHg,
+			distance: 10, precipitation: 1,
+			temperatureUnit: "°F", speedUnit: "mph", pressureUnit: "inHg", distanceUnit: "mi", precipitationUnit: "in",
+		},
+		{
+			units: "", nwsUnits: "us",
+			temperature: 68, difference: 18, speed: 36 / kmhPerMph, pressure: 101325 / paPerInHg,
+			distance: 10, precipitation: 1,
+			temperatureUnit: "°F", speedUnit: "mph", pressureUnit: "inHg", distanceUnit: "mi", precipitationUnit: "in",
+		},
+		{
+			units: UnitsSI, nwsUnits: "si",
+			temperature: 20, difference: 10, speed: 10, pressure: 1013.25,
+			distance: 16.09344, precipitation: 25.4,
+			temperatureUnit: "°C", speedUnit: "m/s", pressureUnit: "hPa", distanceUnit: "km", precipitationUnit: "mm",
+		},
+		{
+			units: UnitsMetric, nwsUnits: "si",
+			temperature: 20, difference: 10, speed: 36, pressure: 1013.25,
+			distance: 16.09344, precipitation: 25.4,
+			temperatureUnit: "°C", speedUnit: "km/h", pressureUnit: "hPa", distanceUnit: "km", precipitationUnit: "mm",
+		},
+	}
+
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+
+	for _, tt := range tests {
+		t.Run(string(tt.units), func(t *testing.T) {
+			u := tt.units
+			if got := u.NWSUnits(); got != tt.nwsUnits {
+				t.Errorf("NWSUnits() = %q, want %q", got, tt.nwsUnits)
+			}
+
+			values := []struct {
+				name      string
+				got, want float64
+			}{
+				{"Temperature", u.Temperature(20), tt.temperature},
+				{"TemperatureDifference", u.TemperatureDifference(10), tt.difference},
+				{"Speed", u.Speed(36), tt.speed},
+				{"Pressure", u.Pressure(101325), tt.pressure},
+				{"Distance", u.Distance(16093.44), tt.distance},
+				{"Precipitation", u.Precipitation(25.4), tt.precipitation},
+			}
+			for _, v := range values {
+				if !near(v.got, v.want) {
+					t.Errorf("%s() = %v, want %v", v.name, v.got, v.want)
+				}
+			}
+
+			labels := []struct{ name, got, want string }{
+				{"TemperatureUnit", u.TemperatureUnit(), tt.temperatureUnit},
+				{"SpeedUnit", u.SpeedUnit(), tt.speedUnit},
+				{"PressureUnit", u.PressureUnit(), tt.pressureUnit},
+				{"DistanceUnit", u.DistanceUnit(), tt.distanceUnit},
+				{"PrecipitationUnit", u.PrecipitationUnit(), tt.precipitationUnit},
+			}
+			for _, l := range labels {
+				if l.got != l.want {
+					t.Errorf("%s() = %q, want %q", l.name, l.got, l.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatWindSpeed(t *testing.T) {
+	tests := []struct {
+		units UnitSystem
+		text  string
+		want  string
+	}{
+		{UnitsUS, "5 to 10 mph", "5 to 10 mph"},
+		{UnitsUS, "20 km/h", "12 mph"},
+		{UnitsUS, "10 to 15 km/h", "6 to 9 mph"},
+		{UnitsUS, "Calm", "Calm"},
+		{UnitsUS, "breezy", "breezy"},
+		{UnitsSI, "5 to 7 mph", "2 to 3 m/s"},
+		{UnitsSI, "4 to 5 mph", "2 m/s"}, // Both ends round to 2
+		{UnitsSI, "0 mph", "0 m/s"},
+		{UnitsSI, "calm", "calm"},
+		{UnitsMetric, "10 mph", "16 km/h"},
+		{UnitsMetric, "5 to 10 mph", "8 to 16 km/h"},
+		{UnitsMetric, "20 km/h", "20 km/h"},
+		{UnitsMetric, "0 mph", "0 km/h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.units)+"/"+tt.text, func(t *testing.T) {
+			if got := tt.units.FormatWindSpeed(tt.text); got != tt.want {
+				t.Errorf("%s.FormatWindSpeed(%q) = %q, want %q", tt.units, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	tests := []struct {
+		units UnitSystem
+		q     QuantitativeValue
+		want  string
+	}{
+		{UnitsUS, QuantitativeValue{Value: floatPtr(20), UnitCode: "wmoUnit:degC"}, "68.0°F"},
+		{UnitsSI, QuantitativeValue{Value: floatPtr(68), UnitCode: "wmoUnit:degF"}, "20.0°C"},
+		{UnitsSI, QuantitativeValue{Value: floatPtr(36), UnitCode: "wmoUnit:km_h-1"}, "10.0 m/s"},
+		{UnitsMetric, QuantitativeValue{Value: floatPtr(10), UnitCode: "wmoUnit:m_s-1"}, "36.0 km/h"},
+		{UnitsUS, QuantitativeValue{Value: floatPtr(101325), UnitCode: "wmoUnit:Pa"}, "29.92 inHg"},
+		{UnitsSI, QuantitativeValue{Value: floatPtr(101325), UnitCode: "wmoUnit:Pa"}, "1013.2 hPa"},
+		{UnitsUS, QuantitativeValue{Value: floatPtr(16093.44), UnitCode: "wmoUnit:m"}, "10.0 mi"},
+		{UnitsUS, QuantitativeValue{Value: floatPtr(12.7), UnitCode: "wmoUnit:mm"}, "0.50 in"},
+		{UnitsUS, QuantitativeValue{UnitCode: "wmoUnit:degC"}, "n/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.units)+"/"+tt.q.UnitCode, func(t *testing.T) {
+			if got := tt.units.FormatQuantity(tt.q); got != tt.want {
+				t.Errorf("%s.FormatQuantity(%s) = %q, want %q", tt.units, tt.q.UnitCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatForecastTemperature(t *testing.T) {
+	tests := []struct {
+		units UnitSystem
+		value int
+		unit  string
+		want  string
+	}{
+		{UnitsUS, 61, "F", "61°F"},
+		{UnitsSI, 61, "F", "16°C"},
+		{UnitsUS, 20, "C", "68°F"},
+		{UnitsMetric, 20, "C", "20°C"},
+		{UnitsSI, 300, "K", "300°K"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.units.FormatForecastTemperature(tt.value, tt.unit); got != tt.want {
+			t.Errorf("%s.FormatForecastTemperature(%d, %q) = %q, want %q", tt.units, tt.value, tt.unit, got, tt.want)
+		}
+	}
+}
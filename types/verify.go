@@ -0,0 +1,434 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/dwburke/weather/db"
+)
+
+// LeadTimeBucket groups forecasts by how far ahead of the period start they were retrieved
+type LeadTimeBucket struct {
+	Label string
+	From  time.Duration
+	To    time.Duration
+}
+
+// DefaultLeadTimeBuckets are the lead time ranges verification results are reported in
+var DefaultLeadTimeBuckets = []LeadTimeBucket{
+	{Label: "0-12h", From: 0, To: 12 * time.Hour},
+	{Label: "12-24h", From: 12 * time.Hour, To: 24 * time.Hour},
+	{Label: "1-2d", From: 24 * time.Hour, To: 48 * time.Hour},
+	{Label: "2-3d", From: 48 * time.Hour, To: 72 * time.Hour},
+	{Label: "3-5d", From: 72 * time.Hour, To: 120 * time.Hour},
+	{Label: "5d+", From: 120 * time.Hour, To: time.Duration(math.MaxInt64)},
+}
+
+// LeadTimeStats holds the accumulated forecast errors for one lead time bucket.
+// Temperature errors are in °C and wind speed errors in km/h (forecast minus observed).
+type LeadTimeStats struct {
+	Bucket LeadTimeBucket
+
+	TemperatureCount  int
+	temperatureErrSum float64
+	temperatureAbsSum float64
+	temperatureSqSum  float64
+
+	WindCount  int
+	windErrSum float64
+	windAbsSum float64
+
+	PrecipHits             int
+	PrecipMisses           int
+	PrecipFalseAlarms      int
+	PrecipCorrectNegatives int
+}
+
+// TemperatureBias returns the mean temperature error (positive means forecasts were too warm)
+func (s *LeadTimeStats) TemperatureBias() float64 {
+	if s.TemperatureCount == 0 {
+		return math.NaN()
+	}
+	return s.temperatureErrSum / float64(s.TemperatureCount)
+}
+
+// TemperatureMAE returns the mean absolute temperature error
+func (s *LeadTimeStats) TemperatureMAE() float64 {
+	if s.TemperatureCount == 0 {
+		return math.NaN()
+	}
+	return s.temperatureAbsSum / float64(s.TemperatureCount)
+}
+
+// TemperatureRMSE returns the root mean square temperature error
+func (s *LeadTimeStats) TemperatureRMSE() float64 {
+	if s.TemperatureCount == 0 {
+		return math.NaN()
+	}
+	return math.Sqrt(s.temperatureSqSum / float64(s.TemperatureCount))
+}
+
+// WindBias returns the mean wind speed error
+func (s *LeadTimeStats) WindBias() float64 {
+	if s.WindCount == 0 {
+		return math.NaN()
+	}
+	return s.windErrSum / float64(s.WindCount)
+}
+
+// WindMAE returns the mean absolute wind speed error
+func (s *LeadTimeStats) WindMAE() float64 {
+	if s.WindCount == 0 {
+		return math.NaN()
+	}
+	return s.windAbsSum / float64(s.WindCount)
+}
+
+// PrecipPOD returns the probability of detection: the share of observed precipitation that was forecast
+func (s *LeadTimeStats) PrecipPOD() float64 {
+	if s.PrecipHits+s.PrecipMisses == 0 {
+		return math.NaN()
+	}
+	return float64(s.PrecipHits) / float64(s.PrecipHits+s.PrecipMisses)
+}
+
+// PrecipFAR returns the false alarm ratio: the share of forecast precipitation that did not occur
+func (s *LeadTimeStats) PrecipFAR() float64 {
+	if s.PrecipHits+s.PrecipFalseAlarms == 0 {
+		return math.NaN()
+	}
+	return float64(s.PrecipFalseAlarms) / float64(s.PrecipHits+s.PrecipFalseAlarms)
+}
+
+// PrecipAccuracy returns the share of periods where the precipitation forecast was correct
+func (s *LeadTimeStats) PrecipAccuracy() float64 {
+	total := s.PrecipHits + s.PrecipMisses + s.PrecipFalseAlarms + s.PrecipCorrectNegatives
+	if total == 0 {
+		return math.NaN()
+	}
+	return float64(s.PrecipHits+s.PrecipCorrectNegatives) / float64(total)
+}
+
+func (s *LeadTimeStats) addTemperature(forecast, observed float64) {
+	diff := forecast - observed
+	s.TemperatureCount++
+	s.temperatureErrSum += diff
+	s.temperatureAbsSum += math.Abs(diff)
+	s.temperatureSqSum += diff * diff
+}
+
+func (s *LeadTimeStats) addWind(forecast, observed float64) {
+	diff := forecast - observed
+	s.WindCount++
+	s.windErrSum += diff
+	s.windAbsSum += math.Abs(diff)
+}
+
+func (s *LeadTimeStats) addPrecip(forecast, observed bool) {
+	switch {
+	case forecast && observed:
+		s.PrecipHits++
+	case !forecast && observed:
+		s.PrecipMisses++
+	case forecast && !observed:
+		s.PrecipFalseAlarms++
+	default:
+		s.PrecipCorrectNegatives++
+	}
+}
+
+// VerificationReport holds forecast verification results broken down by lead time
+type VerificationReport struct {
+	From             time.Time
+	To               time.Time
+	IsHourly         bool
+	ForecastCount    int
+	ObservationCount int
+	MatchedCount     int
+	Stats            []*LeadTimeStats
+}
+
+// PrecipitationThreshold is the chance of precipitation, in %, at or above which a forecast
+// period counts as forecasting precipitation when it is verified
+const PrecipitationThreshold = 50.0
+
+// VerifyForecasts pairs stored forecasts for the given coordinates whose periods start within
+// [from, to) with stored observations and scores them by lead time. Forecasts and observations
+// are matched to the coordinates rounded to 4 decimal places (about 11 m), the precision of NWS
+// /points lookups, so coordinates that only differ in later digits still match.
+func VerifyForecasts(lat, lon float64, from, to time.Time, isHourly bool) (*VerificationReport, error) {
+	return VerifyForecastsContext(context.Background(), lat, lon, from, to, isHourly)
+}
+
+// VerifyForecastsContext is like VerifyForecasts but returns early once ctx is done
+func VerifyForecastsContext(ctx context.Context, lat, lon float64, from, to time.Time, isHourly bool) (*VerificationReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var forecasts []WeatherForecast
+	if err := nearCoordinates(gdbh, lat, lon).
+		Where("is_hourly = ? AND start_time >= ? AND start_time < ?", isHourly, from, to).
+		Order("start_time ASC, forecast_date ASC").
+		Find(&forecasts).Error; err != nil {
+		return nil, err
+	}
+
+	return verify(ctx, forecasts, nearCoordinates(gdbh, lat, lon), from, to, isHourly)
+}
+
+// VerifyForecastsForLocation is like VerifyForecasts but for a named location. Forecasts and
+// observations are matched by location ID, so those saved before the location was moved are
+// included. Observations saved for bare coordinates are also used when they are near the
+// location's current coordinates, rounded as for VerifyForecasts.
+func VerifyForecastsForLocation(loc *Location, from, to time.Time, isHourly bool) (*VerificationReport, error) {
+	return VerifyForecastsForLocationContext(context.Background(), loc, from, to, isHourly)
+}
+
+// VerifyForecastsForLocationContext is like VerifyForecastsForLocation but returns early once ctx is done
+func VerifyForecastsForLocationContext(ctx context.Context, loc *Location, from, to time.Time, isHourly bool) (*VerificationReport, error) {
+	forecasts, err := GetForecastsInRangeForLocationContext(ctx, loc.ID, from, to, isHourly)
+	if err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	near, args := coordinateRange(loc.Latitude, loc.Longitude)
+	query := gdbh.Where("location_id = ? OR ((location_id = 0 OR location_id IS NULL) AND "+near+")",
+		append([]interface{}{loc.ID}, args...)...)
+
+	return verify(ctx, forecasts, query, from, to, isHourly)
+}
+
+// verify scores forecasts against the observations selected by query
+func verify(ctx context.Context, forecasts []WeatherForecast, query *gorm.DB, from, to time.Time, isHourly bool) (*VerificationReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Daily periods can end up to a day after the last period starts
+	var observations []Observation
+	if err := query.
+		Where("timestamp >= ? AND timestamp < ?", from, to.Add(24*time.Hour)).
+		Order("timestamp ASC").
+		Find(&observations).Error; err != nil {
+		return nil, err
+	}
+
+	report := ScoreForecasts(forecasts, observations)
+	report.From = from
+	report.To = to
+	report.IsHourly = isHourly
+
+	return report, nil
+}
+
+// nearCoordinates restricts a query to rows whose coordinates round to the same 4 decimal places
+// as lat and lon
+func nearCoordinates(tx *gorm.DB, lat, lon float64) *gorm.DB {
+	near, args := coordinateRange(lat, lon)
+	return tx.Where(near, args...)
+}
+
+// coordinateRange returns the condition matching coordinates that round to the same 4 decimal
+// places as lat and lon. It compares against a range rather than rounding the columns so the
+// query can use an index and works the same on every database.
+func coordinateRange(lat, lon float64) (string, []interface{}) {
+	const half = 0.00005
+	lat = math.Round(lat*10000) / 10000
+	lon = math.Round(lon*10000) / 10000
+
+	return "latitude >= ? AND latitude < ? AND longitude >= ? AND longitude < ?",
+		[]interface{}{lat - half, lat + half, lon - half, lon + half}
+}
+
+// ScoreForecasts computes error metrics for the forecasts against observations taken during each forecast period
+func ScoreForecasts(forecasts []WeatherForecast, observations []Observation) *VerificationReport {
+	report := &VerificationReport{
+		ForecastCount:    len(forecasts),
+		ObservationCount: len(observations),
+	}
+
+	for _, bucket := range DefaultLeadTimeBuckets {
+		report.Stats = append(report.Stats, &LeadTimeStats{Bucket: bucket})
+	}
+
+	for i := range forecasts {
+		forecast := &forecasts[i]
+
+		stats := report.statsFor(forecast.LeadTime())
+		if stats == nil {
+			continue
+		}
+
+		window := observationsInPeriod(observations, forecast.StartTime, forecast.EndTime)
+		if len(window) == 0 {
+			continue
+		}
+		report.MatchedCount++
+
+		if observed, ok := observedTemperature(window, forecast); ok {
+			stats.addTemperature(forecast.TemperatureC(), observed)
+		}
+
+		if forecastWind, ok := forecast.WindSpeedKmh(); ok {
+			if observed, ok := meanObserved(window, func(o *Observation) *float64 { return o.WindSpeedKmh }); ok {
+				stats.addWind(forecastWind, observed)
+			}
+		}
+
+		if predicted, ok := forecastsPrecipitation(forecast); ok {
+			if observed, ok := observedPrecipitation(window); ok {
+				stats.addPrecip(predicted, observed)
+			}
+		}
+	}
+
+	return report
+}
+
+// FormatReport returns a formatted string representation of the verification report
+func (r *VerificationReport) FormatReport(units UnitSystem) string {
+	forecastType := "daily"
+	if r.IsHourly {
+		forecastType = "hourly"
+	}
+
+	result := fmt.Sprintf("Forecast Verification (%s, %s to %s):\n", forecastType,
+		r.From.Format("2006-01-02"), r.To.Format("2006-01-02"))
+	result += "=========================================================\n\n"
+	result += fmt.Sprintf("📊 %d forecast periods, %d observations, %d periods matched\n\n",
+		r.ForecastCount, r.ObservationCount, r.MatchedCount)
+
+	result += fmt.Sprintf("%-8s %6s %9s %8s %9s %6s %9s %8s %6s %6s %6s\n",
+		"Lead", "N", "Bias "+units.TemperatureUnit(), "MAE "+units.TemperatureUnit(), "RMSE "+units.TemperatureUnit(),
+		"Wind N", "Wind Bias", "Wind MAE", "POD", "FAR", "Acc")
+
+	for _, s := range r.Stats {
+		result += fmt.Sprintf("%-8s %6d %9s %8s %9s %6d %9s %8s %6s %6s %6s\n",
+			s.Bucket.Label,
+			s.TemperatureCount,
+			formatMetric(units.TemperatureDifference(s.TemperatureBias())),
+			formatMetric(units.TemperatureDifference(s.TemperatureMAE())),
+			formatMetric(units.TemperatureDifference(s.TemperatureRMSE())),
+			s.WindCount,
+			formatMetric(units.Speed(s.WindBias())),
+			formatMetric(units.Speed(s.WindMAE())),
+			formatMetric(s.PrecipPOD()),
+			formatMetric(s.PrecipFAR()),
+			formatMetric(s.PrecipAccuracy()),
+		)
+	}
+
+	result += "\nWind errors are in " + units.SpeedUnit() + ". POD = probability of detection, FAR = false alarm ratio, Acc = precipitation accuracy.\n"
+
+	return result
+}
+
+func (r *VerificationReport) statsFor(lead time.Duration) *LeadTimeStats {
+	for _, s := range r.Stats {
+		if lead >= s.Bucket.From && lead < s.Bucket.To {
+			return s
+		}
+	}
+	return nil
+}
+
+func formatMetric(value float64) string {
+	if math.IsNaN(value) {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// observationsInPeriod returns the observations taken within [start, end)
+func observationsInPeriod(observations []Observation, start, end time.Time) []Observation {
+	var window []Observation
+	for _, o := range observations {
+		if !o.Timestamp.Before(start) && o.Timestamp.Before(end) {
+			window = append(window, o)
+		}
+	}
+	return window
+}
+
+// observedTemperature returns the observed value comparable to the forecast temperature.
+// Daily periods forecast the daytime high or overnight low, hourly periods the mean.
+func observedTemperature(window []Observation, forecast *WeatherForecast) (float64, bool) {
+	temperature := func(o *Observation) *float64 { return o.TemperatureC }
+
+	if forecast.IsHourly {
+		return meanObserved(window, temperature)
+	}
+
+	var result float64
+	found := false
+	for i := range window {
+		value := temperature(&window[i])
+		if value == nil {
+			continue
+		}
+		if !found ||
+			(forecast.IsDaytime && *value > result) ||
+			(!forecast.IsDaytime && *value < result) {
+			result = *value
+			found = true
+		}
+	}
+
+	return result, found
+}
+
+func meanObserved(window []Observation, field func(*Observation) *float64) (float64, bool) {
+	var sum float64
+	var count int
+	for i := range window {
+		if value := field(&window[i]); value != nil {
+			sum += *value
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// observedPrecipitation reports whether any precipitation was measured in the window
+func observedPrecipitation(window []Observation) (bool, bool) {
+	reported := false
+	for _, o := range window {
+		if o.PrecipitationLastHourMm == nil {
+			continue
+		}
+		reported = true
+		if *o.PrecipitationLastHourMm > 0 {
+			return true, true
+		}
+	}
+	return false, reported
+}
+
+// forecastsPrecipitation reports whether the period's chance of precipitation is at least
+// PrecipitationThreshold, and whether a chance was stored. Periods saved before the chance was
+// stored, or for which the provider gave none, are not scored for precipitation.
+func forecastsPrecipitation(forecast *WeatherForecast) (bool, bool) {
+	if forecast.PrecipitationProbability == nil {
+		return false, false
+	}
+	return *forecast.PrecipitationProbability >= PrecipitationThreshold, true
+}
@@ -0,0 +1,295 @@
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVerifyForecastsMatching(t *testing.T) {
+	// The run is saved with coordinates that only differ from the query in later digits
+	forecast := replayForecast(t, true)
+	if _, err := SaveForecastRun(&ForecastRun{LocationID: 950, Latitude: 35.46760001, Longitude: -97.51639998, IsHourly: true}, forecast); err != nil {
+		t.Fatalf("SaveForecastRun() error = %v", err)
+	}
+	start, err := time.Parse(time.RFC3339, forecast.Properties.Periods[0].StartTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observations := stationObservations("KOKC", start, 2)
+	if _, err := SaveObservationsToDB(observations, 35.4676, -97.5164); err != nil {
+		t.Fatal(err)
+	}
+	// About 11 m away, a different rounded key
+	if _, err := SaveObservationsToDB(observations, 35.4677, -97.5164); err != nil {
+		t.Fatal(err)
+	}
+
+	// Observations saved for the location before it was moved
+	if _, err := SaveObservationsForLocation(stationObservations("KPWA", start, 1), &Location{ID: 950, Latitude: 35.5346, Longitude: -97.6473}); err != nil {
+		t.Fatal(err)
+	}
+
+	loc := &Location{ID: 950, Latitude: 35.4676, Longitude: -97.5164}
+	other := &Location{ID: 951, Latitude: 35.4676, Longitude: -97.5164}
+
+	tests := []struct {
+		name             string
+		verify           func() (*VerificationReport, error)
+		wantForecasts    int
+		wantObservations int
+	}{
+		{
+			name: "coordinates",
+			verify: func() (*VerificationReport, error) {
+				return VerifyForecasts(35.4676, -97.5164, start, start.Add(2*time.Hour), true)
+			},
+			wantForecasts:    2,
+			wantObservations: 2,
+		},
+		{
+			name: "coordinates with more digits",
+			verify: func() (*VerificationReport, error) {
+				return VerifyForecasts(35.46759, -97.51641, start, start.Add(2*time.Hour), true)
+			},
+			wantForecasts:    2,
+			wantObservations: 2,
+		},
+		{
+			name: "other coordinates",
+			verify: func() (*VerificationReport, error) {
+				return VerifyForecasts(35.4678, -97.5164, start, start.Add(2*time.Hour), true)
+			},
+			wantForecasts:    0,
+			wantObservations: 0,
+		},
+		{
+			name: "location",
+			verify: func() (*VerificationReport, error) {
+				return VerifyForecastsForLocation(loc, start, start.Add(2*time.Hour), true)
+			},
+			wantForecasts:    2,
+			wantObservations: 3,
+		},
+		{
+			name: "other location at the same coordinates",
+			verify: func() (*VerificationReport, error) {
+				return VerifyForecastsForLocation(other, start, start.Add(2*time.Hour), true)
+			},
+			wantForecasts:    0,
+			wantObservations: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := tt.verify()
+			if err != nil {
+				t.Fatalf("verify error = %v", err)
+			}
+			if report.ForecastCount != tt.wantForecasts || report.ObservationCount != tt.wantObservations {
+				t.Errorf("verified %d forecasts against %d observations, want %d, %d",
+					report.ForecastCount, report.ObservationCount, tt.wantForecasts, tt.wantObservations)
+			}
+		})
+	}
+}
+
+func TestScoreForecasts(t *testing.T) {
+	start := time.Date(2026, 6, 1, 6, 0, 0, 0, time.UTC)
+
+	// period returns a forecast for [start, start+length) retrieved lead before it starts
+	period := func(lead, length time.Duration, isDaytime, isHourly bool, temperature int, wind string, precipChance *float64) WeatherForecast {
+		return WeatherForecast{
+			StartTime:                start,
+			EndTime:                  start.Add(length),
+			IsDaytime:                isDaytime,
+			IsHourly:                 isHourly,
+			Temperature:              temperature,
+			TemperatureUnit:          "C",
+			WindSpeed:                wind,
+			PrecipitationProbability: precipChance,
+			ForecastDate:             start.Add(-lead),
+		}
+	}
+	// observation returns an observation taken offset after start
+	observation := func(offset time.Duration, temperature, wind, precipitation *float64) Observation {
+		return Observation{
+			Timestamp:               start.Add(offset),
+			TemperatureC:            temperature,
+			WindSpeedKmh:            wind,
+			PrecipitationLastHourMm: precipitation,
+		}
+	}
+
+	type precip struct{ hits, misses, falseAlarms, correctNegatives int }
+
+	tests := []struct {
+		name         string
+		forecasts    []WeatherForecast
+		observations []Observation
+		wantMatched  int
+		bucket       string  // Bucket the forecasts are scored in
+		wantTempN    int     // Temperatures scored
+		wantTempBias float64 // Checked if wantTempN > 0
+		wantTempMAE  float64
+		wantTempRMSE float64
+		wantWindN    int
+		wantWindBias float64 // Checked if wantWindN > 0
+		wantPrecip   precip
+	}{
+		{
+			name:      "daytime high",
+			forecasts: []WeatherForecast{period(6*time.Hour, 12*time.Hour, true, false, 16, "10 to 14 km/h", floatPtr(60))},
+			observations: []Observation{
+				observation(time.Hour, floatPtr(10), floatPtr(10), floatPtr(0)),
+				observation(5*time.Hour, floatPtr(15), floatPtr(14), floatPtr(1.2)),
+				observation(9*time.Hour, floatPtr(12), nil, nil),
+			},
+			wantMatched:  1,
+			bucket:       "0-12h",
+			wantTempN:    1,
+			wantTempBias: 1,
+			wantTempMAE:  1,
+			wantTempRMSE: 1,
+			wantWindN:    1,
+			wantWindBias: 0,
+			wantPrecip:   precip{hits: 1},
+		},
+		{
+			name:      "overnight low",
+			forecasts: []WeatherForecast{period(18*time.Hour, 12*time.Hour, false, false, 5, "5 km/h", floatPtr(0))},
+			observations: []Observation{
+				observation(time.Hour, floatPtr(8), nil, floatPtr(0)),
+				observation(6*time.Hour, floatPtr(3), nil, floatPtr(0)),
+			},
+			wantMatched:  1,
+			bucket:       "12-24h",
+			wantTempN:    1,
+			wantTempBias: 2,
+			wantTempMAE:  2,
+			wantTempRMSE: 2,
+			wantPrecip:   precip{correctNegatives: 1},
+		},
+		{
+			name:      "hourly mean",
+			forecasts: []WeatherForecast{period(30*time.Hour, time.Hour, true, true, 10, "Calm", floatPtr(80))},
+			observations: []Observation{
+				observation(0, floatPtr(11), floatPtr(2), floatPtr(0)),
+				observation(30*time.Minute, floatPtr(13), floatPtr(4), floatPtr(0)),
+				observation(time.Hour, floatPtr(30), floatPtr(50), floatPtr(5)), // next period
+			},
+			wantMatched:  1,
+			bucket:       "1-2d",
+			wantTempN:    1,
+			wantTempBias: -2,
+			wantTempMAE:  2,
+			wantTempRMSE: 2,
+			wantWindN:    1,
+			wantWindBias: -3,
+			wantPrecip:   precip{falseAlarms: 1},
+		},
+		{
+			name: "errors accumulated",
+			forecasts: []WeatherForecast{
+				period(2*time.Hour, time.Hour, true, true, 14, "", floatPtr(0)),
+				period(3*time.Hour, time.Hour, true, true, 8, "", floatPtr(PrecipitationThreshold-1)),
+			},
+			observations: []Observation{observation(0, floatPtr(11), nil, floatPtr(0.5))},
+			wantMatched:  2,
+			bucket:       "0-12h",
+			wantTempN:    2,
+			wantTempBias: 0,
+			wantTempMAE:  3,
+			wantTempRMSE: 3,
+			wantPrecip:   precip{misses: 2},
+		},
+		{
+			name:         "chance at the threshold",
+			forecasts:    []WeatherForecast{period(time.Hour, time.Hour, true, true, 10, "", floatPtr(PrecipitationThreshold))},
+			observations: []Observation{observation(0, nil, nil, floatPtr(0.2))},
+			wantMatched:  1,
+			bucket:       "0-12h",
+			wantPrecip:   precip{hits: 1},
+		},
+		{
+			name:         "no stored chance",
+			forecasts:    []WeatherForecast{period(time.Hour, time.Hour, true, true, 10, "", nil)},
+			observations: []Observation{observation(0, nil, nil, floatPtr(0.2))},
+			wantMatched:  1,
+			bucket:       "0-12h",
+		},
+		{
+			name:         "no observations in period",
+			forecasts:    []WeatherForecast{period(time.Hour, time.Hour, true, true, 10, "5 km/h", floatPtr(90))},
+			observations: []Observation{observation(-time.Minute, floatPtr(10), floatPtr(5), floatPtr(1))},
+			bucket:       "0-12h",
+		},
+		{
+			name:         "retrieved after the period started",
+			forecasts:    []WeatherForecast{period(-time.Hour, time.Hour, true, true, 10, "5 km/h", floatPtr(90))},
+			observations: []Observation{observation(0, floatPtr(10), floatPtr(5), floatPtr(1))},
+			bucket:       "0-12h",
+		},
+		{
+			name:         "missing values",
+			forecasts:    []WeatherForecast{period(6*24*time.Hour, time.Hour, true, true, 10, "breezy", floatPtr(90))},
+			observations: []Observation{observation(0, nil, floatPtr(5), nil)},
+			wantMatched:  1,
+			bucket:       "5d+",
+		},
+	}
+
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ScoreForecasts(tt.forecasts, tt.observations)
+
+			if report.ForecastCount != len(tt.forecasts) || report.ObservationCount != len(tt.observations) {
+				t.Errorf("counts = %d forecasts, %d observations, want %d, %d",
+					report.ForecastCount, report.ObservationCount, len(tt.forecasts), len(tt.observations))
+			}
+			if report.MatchedCount != tt.wantMatched {
+				t.Errorf("MatchedCount = %d, want %d", report.MatchedCount, tt.wantMatched)
+			}
+			if len(report.Stats) != len(DefaultLeadTimeBuckets) {
+				t.Fatalf("%d lead time buckets, want %d", len(report.Stats), len(DefaultLeadTimeBuckets))
+			}
+
+			for _, stats := range report.Stats {
+				if stats.Bucket.Label != tt.bucket {
+					if stats.TemperatureCount != 0 || stats.WindCount != 0 || !math.IsNaN(stats.PrecipAccuracy()) {
+						t.Errorf("bucket %s has scores, want them all in %s", stats.Bucket.Label, tt.bucket)
+					}
+					continue
+				}
+
+				if stats.TemperatureCount != tt.wantTempN {
+					t.Errorf("TemperatureCount = %d, want %d", stats.TemperatureCount, tt.wantTempN)
+				} else if tt.wantTempN > 0 {
+					if !near(stats.TemperatureBias(), tt.wantTempBias) || !near(stats.TemperatureMAE(), tt.wantTempMAE) ||
+						!near(stats.TemperatureRMSE(), tt.wantTempRMSE) {
+						t.Errorf("temperature bias, MAE, RMSE = %v, %v, %v, want %v, %v, %v",
+							stats.TemperatureBias(), stats.TemperatureMAE(), stats.TemperatureRMSE(),
+							tt.wantTempBias, tt.wantTempMAE, tt.wantTempRMSE)
+					}
+				} else if !math.IsNaN(stats.TemperatureBias()) {
+					t.Errorf("TemperatureBias() = %v with no temperatures, want NaN", stats.TemperatureBias())
+				}
+
+				if stats.WindCount != tt.wantWindN {
+					t.Errorf("WindCount = %d, want %d", stats.WindCount, tt.wantWindN)
+				} else if tt.wantWindN > 0 && !near(stats.WindBias(), tt.wantWindBias) {
+					t.Errorf("WindBias() = %v, want %v", stats.WindBias(), tt.wantWindBias)
+				}
+
+				got := precip{stats.PrecipHits, stats.PrecipMisses, stats.PrecipFalseAlarms, stats.PrecipCorrectNegatives}
+				if got != tt.wantPrecip {
+					t.Errorf("precipitation = %+v, want %+v", got, tt.wantPrecip)
+				}
+			}
+		})
+	}
+}
@@ -1,11 +1,16 @@
 package types
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // NWS API Response structures
@@ -14,20 +19,27 @@ type PointsResponse struct {
 }
 
 type PointsProperties struct {
-	GridID           string `json:"gridId"`
-	GridX            int    `json:"gridX"`
-	GridY            int    `json:"gridY"`
-	Forecast         string `json:"forecast"`
-	ForecastHourly   string `json:"forecastHourly"`
-	ForecastGridData string `json:"forecastGridData"`
+	GridID              string `json:"gridId"`
+	GridX               int    `json:"gridX"`
+	GridY               int    `json:"gridY"`
+	Forecast            string `json:"forecast"`
+	ForecastHourly      string `json:"forecastHourly"`
+	ForecastGridData    string `json:"forecastGridData"`
+	ObservationStations string `json:"observationStations"`
+	TimeZone            string `json:"timeZone"`
+	ForecastZone        string `json:"forecastZone"`
+	County              string `json:"county"`
 }
 
 type ForecastResponse struct {
+	Provider   string             `json:"provider,omitempty"` // Provider the forecast came from, set by the client
 	Properties ForecastProperties `json:"properties"`
 }
 
 type ForecastProperties struct {
-	Periods []ForecastPeriod `json:"periods"`
+	GeneratedAt string           `json:"generatedAt"`
+	UpdateTime  string           `json:"updateTime"`
+	Periods     []ForecastPeriod `json:"periods"`
 }
 
 type ForecastPeriod struct {
@@ -44,75 +56,159 @@ type ForecastPeriod struct {
 	Icon             string `json:"icon"`
 	ShortForecast    string `json:"shortForecast"`
 	DetailedForecast string `json:"detailedForecast"`
+
+	ProbabilityOfPrecipitation QuantitativeValue `json:"probabilityOfPrecipitation"`
+	Dewpoint                   QuantitativeValue `json:"dewpoint"`
+	RelativeHumidity           QuantitativeValue `json:"relativeHumidity"`
 }
 
 // WeatherClient handles NWS API interactions
 type WeatherClient struct {
-	BaseURL    string
-	HTTPClient *http.Client
+	BaseURL     string
+	HTTPClient  *http.Client
+	PointsCache *PointsCache  // Optional cache of /points lookups, nil disables caching
+	RetryPolicy RetryPolicy   // How failed requests are retried
+	Limiter     *rate.Limiter // Optional request rate limiter, may be shared between clients
+	Units       UnitSystem    // Units forecasts are requested in, the NWS default (us) if empty
+}
+
+const userAgent = "weather-app/1.0 (your-email@example.com)"
+
+// APIError is returned when the NWS API responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("NWS API error: %s - %s", e.Status, e.Body)
 }
 
 func NewWeatherClient() *WeatherClient {
+	// An in-memory cache never fails to be created
+	pointsCache, _ := NewPointsCache(DefaultPointsCacheTTL, "")
+
 	return &WeatherClient{
 		BaseURL: "https://api.weather.gov",
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		PointsCache: pointsCache,
+		RetryPolicy: DefaultRetryPolicy(),
 	}
 }
 
-// GetForecastByCoordinates gets weather forecast for given latitude and longitude
-func (w *WeatherClient) GetForecastByCoordinates(lat, lon float64) (*ForecastResponse, error) {
-	// First, get the grid information for the coordinates
-	pointsURL := fmt.Sprintf("%s/points/%.4f,%.4f", w.BaseURL, lat, lon)
-
-	req, err := http.NewRequest("GET", pointsURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// NWS API requires a User-Agent header
-	req.Header.Set("User-Agent", "weather-app/1.0 (your-email@example.com)")
+// getJSON performs a GET request against the NWS API and decodes the JSON response into v
+func (w *WeatherClient) getJSON(ctx context.Context, url string, v interface{}) error {
+	_, err := w.fetchJSON(ctx, url, v)
+	return err
+}
 
-	resp, err := w.HTTPClient.Do(req)
+// fetchJSON performs a GET request against the NWS API and decodes the JSON response into v.
+// It reports whether the request was redirected to a different URL.
+func (w *WeatherClient) fetchJSON(ctx context.Context, url string, v interface{}) (bool, error) {
+	resp, err := w.do(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get points data: %w", err)
+		return false, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("NWS API error: %s - %s", resp.Status, string(body))
+		return false, &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
 
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return resp.Request.URL.String() != url, nil
+}
+
+// do performs a GET request against the NWS API with the client's retry policy and rate limiter
+func (w *WeatherClient) do(ctx context.Context, url string) (*http.Response, error) {
+	return getWithRetry(ctx, w.HTTPClient, &w.RetryPolicy, w.Limiter, url, "application/geo+json")
+}
+
+// GetPoints gets the grid metadata (forecast URLs, grid coordinates, zones) for given latitude and longitude,
+// using the points cache when one is configured
+func (w *WeatherClient) GetPoints(lat, lon float64) (*PointsProperties, error) {
+	return w.GetPointsContext(context.Background(), lat, lon)
+}
+
+// GetPointsContext is like GetPoints but uses ctx for the API requests
+func (w *WeatherClient) GetPointsContext(ctx context.Context, lat, lon float64) (*PointsProperties, error) {
+	if w.PointsCache != nil {
+		if points, ok := w.PointsCache.Get(lat, lon); ok {
+			return points, nil
+		}
+	}
+
+	return w.RefreshPointsContext(ctx, lat, lon)
+}
+
+// RefreshPoints looks up the grid metadata for given latitude and longitude from the API,
+// bypassing and then updating the points cache
+func (w *WeatherClient) RefreshPoints(lat, lon float64) (*PointsProperties, error) {
+	return w.RefreshPointsContext(context.Background(), lat, lon)
+}
+
+// RefreshPointsContext is like RefreshPoints but uses ctx for the API requests
+func (w *WeatherClient) RefreshPointsContext(ctx context.Context, lat, lon float64) (*PointsProperties, error) {
+	pointsURL := fmt.Sprintf("%s/points/%.4f,%.4f", w.BaseURL, lat, lon)
+
 	var pointsResp PointsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&pointsResp); err != nil {
-		return nil, fmt.Errorf("failed to decode points response: %w", err)
+	if err := w.getJSON(ctx, pointsURL, &pointsResp); err != nil {
+		return nil, fmt.Errorf("failed to get points data: %w", err)
 	}
 
-	// Now get the forecast using the forecast URL from the points response
-	forecastReq, err := http.NewRequest("GET", pointsResp.Properties.Forecast, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create forecast request: %w", err)
+	if w.PointsCache != nil {
+		w.PointsCache.Set(lat, lon, &pointsResp.Properties)
 	}
 
-	forecastReq.Header.Set("User-Agent", "weather-app/1.0 (your-email@example.com)")
+	return &pointsResp.Properties, nil
+}
 
-	forecastResp, err := w.HTTPClient.Do(forecastReq)
+// getFromPoints fetches a URL taken from the points metadata of the coordinates. If the URL
+// returns 404 the cached metadata is stale: it is looked up again and the request retried once.
+// If the URL redirects, the cached metadata is dropped so the next call looks it up again.
+func (w *WeatherClient) getFromPoints(ctx context.Context, lat, lon float64, resource func(*PointsProperties) string, v interface{}) error {
+	points, err := w.GetPointsContext(ctx, lat, lon)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get forecast data: %w", err)
+		return err
 	}
-	defer forecastResp.Body.Close()
 
-	if forecastResp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(forecastResp.Body)
-		return nil, fmt.Errorf("forecast API error: %s - %s", forecastResp.Status, string(body))
+	redirected, err := w.fetchJSON(ctx, resource(points), v)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && w.PointsCache != nil {
+		if points, err = w.RefreshPointsContext(ctx, lat, lon); err != nil {
+			return err
+		}
+		redirected, err = w.fetchJSON(ctx, resource(points), v)
+	}
+
+	if redirected && w.PointsCache != nil {
+		w.PointsCache.Delete(lat, lon)
 	}
 
+	return err
+}
+
+// GetForecastByCoordinates gets weather forecast for given latitude and longitude
+func (w *WeatherClient) GetForecastByCoordinates(lat, lon float64) (*ForecastResponse, error) {
+	return w.GetForecastByCoordinatesContext(context.Background(), lat, lon)
+}
+
+// GetForecastByCoordinatesContext is like GetForecastByCoordinates but uses ctx for the API requests
+func (w *WeatherClient) GetForecastByCoordinatesContext(ctx context.Context, lat, lon float64) (*ForecastResponse, error) {
+	// Get the forecast using the forecast URL from the points metadata for the coordinates
 	var forecast ForecastResponse
-	if err := json.NewDecoder(forecastResp.Body).Decode(&forecast); err != nil {
-		return nil, fmt.Errorf("failed to decode forecast response: %w", err)
+	if err := w.getFromPoints(ctx, lat, lon, func(p *PointsProperties) string { return w.withUnits(p.Forecast) }, &forecast); err != nil {
+		return nil, fmt.Errorf("failed to get forecast data: %w", err)
 	}
+	forecast.Provider = ProviderNWS
 
 	return &forecast, nil
 }
@@ -120,61 +216,39 @@ func (w *WeatherClient) GetForecastByCoordinates(lat, lon float64) (*ForecastRes
 // GetHourlyForecastByCoordinates gets hourly weather forecast for given latitude and longitude
 // This can provide up to 156 hours (6.5 days) of hourly forecast data
 func (w *WeatherClient) GetHourlyForecastByCoordinates(lat, lon float64) (*ForecastResponse, error) {
-	// First, get the grid information for the coordinates
-	pointsURL := fmt.Sprintf("%s/points/%.4f,%.4f", w.BaseURL, lat, lon)
-	
-	req, err := http.NewRequest("GET", pointsURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	
-	req.Header.Set("User-Agent", "weather-app/1.0 (your-email@example.com)")
-	
-	resp, err := w.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get points data: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("NWS API error: %s - %s", resp.Status, string(body))
-	}
-	
-	var pointsResp PointsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&pointsResp); err != nil {
-		return nil, fmt.Errorf("failed to decode points response: %w", err)
-	}
-	
+	return w.GetHourlyForecastByCoordinatesContext(context.Background(), lat, lon)
+}
+
+// GetHourlyForecastByCoordinatesContext is like GetHourlyForecastByCoordinates but uses ctx for the API requests
+func (w *WeatherClient) GetHourlyForecastByCoordinatesContext(ctx context.Context, lat, lon float64) (*ForecastResponse, error) {
 	// Use the hourly forecast URL instead of the regular forecast URL
-	forecastReq, err := http.NewRequest("GET", pointsResp.Properties.ForecastHourly, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create forecast request: %w", err)
-	}
-	
-	forecastReq.Header.Set("User-Agent", "weather-app/1.0 (your-email@example.com)")
-	
-	forecastResp, err := w.HTTPClient.Do(forecastReq)
-	if err != nil {
+	var forecast ForecastResponse
+	if err := w.getFromPoints(ctx, lat, lon, func(p *PointsProperties) string { return w.withUnits(p.ForecastHourly) }, &forecast); err != nil {
 		return nil, fmt.Errorf("failed to get forecast data: %w", err)
 	}
-	defer forecastResp.Body.Close()
-	
-	if forecastResp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(forecastResp.Body)
-		return nil, fmt.Errorf("forecast API error: %s - %s", forecastResp.Status, string(body))
+	forecast.Provider = ProviderNWS
+
+	return &forecast, nil
+}
+
+// withUnits adds the units query parameter to a forecast URL when the client requests a unit system
+func (w *WeatherClient) withUnits(forecastURL string) string {
+	if w.Units == "" {
+		return forecastURL
 	}
-	
-	var forecast ForecastResponse
-	if err := json.NewDecoder(forecastResp.Body).Decode(&forecast); err != nil {
-		return nil, fmt.Errorf("failed to decode forecast response: %w", err)
+
+	u, err := url.Parse(forecastURL)
+	if err != nil {
+		return forecastURL
 	}
-	
-	return &forecast, nil
+	query := u.Query()
+	query.Set("units", w.Units.NWSUnits())
+	u.RawQuery = query.Encode()
+	return u.String()
 }
 
-// FormatForecast returns a formatted string representation of the forecast
-func (f *ForecastResponse) FormatForecast(periods int) string {
+// FormatForecast returns a formatted string representation of the forecast in the given units
+func (f *ForecastResponse) FormatForecast(periods int, units UnitSystem) string {
 	if periods <= 0 || periods > len(f.Properties.Periods) {
 		periods = len(f.Properties.Periods)
 	}
@@ -185,12 +259,22 @@ func (f *ForecastResponse) FormatForecast(periods int) string {
 	for i := 0; i < periods && i < len(f.Properties.Periods); i++ {
 		period := f.Properties.Periods[i]
 		result += fmt.Sprintf("📅 %s\n", period.Name)
-		result += fmt.Sprintf("🌡️  Temperature: %d°%s", period.Temperature, period.TemperatureUnit)
+		result += fmt.Sprintf("🌡️  Temperature: %s", units.FormatForecastTemperature(period.Temperature, period.TemperatureUnit))
 		if period.TemperatureTrend != "" {
 			result += fmt.Sprintf(" (%s)", period.TemperatureTrend)
 		}
 		result += "\n"
-		result += fmt.Sprintf("💨 Wind: %s %s\n", period.WindSpeed, period.WindDirection)
+		result += fmt.Sprintf("💨 Wind: %s %s\n", units.FormatWindSpeed(period.WindSpeed), period.WindDirection)
+		if period.ProbabilityOfPrecipitation.Value != nil {
+			result += fmt.Sprintf("☔ Chance of precipitation: %.0f%%\n", *period.ProbabilityOfPrecipitation.Value)
+		}
+		if period.Dewpoint.Value != nil || period.RelativeHumidity.Value != nil {
+			humidity := "n/a"
+			if period.RelativeHumidity.Value != nil {
+				humidity = fmt.Sprintf("%.0f%%", *period.RelativeHumidity.Value)
+			}
+			result += fmt.Sprintf("💧 Dewpoint: %s, humidity: %s\n", units.FormatQuantity(period.Dewpoint), humidity)
+		}
 		result += fmt.Sprintf("☁️  Conditions: %s\n", period.ShortForecast)
 		if period.DetailedForecast != "" {
 			result += fmt.Sprintf("📝 Details: %s\n", period.DetailedForecast)
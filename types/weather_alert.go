@@ -0,0 +1,244 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/dwburke/weather/db"
+)
+
+// WeatherAlert represents an NWS alert stored in the database. Each alert ID is stored once;
+// later updates and cancellations that reference it mark it as superseded or cancelled.
+type WeatherAlert struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Alert identity
+	AlertID     string `json:"alert_id" gorm:"column:alert_id;not null;unique_index"`
+	MessageType string `json:"message_type" gorm:"column:message_type"`
+	Status      string `json:"status" gorm:"column:status"`
+	References  string `json:"references" gorm:"column:reference_ids;type:text"` // Comma separated IDs of alerts this one updates or cancels
+
+	// Alert details
+	Event       string `json:"event" gorm:"column:event;index"`
+	Severity    string `json:"severity" gorm:"column:severity;index"`
+	Urgency     string `json:"urgency" gorm:"column:urgency"`
+	Certainty   string `json:"certainty" gorm:"column:certainty"`
+	Headline    string `json:"headline" gorm:"column:headline;type:text"`
+	Description string `json:"description" gorm:"column:description;type:text"`
+	Instruction string `json:"instruction" gorm:"column:instruction;type:text"`
+	SenderName  string `json:"sender_name" gorm:"column:sender_name"`
+
+	// Affected area
+	AreaDesc      string `json:"area_desc" gorm:"column:area_desc;type:text"`
+	AffectedZones string `json:"affected_zones" gorm:"column:affected_zones;type:text"` // Comma separated zone IDs
+	Polygon       string `json:"polygon" gorm:"column:polygon;type:text"`               // JSON array of [longitude, latitude] pairs
+
+	// Alert times
+	Sent      *time.Time `json:"sent" gorm:"column:sent"`
+	Effective *time.Time `json:"effective" gorm:"column:effective"`
+	Onset     *time.Time `json:"onset" gorm:"column:onset"`
+	Expires   *time.Time `json:"expires" gorm:"column:expires;index"`
+	Ends      *time.Time `json:"ends" gorm:"column:ends"`
+
+	// Lifecycle
+	SupersededBy string    `json:"superseded_by" gorm:"column:superseded_by"` // ID of the alert that updated or cancelled this one
+	Cancelled    bool      `json:"cancelled" gorm:"column:cancelled"`
+	FirstSeenAt  time.Time `json:"first_seen_at" gorm:"column:first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at" gorm:"column:last_seen_at;index"`
+}
+
+func (WeatherAlert) TableName() string {
+	return "weather_alerts"
+}
+
+// AlertSaveResult reports how saving alerts changed the database
+type AlertSaveResult struct {
+	Inserted   int // Alerts that were not stored yet
+	Updated    int // Stored alerts that were seen again
+	Superseded int // Earlier alerts marked as superseded by an update or cancellation
+}
+
+// String summarizes the result, e.g. "1 new alerts saved, 2 existing alerts updated, 0 earlier alerts superseded"
+func (r *AlertSaveResult) String() string {
+	return fmt.Sprintf("%d new alerts saved, %d existing alerts updated, %d earlier alerts superseded",
+		r.Inserted, r.Updated, r.Superseded)
+}
+
+// SaveAlertsToDB saves alerts to the database, deduplicating by alert ID. Alerts referenced by
+// an update or cancellation are marked as superseded (and cancelled for cancellations).
+func SaveAlertsToDB(alerts []Alert) (*AlertSaveResult, error) {
+	return SaveAlertsToDBContext(context.Background(), alerts)
+}
+
+// SaveAlertsToDBContext is like SaveAlertsToDB but stops between alerts once ctx is done
+func SaveAlertsToDBContext(ctx context.Context, alerts []Alert) (*AlertSaveResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &AlertSaveResult{}
+
+	for _, alert := range alerts {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		record := NewWeatherAlert(&alert)
+		record.LastSeenAt = now
+
+		var existing WeatherAlert
+		err := gdbh.Where("alert_id = ?", alert.ID).First(&existing).Error
+		switch {
+		case gorm.IsRecordNotFoundError(err):
+			record.FirstSeenAt = now
+
+			// An update or cancellation for this alert may have been stored before the alert itself
+			newer, err := findReferencingAlert(gdbh, alert.ID)
+			if err != nil {
+				return result, err
+			}
+			if newer != nil {
+				record.SupersededBy = newer.AlertID
+				record.Cancelled = newer.MessageType == "Cancel"
+			}
+
+			if err := gdbh.Create(&record).Error; err != nil {
+				return result, err
+			}
+			result.Inserted++
+		case err != nil:
+			return result, err
+		default:
+			record.ID = existing.ID
+			record.CreatedAt = existing.CreatedAt
+			record.FirstSeenAt = existing.FirstSeenAt
+			record.SupersededBy = existing.SupersededBy
+			record.Cancelled = existing.Cancelled
+			if err := gdbh.Save(&record).Error; err != nil {
+				return result, err
+			}
+			result.Updated++
+		}
+
+		// Follow the references chain to mark the alerts this one replaces
+		for _, ref := range alert.References {
+			updated := gdbh.Model(&WeatherAlert{}).
+				Where("alert_id = ? AND superseded_by = ?", ref.Identifier, "").
+				Updates(map[string]interface{}{
+					"superseded_by": alert.ID,
+					"cancelled":     alert.MessageType == "Cancel",
+				})
+			if updated.Error != nil {
+				return result, updated.Error
+			}
+			result.Superseded += int(updated.RowsAffected)
+		}
+
+		// A cancellation is not itself an active alert
+		if alert.MessageType == "Cancel" {
+			if err := gdbh.Model(&WeatherAlert{}).Where("alert_id = ?", alert.ID).
+				Update("cancelled", true).Error; err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// findReferencingAlert returns the newest stored alert that references the alert ID, or nil if
+// there is none. LIKE only narrows the candidates down; the comma separated IDs are compared
+// exactly so an ID that merely contains this one does not match.
+func findReferencingAlert(gdbh *gorm.DB, alertID string) (*WeatherAlert, error) {
+	var candidates []WeatherAlert
+	if err := gdbh.Where("reference_ids LIKE ?", "%"+alertID+"%").Order("sent DESC").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range candidates {
+		for _, ref := range strings.Split(candidates[i].References, ",") {
+			if ref == alertID {
+				return &candidates[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// GetActiveStoredAlerts retrieves stored alerts that have not expired, been superseded or been cancelled
+func GetActiveStoredAlerts() ([]WeatherAlert, error) {
+	return GetActiveStoredAlertsContext(context.Background())
+}
+
+// GetActiveStoredAlertsContext is like GetActiveStoredAlerts but returns early once ctx is done
+func GetActiveStoredAlertsContext(ctx context.Context) ([]WeatherAlert, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []WeatherAlert
+
+	if err := gdbh.Where("superseded_by = ? AND cancelled = ? AND (expires IS NULL OR expires > ?)", "", false, time.Now()).
+		Order("sent DESC").
+		Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// NewWeatherAlert converts an API alert into the record stored for it, without saving it
+func NewWeatherAlert(alert *Alert) WeatherAlert {
+	references := make([]string, 0, len(alert.References))
+	for _, ref := range alert.References {
+		references = append(references, ref.Identifier)
+	}
+
+	var polygon string
+	if len(alert.Polygon) > 0 {
+		if encoded, err := json.Marshal(alert.Polygon); err == nil {
+			polygon = string(encoded)
+		}
+	}
+
+	return WeatherAlert{
+		AlertID:       alert.ID,
+		MessageType:   alert.MessageType,
+		Status:        alert.Status,
+		References:    strings.Join(references, ","),
+		Event:         alert.Event,
+		Severity:      alert.Severity,
+		Urgency:       alert.Urgency,
+		Certainty:     alert.Certainty,
+		Headline:      alert.Headline,
+		Description:   alert.Description,
+		Instruction:   alert.Instruction,
+		SenderName:    alert.SenderName,
+		AreaDesc:      alert.AreaDesc,
+		AffectedZones: strings.Join(alert.ZoneIDs(), ","),
+		Polygon:       polygon,
+		Sent:          parseNWSTime(alert.Sent),
+		Effective:     parseNWSTime(alert.Effective),
+		Onset:         parseNWSTime(alert.Onset),
+		Expires:       parseNWSTime(alert.Expires),
+		Ends:          parseNWSTime(alert.Ends),
+	}
+}
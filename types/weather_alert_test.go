@@ -0,0 +1,184 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dwburke/weather/db"
+)
+
+// alertAt returns an alert sent at sent that references refs
+func alertAt(id, messageType string, sent time.Time, refs ...string) Alert {
+	alert := Alert{
+		ID:          id,
+		MessageType: messageType,
+		Event:       "Winter Storm Warning",
+		Sent:        sent.Format(time.RFC3339),
+		Expires:     sent.Add(24 * time.Hour).Format(time.RFC3339),
+	}
+	for _, ref := range refs {
+		alert.References = append(alert.References, AlertReference{Identifier: ref})
+	}
+	return alert
+}
+
+func TestFindReferencingAlert(t *testing.T) {
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := time.Date(2026, 10, 16, 6, 0, 0, 0, time.UTC)
+	for _, alert := range []Alert{
+		alertAt("urn:find.2", "Update", sent, "urn:find.10"),
+		alertAt("urn:find.3", "Update", sent.Add(time.Hour), "urn:find.1x", "urn:find.11"),
+		alertAt("urn:find.4", "Update", sent.Add(2*time.Hour), "urn:find.5", "urn:find.11"),
+		alertAt("urn:find.6", "Cancel", sent.Add(3*time.Hour), "urn:find.5"),
+	} {
+		record := NewWeatherAlert(&alert)
+		if err := gdbh.Create(&record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		alertID string
+		want    string
+	}{
+		{name: "only a longer ID contains it", alertID: "urn:find.1", want: ""},
+		{name: "exact reference", alertID: "urn:find.10", want: "urn:find.2"},
+		{name: "last of several references", alertID: "urn:find.11", want: "urn:find.4"},
+		{name: "newest of several alerts", alertID: "urn:find.5", want: "urn:find.6"},
+		{name: "not referenced", alertID: "urn:find.7", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newer, err := findReferencingAlert(gdbh, tt.alertID)
+			if err != nil {
+				t.Fatalf("findReferencingAlert() error = %v", err)
+			}
+			got := ""
+			if newer != nil {
+				got = newer.AlertID
+			}
+			if got != tt.want {
+				t.Errorf("findReferencingAlert(%q) = %q, want %q", tt.alertID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveAlerts(t *testing.T) {
+	sent := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	steps := []struct {
+		name   string
+		alerts []Alert
+		want   AlertSaveResult
+	}{
+		{
+			name:   "new alert",
+			alerts: []Alert{alertAt("urn:save.1", "Alert", sent)},
+			want:   AlertSaveResult{Inserted: 1},
+		},
+		{
+			name:   "seen again",
+			alerts: []Alert{alertAt("urn:save.1", "Alert", sent)},
+			want:   AlertSaveResult{Updated: 1},
+		},
+		{
+			name:   "update supersedes it",
+			alerts: []Alert{alertAt("urn:save.2", "Update", sent.Add(time.Minute), "urn:save.1")},
+			want:   AlertSaveResult{Inserted: 1, Superseded: 1},
+		},
+		{
+			name:   "cancellation before the alert it cancels",
+			alerts: []Alert{alertAt("urn:save.4", "Cancel", sent.Add(2*time.Minute), "urn:save.3")},
+			want:   AlertSaveResult{Inserted: 1},
+		},
+		{
+			name:   "cancelled alert arrives late",
+			alerts: []Alert{alertAt("urn:save.3", "Alert", sent)},
+			want:   AlertSaveResult{Inserted: 1},
+		},
+	}
+
+	for _, step := range steps {
+		result, err := SaveAlertsToDB(step.alerts)
+		if err != nil {
+			t.Fatalf("%s: SaveAlertsToDB() error = %v", step.name, err)
+		}
+		if *result != step.want {
+			t.Errorf("%s: SaveAlertsToDB() = %s, want %s", step.name, result, &step.want)
+		}
+	}
+
+	active, err := GetActiveStoredAlerts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	activeIDs := make(map[string]bool)
+	for _, alert := range active {
+		activeIDs[alert.AlertID] = true
+	}
+
+	for id, wantActive := range map[string]bool{
+		"urn:save.1": false, // Superseded by the update
+		"urn:save.2": true,
+		"urn:save.3": false, // Cancelled before it was stored
+		"urn:save.4": false, // A cancellation is not an active alert
+	} {
+		if activeIDs[id] != wantActive {
+			t.Errorf("%s active = %v, want %v", id, activeIDs[id], wantActive)
+		}
+	}
+
+	gdbh, _ := db.GetDB().DB()
+	var late WeatherAlert
+	if err := gdbh.Where("alert_id = ?", "urn:save.3").First(&late).Error; err != nil {
+		t.Fatal(err)
+	}
+	if late.SupersededBy != "urn:save.4" || !late.Cancelled {
+		t.Errorf("late alert superseded by %q, cancelled %v, want urn:save.4 and cancelled", late.SupersededBy, late.Cancelled)
+	}
+}
+
+func TestAlertCollectionAlerts(t *testing.T) {
+	var collection AlertCollection
+	if err := json.Unmarshal([]byte(`{"features": [
+		{
+			"geometry": {"type": "Polygon", "coordinates": [[[-105.1, 39.9], [-105.0, 39.9], [-105.0, 40.0], [-105.1, 39.9]]]},
+			"properties": {"id": "urn:polygon", "affectedZones": ["https://api.weather.gov/zones/forecast/COZ039"]}
+		},
+		{
+			"geometry": null,
+			"properties": {"id": "urn:zones", "affectedZones": ["https://api.weather.gov/zones/forecast/COZ040", "https://api.weather.gov/zones/county/COC013"]}
+		}
+	]}`), &collection); err != nil {
+		t.Fatal(err)
+	}
+
+	alerts := collection.Alerts()
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2", len(alerts))
+	}
+	if len(alerts[0].Polygon) != 4 || alerts[0].Polygon[1] != [2]float64{-105.0, 39.9} {
+		t.Errorf("polygon = %v, want the outer ring of the geometry", alerts[0].Polygon)
+	}
+	if alerts[1].Polygon != nil {
+		t.Errorf("alert without geometry has polygon %v", alerts[1].Polygon)
+	}
+
+	zones := alerts[1].ZoneIDs()
+	if len(zones) != 2 || zones[0] != "COZ040" || zones[1] != "COC013" {
+		t.Errorf("ZoneIDs() = %v, want [COZ040 COC013]", zones)
+	}
+
+	record := NewWeatherAlert(&alerts[1])
+	if record.AffectedZones != "COZ040,COC013" {
+		t.Errorf("stored zones = %q, want COZ040,COC013", record.AffectedZones)
+	}
+}
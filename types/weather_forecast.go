@@ -1,9 +1,14 @@
 package types
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
-	
+
+	"github.com/jinzhu/gorm"
+
 	"github.com/dwburke/weather/db"
 )
 
@@ -14,8 +19,10 @@ type WeatherForecast struct {
 	UpdatedAt        time.Time `json:"updated_at"`
 	
 	// Location information
+	LocationID       uint      `json:"location_id" gorm:"column:location_id;index"` // Named location, 0 for bare coordinates
 	Latitude         float64   `json:"latitude" gorm:"column:latitude;not null"`
 	Longitude        float64   `json:"longitude" gorm:"column:longitude;not null"`
+	PlaceName        string    `json:"place_name" gorm:"column:place_name"` // Geocoded place the coordinates were resolved from
 	
 	// Forecast period information
 	PeriodNumber     int       `json:"period_number" gorm:"column:period_number;not null"`
@@ -33,8 +40,23 @@ type WeatherForecast struct {
 	Icon             string    `json:"icon" gorm:"column:icon"`
 	ShortForecast    string    `json:"short_forecast" gorm:"column:short_forecast"`
 	DetailedForecast string    `json:"detailed_forecast" gorm:"column:detailed_forecast;type:text"`
+
+	// Numeric values parsed from the text fields above, nil when they could not be parsed
+	TemperatureCelsius    *float64 `json:"temperature_c" gorm:"column:temperature_c"`
+	TemperatureFahrenheit *float64 `json:"temperature_f" gorm:"column:temperature_f"`
+	WindSpeedMinKmh       *float64 `json:"wind_speed_min_kmh" gorm:"column:wind_speed_min_kmh"`
+	WindSpeedMaxKmh       *float64 `json:"wind_speed_max_kmh" gorm:"column:wind_speed_max_kmh"`
+	WindGustKmh           *float64 `json:"wind_gust_kmh" gorm:"column:wind_gust_kmh"`
+	WindDirectionDeg      *float64 `json:"wind_direction_deg" gorm:"column:wind_direction_deg"`
+
+	// Quantitative values of the period, nil when the provider did not forecast them
+	PrecipitationProbability *float64 `json:"precipitation_probability" gorm:"column:precipitation_probability"` // Chance of precipitation in %
+	DewpointC                *float64 `json:"dewpoint_c" gorm:"column:dewpoint_c"`
+	RelativeHumidity         *float64 `json:"relative_humidity" gorm:"column:relative_humidity"` // In %
 	
 	// Metadata
+	Provider         string    `json:"provider" gorm:"column:provider"`                 // Provider the forecast came from, e.g. "nws"
+	RunID            uint      `json:"run_id" gorm:"column:run_id;index"`               // Forecast run this period was fetched in
 	ForecastDate     time.Time `json:"forecast_date" gorm:"column:forecast_date;index"` // When this forecast was retrieved
 	IsHourly         bool      `json:"is_hourly" gorm:"column:is_hourly;index"`         // True for hourly forecasts, false for daily
 }
@@ -71,81 +93,350 @@ func (w *WeatherForecast) Save() error {
 	return nil
 }
 
-// SaveForecastToDB saves a complete forecast response to the database
-// It checks for existing records and only saves new ones or updates existing ones
-func SaveForecastToDB(forecast *ForecastResponse, lat, lon float64, isHourly bool) error {
+// upsertBatchSize is the number of periods or observations written by one INSERT statement
+const upsertBatchSize = 100
+
+// forecastPeriodKey is the unique key of a stored forecast period. forecast_date is the same for
+// every period of a run, but TimescaleDB requires the partitioning column in every unique key.
+var forecastPeriodKey = []string{"run_id", "period_number", "forecast_date"}
+
+// SaveResult reports how saving a forecast changed the database
+type SaveResult struct {
+	RunID     uint // Run the periods were saved under
+	NewRun    bool // False if the forecast's update time matched a run that was already stored
+	Inserted  int  // Periods that were not stored yet
+	Updated   int  // Stored periods whose values changed
+	Unchanged int  // Stored periods that were already up to date
+}
+
+// String summarizes the result, e.g. "forecast run #12: 3 inserted, 1 updated, 10 unchanged"
+func (r *SaveResult) String() string {
+	return fmt.Sprintf("forecast run #%d: %d inserted, %d updated, %d unchanged", r.RunID, r.Inserted, r.Updated, r.Unchanged)
+}
+
+// SaveForecastToDB saves a complete forecast response to the database.
+// Each new NWS update is recorded as a new forecast run with all of its periods stored under that
+// run, so earlier forecasts for the same periods are kept rather than overwritten. Saving the same
+// update again updates that run's periods instead of storing them twice.
+func SaveForecastToDB(forecast *ForecastResponse, lat, lon float64, isHourly bool) (*SaveResult, error) {
+	return SaveForecastToDBContext(context.Background(), forecast, lat, lon, isHourly)
+}
+
+// SaveForecastToDBContext is like SaveForecastToDB but stops saving once ctx is done
+func SaveForecastToDBContext(ctx context.Context, forecast *ForecastResponse, lat, lon float64, isHourly bool) (*SaveResult, error) {
+	return SaveForecastRunContext(ctx, &ForecastRun{Latitude: lat, Longitude: lon, IsHourly: isHourly}, forecast)
+}
+
+// SaveForecastForLocation saves a complete forecast response for a named location
+func SaveForecastForLocation(forecast *ForecastResponse, loc *Location, isHourly bool) (*SaveResult, error) {
+	return SaveForecastForLocationContext(context.Background(), forecast, loc, isHourly)
+}
+
+// SaveForecastForLocationContext is like SaveForecastForLocation but stops saving once ctx is done
+func SaveForecastForLocationContext(ctx context.Context, forecast *ForecastResponse, loc *Location, isHourly bool) (*SaveResult, error) {
+	return SaveForecastRunContext(ctx, &ForecastRun{
+		LocationID: loc.ID,
+		Latitude:   loc.Latitude,
+		Longitude:  loc.Longitude,
+		IsHourly:   isHourly,
+	}, forecast)
+}
+
+// SaveForecastRun saves a forecast response as a run. The run's location and forecast type must
+// be set by the caller; issuance times, retrieval time and period count are filled in here. If a
+// run with the same location, type, provider and update time is already stored, the periods are
+// saved under that run and run is replaced by it.
+func SaveForecastRun(run *ForecastRun, forecast *ForecastResponse) (*SaveResult, error) {
+	return SaveForecastRunContext(context.Background(), run, forecast)
+}
+
+// SaveForecastRunContext is like SaveForecastRun but checks ctx between batches. The run and its
+// periods are written in one transaction, so a cancelled save leaves no partial run behind.
+func SaveForecastRunContext(ctx context.Context, run *ForecastRun, forecast *ForecastResponse) (*SaveResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	gdbh, err := db.GetDB().DB()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Auto-migrate the table if it doesn't exist
-	gdbh.AutoMigrate(&WeatherForecast{})
+	run.Provider = forecast.Provider
+	if run.Provider == "" {
+		run.Provider = ProviderNWS
+	}
+	run.GeneratedAt = parseNWSTime(forecast.Properties.GeneratedAt)
+	run.UpdateTime = parseNWSTime(forecast.Properties.UpdateTime)
+	run.RetrievedAt = time.Now()
+	run.PeriodCount = len(forecast.Properties.Periods)
 
-	forecastDate := time.Now()
-	var savedCount, updatedCount int
-	
-	// Process each forecast period
+	requested := *run
+	result, err := saveForecastRun(ctx, gdbh, run, forecast)
+	if err != nil && result != nil && result.NewRun && run.UpdateTime != nil && ctx.Err() == nil {
+		// A concurrent save of the same update may have created the run first, failing the
+		// unique key on forecast runs. Saving again finds and reuses that run.
+		*run = requested
+		result, err = saveForecastRun(ctx, gdbh, run, forecast)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// saveForecastRun finds or creates the run and upserts its periods in one transaction. The result
+// is returned even on failure so the caller can tell whether a new run was being created.
+func saveForecastRun(ctx context.Context, gdbh *gorm.DB, run *ForecastRun, forecast *ForecastResponse) (*SaveResult, error) {
+	result := &SaveResult{}
+
+	tx := gdbh.Begin()
+	if tx.Error != nil {
+		return result, tx.Error
+	}
+
+	existing, err := findForecastRun(tx, run)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	if existing != nil {
+		*run = *existing
+	} else {
+		result.NewRun = true
+		if err := tx.Create(run).Error; err != nil {
+			tx.Rollback()
+			return result, err
+		}
+	}
+	result.RunID = run.ID
+
+	weatherForecasts, err := ForecastPeriods(run, forecast)
+	if err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	stored := make(map[int]*WeatherForecast)
+	if existing != nil {
+		var storedForecasts []WeatherForecast
+		if err := tx.Where("run_id = ?", run.ID).Find(&storedForecasts).Error; err != nil {
+			tx.Rollback()
+			return result, err
+		}
+		for i := range storedForecasts {
+			stored[storedForecasts[i].PeriodNumber] = &storedForecasts[i]
+		}
+	}
+
+	changed := make([]WeatherForecast, 0, len(weatherForecasts))
+	for i := range weatherForecasts {
+		previous, ok := stored[weatherForecasts[i].PeriodNumber]
+		switch {
+		case !ok:
+			result.Inserted++
+		case sameForecastValues(tx, previous, &weatherForecasts[i]):
+			result.Unchanged++
+			continue
+		default:
+			weatherForecasts[i].CreatedAt = previous.CreatedAt
+			result.Updated++
+		}
+		changed = append(changed, weatherForecasts[i])
+	}
+
+	for start := 0; start < len(changed); start += upsertBatchSize {
+		if err := ctx.Err(); err != nil {
+			tx.Rollback()
+			return result, err
+		}
+
+		end := min(start+upsertBatchSize, len(changed))
+		if err := upsertForecasts(tx, changed[start:end]); err != nil {
+			tx.Rollback()
+			return result, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// findForecastRun returns the stored run for the same location, type, provider and update time
+// as run, or nil if there is none or the forecast has no update time
+func findForecastRun(tx *gorm.DB, run *ForecastRun) (*ForecastRun, error) {
+	if run.UpdateTime == nil {
+		return nil, nil
+	}
+
+	var runs []ForecastRun
+	if err := tx.Where("location_id = ? AND latitude = ? AND longitude = ? AND is_hourly = ? AND provider = ? AND update_time = ?",
+		run.LocationID, run.Latitude, run.Longitude, run.IsHourly, run.Provider, *run.UpdateTime).
+		Limit(1).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return &runs[0], nil
+}
+
+// upsertForecasts writes forecast periods with one multi-row INSERT, updating periods that are
+// already stored under the same run
+func upsertForecasts(tx *gorm.DB, forecasts []WeatherForecast) error {
+	if len(forecasts) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	scope := tx.NewScope(&WeatherForecast{})
+
+	var columns, quoted, updates []string
+	rows := make([][]interface{}, 0, len(forecasts))
+	for i := range forecasts {
+		forecast := &forecasts[i]
+		if forecast.CreatedAt.IsZero() {
+			forecast.CreatedAt = now
+		}
+		forecast.UpdatedAt = now
+
+		var row []interface{}
+		for _, field := range forecastFields(tx, forecast) {
+			if i == 0 {
+				columns = append(columns, field.DBName)
+				quoted = append(quoted, scope.Quote(field.DBName))
+				if field.DBName != "created_at" && !isForecastKey(field.DBName) {
+					updates = append(updates, field.DBName)
+				}
+			}
+			row = append(row, field.Field.Interface())
+		}
+		rows = append(rows, row)
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES ? %s", scope.QuotedTableName(), strings.Join(quoted, ", "),
+		db.UpsertClause(scope.Quote, forecastPeriodKey, updates))
+
+	return tx.Exec(sql, rows).Error
+}
+
+// forecastFields returns the stored columns of a forecast period, without its ID
+func forecastFields(tx *gorm.DB, forecast *WeatherForecast) []*gorm.Field {
+	var fields []*gorm.Field
+	for _, field := range tx.NewScope(forecast).Fields() {
+		if field.IsNormal && !field.IsIgnored && !field.IsPrimaryKey {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func isForecastKey(column string) bool {
+	for _, key := range forecastPeriodKey {
+		if column == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sameForecastValues reports whether two forecast periods store the same values, ignoring their
+// IDs and timestamps. Times are compared as instants, as the database may return another zone.
+func sameForecastValues(tx *gorm.DB, a, b *WeatherForecast) bool {
+	aFields, bFields := forecastFields(tx, a), forecastFields(tx, b)
+	for i := range aFields {
+		if aFields[i].DBName == "created_at" || aFields[i].DBName == "updated_at" {
+			continue
+		}
+
+		av, bv := aFields[i].Field.Interface(), bFields[i].Field.Interface()
+		if at, ok := av.(time.Time); ok {
+			if !at.Equal(bv.(time.Time)) {
+				return false
+			}
+			continue
+		}
+		if at, ok := av.(*time.Time); ok {
+			bt := bv.(*time.Time)
+			if (at == nil) != (bt == nil) || (at != nil && !at.Equal(*bt)) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(av, bv) {
+			return false
+		}
+	}
+	return true
+}
+
+// ForecastPeriods converts the periods of a forecast response into the records stored for a run,
+// without saving them. The records take their location, provider and times from the run.
+func ForecastPeriods(run *ForecastRun, forecast *ForecastResponse) ([]WeatherForecast, error) {
+	lat, lon, isHourly := run.Latitude, run.Longitude, run.IsHourly
+
+	weatherForecasts := make([]WeatherForecast, 0, len(forecast.Properties.Periods))
 	for _, period := range forecast.Properties.Periods {
 		startTime, err := time.Parse(time.RFC3339, period.StartTime)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		
 		endTime, err := time.Parse(time.RFC3339, period.EndTime)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		
-		// Check if a record already exists for this location, period number, start time, and forecast type
-		// We use start_time and is_hourly as unique identifiers since period numbers can be reused
-		var existingForecast WeatherForecast
-		result := gdbh.Where("latitude = ? AND longitude = ? AND period_number = ? AND start_time = ? AND is_hourly = ?", 
-			lat, lon, period.Number, startTime, isHourly).First(&existingForecast)
-		
-		weatherForecast := WeatherForecast{
-			Latitude:         lat,
-			Longitude:        lon,
-			PeriodNumber:     period.Number,
-			Name:             period.Name,
-			StartTime:        startTime,
-			EndTime:          endTime,
-			IsDaytime:        period.IsDaytime,
-			Temperature:      period.Temperature,
-			TemperatureUnit:  period.TemperatureUnit,
-			TemperatureTrend: period.TemperatureTrend,
-			WindSpeed:        period.WindSpeed,
-			WindDirection:    period.WindDirection,
-			Icon:             period.Icon,
-			ShortForecast:    period.ShortForecast,
-			DetailedForecast: period.DetailedForecast,
-			ForecastDate:     forecastDate,
-			IsHourly:         isHourly,
-		}
-		
-		if result.Error != nil {
-			// Record doesn't exist, create new one
-			if err := weatherForecast.Create(); err != nil {
-				return err
-			}
-			savedCount++
-		} else {
-			// Record exists, update it with new forecast data
-			weatherForecast.ID = existingForecast.ID
-			weatherForecast.CreatedAt = existingForecast.CreatedAt // Preserve original creation time
-			if err := weatherForecast.Save(); err != nil {
-				return err
-			}
-			updatedCount++
-		}
+		weatherForecasts = append(weatherForecasts, WeatherForecast{
+			LocationID:               run.LocationID,
+			Latitude:                 lat,
+			Longitude:                lon,
+			PlaceName:                run.PlaceName,
+			PeriodNumber:             period.Number,
+			Name:                     period.Name,
+			StartTime:                startTime,
+			EndTime:                  endTime,
+			IsDaytime:                period.IsDaytime,
+			Temperature:              period.Temperature,
+			TemperatureUnit:          period.TemperatureUnit,
+			TemperatureTrend:         period.TemperatureTrend,
+			WindSpeed:                period.WindSpeed,
+			WindDirection:            period.WindDirection,
+			Icon:                     period.Icon,
+			ShortForecast:            period.ShortForecast,
+			DetailedForecast:         period.DetailedForecast,
+			PrecipitationProbability: period.ProbabilityOfPrecipitation.Value,
+			DewpointC:                celsiusValue(period.Dewpoint),
+			RelativeHumidity:         period.RelativeHumidity.Value,
+			Provider:                 run.Provider,
+			RunID:                    run.ID,
+			ForecastDate:             run.RetrievedAt,
+			IsHourly:                 isHourly,
+		})
+		weatherForecasts[len(weatherForecasts)-1].SetNumericValues()
 	}
-	
-	fmt.Printf("📊 Database summary: %d new records saved, %d existing records updated\n", savedCount, updatedCount)
-	return nil
+
+	return weatherForecasts, nil
 }
 
-// GetLatestForecast retrieves the most recent forecast for given coordinates and type
+// GetLatestForecast retrieves the periods of the most recent forecast run for given coordinates and type
 func GetLatestForecast(lat, lon float64, limit int, isHourly bool) ([]WeatherForecast, error) {
+	return GetLatestForecastContext(context.Background(), lat, lon, limit, isHourly)
+}
+
+// GetLatestForecastContext is like GetLatestForecast but returns early once ctx is done
+func GetLatestForecastContext(ctx context.Context, lat, lon float64, limit int, isHourly bool) ([]WeatherForecast, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	gdbh, err := db.GetDB().DB()
 	if err != nil {
 		return nil, err
@@ -153,18 +444,29 @@ func GetLatestForecast(lat, lon float64, limit int, isHourly bool) ([]WeatherFor
 
 	var forecasts []WeatherForecast
 	
-	// Get the most recent forecast date for these coordinates and forecast type
-	var latestDate time.Time
-	if err := gdbh.Model(&WeatherForecast{}).
-		Where("latitude = ? AND longitude = ? AND is_hourly = ?", lat, lon, isHourly).
-		Select("MAX(forecast_date)").
-		Row().Scan(&latestDate); err != nil {
+	// Find the newest forecast run for these coordinates and forecast type. Runs are selected by
+	// ID rather than retrieval time, which MySQL stores to the second, so two runs saved in the
+	// same second never merge. Periods saved before runs existed have no run ID and are only
+	// used when there is no run, grouped by their retrieval time.
+	var latest []WeatherForecast
+	if err := gdbh.Where("latitude = ? AND longitude = ? AND is_hourly = ?", lat, lon, isHourly).
+		Order("COALESCE(run_id, 0) DESC, forecast_date DESC").
+		Limit(1).
+		Find(&latest).Error; err != nil {
 		return nil, err
 	}
 	
-	// Get all periods from that forecast date and type
-	query := gdbh.Where("latitude = ? AND longitude = ? AND forecast_date = ? AND is_hourly = ?", lat, lon, latestDate, isHourly).
+	if len(latest) == 0 {
+		return nil, nil
+	}
+	
+	// Get all periods of that run
+	query := gdbh.Where("run_id = ?", latest[0].RunID).
 		Order("period_number ASC")
+	if latest[0].RunID == 0 {
+		query = gdbh.Where("latitude = ? AND longitude = ? AND forecast_date = ? AND is_hourly = ? AND (run_id = 0 OR run_id IS NULL)", lat, lon, latest[0].ForecastDate, isHourly).
+			Order("period_number ASC")
+	}
 	
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -176,3 +478,180 @@ func GetLatestForecast(lat, lon float64, limit int, isHourly bool) ([]WeatherFor
 	
 	return forecasts, nil
 }
+
+// GetLatestForecastForLocation retrieves the periods of the most recent forecast run for a named location and type
+func GetLatestForecastForLocation(locationID uint, limit int, isHourly bool) ([]WeatherForecast, error) {
+	return GetLatestForecastForLocationContext(context.Background(), locationID, limit, isHourly)
+}
+
+// GetLatestForecastForLocationContext is like GetLatestForecastForLocation but returns early once ctx is done
+func GetLatestForecastForLocationContext(ctx context.Context, locationID uint, limit int, isHourly bool) ([]WeatherForecast, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// The newest run has the highest ID, even when two were retrieved in the same second
+	var runs []ForecastRun
+	if err := gdbh.Where("location_id = ? AND is_hourly = ?", locationID, isHourly).
+		Order("id DESC").
+		Limit(1).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	var forecasts []WeatherForecast
+
+	query := gdbh.Where("run_id = ?", runs[0].ID).
+		Order("period_number ASC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&forecasts).Error; err != nil {
+		return nil, err
+	}
+
+	return forecasts, nil
+}
+
+// GetForecastEvolution retrieves every stored forecast for the period covering the target time,
+// oldest run first, so the forecast made several days out can be compared with later ones
+func GetForecastEvolution(lat, lon float64, target time.Time, isHourly bool) ([]WeatherForecast, error) {
+	return GetForecastEvolutionContext(context.Background(), lat, lon, target, isHourly)
+}
+
+// GetForecastEvolutionContext is like GetForecastEvolution but returns early once ctx is done
+func GetForecastEvolutionContext(ctx context.Context, lat, lon float64, target time.Time, isHourly bool) ([]WeatherForecast, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var forecasts []WeatherForecast
+
+	if err := gdbh.Where("latitude = ? AND longitude = ? AND is_hourly = ? AND start_time <= ? AND end_time > ?",
+		lat, lon, isHourly, target, target).
+		Order("forecast_date ASC").
+		Find(&forecasts).Error; err != nil {
+		return nil, err
+	}
+
+	return forecasts, nil
+}
+
+// GetForecastEvolutionForLocation is like GetForecastEvolution but for a named location,
+// matching runs by location ID rather than coordinates
+func GetForecastEvolutionForLocation(locationID uint, target time.Time, isHourly bool) ([]WeatherForecast, error) {
+	return GetForecastEvolutionForLocationContext(context.Background(), locationID, target, isHourly)
+}
+
+// GetForecastEvolutionForLocationContext is like GetForecastEvolutionForLocation but returns early once ctx is done
+func GetForecastEvolutionForLocationContext(ctx context.Context, locationID uint, target time.Time, isHourly bool) ([]WeatherForecast, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var forecasts []WeatherForecast
+
+	if err := gdbh.Where("location_id = ? AND is_hourly = ? AND start_time <= ? AND end_time > ?",
+		locationID, isHourly, target, target).
+		Order("forecast_date ASC").
+		Find(&forecasts).Error; err != nil {
+		return nil, err
+	}
+
+	return forecasts, nil
+}
+
+// LeadTime returns how far ahead of the period start this forecast was retrieved
+func (w *WeatherForecast) LeadTime() time.Duration {
+	return w.StartTime.Sub(w.ForecastDate)
+}
+
+// TemperatureC returns the forecast temperature in °C
+func (w *WeatherForecast) TemperatureC() float64 {
+	if w.TemperatureCelsius != nil {
+		return *w.TemperatureCelsius
+	}
+	if strings.EqualFold(w.TemperatureUnit, "F") {
+		return (float64(w.Temperature) - 32) * 5 / 9
+	}
+	return float64(w.Temperature)
+}
+
+// GetForecastsInRange retrieves the periods from every stored run for given coordinates and type
+// whose start time falls within [from, to)
+func GetForecastsInRange(lat, lon float64, from, to time.Time, isHourly bool) ([]WeatherForecast, error) {
+	return GetForecastsInRangeContext(context.Background(), lat, lon, from, to, isHourly)
+}
+
+// GetForecastsInRangeContext is like GetForecastsInRange but returns early once ctx is done
+func GetForecastsInRangeContext(ctx context.Context, lat, lon float64, from, to time.Time, isHourly bool) ([]WeatherForecast, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var forecasts []WeatherForecast
+
+	if err := gdbh.Where("latitude = ? AND longitude = ? AND is_hourly = ? AND start_time >= ? AND start_time < ?",
+		lat, lon, isHourly, from, to).
+		Order("start_time ASC, forecast_date ASC").
+		Find(&forecasts).Error; err != nil {
+		return nil, err
+	}
+
+	return forecasts, nil
+}
+
+// GetForecastsInRangeForLocation is like GetForecastsInRange but for a named location,
+// matching runs by location ID rather than coordinates
+func GetForecastsInRangeForLocation(locationID uint, from, to time.Time, isHourly bool) ([]WeatherForecast, error) {
+	return GetForecastsInRangeForLocationContext(context.Background(), locationID, from, to, isHourly)
+}
+
+// GetForecastsInRangeForLocationContext is like GetForecastsInRangeForLocation but returns early once ctx is done
+func GetForecastsInRangeForLocationContext(ctx context.Context, locationID uint, from, to time.Time, isHourly bool) ([]WeatherForecast, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	gdbh, err := db.GetDB().DB()
+	if err != nil {
+		return nil, err
+	}
+
+	var forecasts []WeatherForecast
+
+	if err := gdbh.Where("location_id = ? AND is_hourly = ? AND start_time >= ? AND start_time < ?",
+		locationID, isHourly, from, to).
+		Order("start_time ASC, forecast_date ASC").
+		Find(&forecasts).Error; err != nil {
+		return nil, err
+	}
+
+	return forecasts, nil
+}
@@ -0,0 +1,283 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dwburke/weather/db"
+)
+
+func TestSaveForecastRun(t *testing.T) {
+	// Each step saves a forecast for the same coordinates, so later steps see earlier saves
+	steps := []struct {
+		name       string
+		modify     func(*ForecastResponse)
+		wantNewRun bool
+		sameRun    bool // Saved under the run of the previous step
+		want       SaveResult
+	}{
+		{
+			name:       "first save",
+			wantNewRun: true,
+			want:       SaveResult{Inserted: 14},
+		},
+		{
+			name:    "same update again",
+			sameRun: true,
+			want:    SaveResult{Unchanged: 14},
+		},
+		{
+			name: "changed period in same update",
+			modify: func(f *ForecastResponse) {
+				f.Properties.Periods[0].Temperature = 63
+			},
+			sameRun: true,
+			want:    SaveResult{Updated: 1, Unchanged: 13},
+		},
+		{
+			name: "new update",
+			modify: func(f *ForecastResponse) {
+				f.Properties.UpdateTime = "2026-10-17T22:00:00Z"
+			},
+			wantNewRun: true,
+			want:       SaveResult{Inserted: 14},
+		},
+	}
+
+	lat, lon := 39.7456, -97.0892
+	var previous uint
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			forecast := replayForecast(t, false)
+			if step.modify != nil {
+				step.modify(forecast)
+			}
+
+			run := &ForecastRun{Latitude: lat, Longitude: lon}
+			result, err := SaveForecastRun(run, forecast)
+			if err != nil {
+				t.Fatalf("SaveForecastRun() error = %v", err)
+			}
+
+			if result.NewRun != step.wantNewRun {
+				t.Errorf("NewRun = %v, want %v", result.NewRun, step.wantNewRun)
+			}
+			if step.sameRun && result.RunID != previous {
+				t.Errorf("RunID = %d, want previous run %d", result.RunID, previous)
+			}
+			if !step.sameRun && result.RunID == previous {
+				t.Errorf("RunID = %d, want a new run", result.RunID)
+			}
+			if run.ID != result.RunID || run.Provider != ProviderNWS || run.PeriodCount != 14 || run.UpdateTime == nil {
+				t.Errorf("run not filled in: %+v", run)
+			}
+			if result.Inserted != step.want.Inserted || result.Updated != step.want.Updated || result.Unchanged != step.want.Unchanged {
+				t.Errorf("SaveForecastRun() = %s, want %d inserted, %d updated, %d unchanged",
+					result, step.want.Inserted, step.want.Updated, step.want.Unchanged)
+			}
+			previous = result.RunID
+
+			gdbh, err := db.GetDB().DB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var periods []WeatherForecast
+			if err := gdbh.Where("run_id = ?", result.RunID).Order("period_number").Find(&periods).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(periods) != 14 {
+				t.Fatalf("stored %d periods under run %d, want 14", len(periods), result.RunID)
+			}
+			first := periods[0]
+			if want := forecast.Properties.Periods[0].Temperature; first.Temperature != want {
+				t.Errorf("stored temperature = %d, want %d", first.Temperature, want)
+			}
+			if first.WindSpeedMinKmh == nil || first.WindSpeedMaxKmh == nil || first.PrecipitationProbability == nil {
+				t.Errorf("numeric values not stored: %+v", first)
+			}
+		})
+	}
+}
+
+func TestSaveForecastRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	run := &ForecastRun{Latitude: 38.8339, Longitude: -104.8214}
+	if _, err := SaveForecastRunContext(ctx, run, replayForecast(t, true)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SaveForecastRunContext() error = %v, want context.Canceled", err)
+	}
+
+	runs, err := GetForecastRuns(run.Latitude, run.Longitude, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("cancelled save stored %d runs", len(runs))
+	}
+}
+
+func TestForecastQueriesForLocation(t *testing.T) {
+	// Location 901 was saved at its original coordinates and again after being moved; location
+	// 902 shares its original coordinates, so only a location ID query tells the runs apart
+	saves := []struct {
+		locationID uint
+		lat, lon   float64
+		updateTime string
+	}{
+		{locationID: 901, lat: 41.2565, lon: -95.9345, updateTime: "2026-10-17T18:00:00Z"},
+		{locationID: 901, lat: 41.2600, lon: -95.9400, updateTime: "2026-10-17T19:00:00Z"},
+		{locationID: 902, lat: 41.2565, lon: -95.9345, updateTime: "2026-10-17T20:00:00Z"},
+	}
+
+	var start time.Time
+	for _, save := range saves {
+		forecast := replayForecast(t, true)
+		forecast.Properties.UpdateTime = save.updateTime
+		if _, err := SaveForecastRun(&ForecastRun{LocationID: save.locationID, Latitude: save.lat, Longitude: save.lon, IsHourly: true}, forecast); err != nil {
+			t.Fatalf("SaveForecastRun() error = %v", err)
+		}
+
+		var err error
+		if start, err = time.Parse(time.RFC3339, forecast.Properties.Periods[0].StartTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		locationID uint
+		want       int
+	}{
+		{name: "moved location", locationID: 901, want: 2},
+		{name: "location sharing coordinates", locationID: 902, want: 1},
+		{name: "unknown location", locationID: 903, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evolution, err := GetForecastEvolutionForLocation(tt.locationID, start, true)
+			if err != nil {
+				t.Fatalf("GetForecastEvolutionForLocation() error = %v", err)
+			}
+			if len(evolution) != tt.want {
+				t.Errorf("GetForecastEvolutionForLocation() = %d forecasts, want one per run (%d)", len(evolution), tt.want)
+			}
+			for _, forecast := range evolution {
+				if forecast.LocationID != tt.locationID {
+					t.Errorf("GetForecastEvolutionForLocation() returned a forecast for location %d", forecast.LocationID)
+				}
+			}
+
+			inRange, err := GetForecastsInRangeForLocation(tt.locationID, start, start.Add(2*time.Hour), true)
+			if err != nil {
+				t.Fatalf("GetForecastsInRangeForLocation() error = %v", err)
+			}
+			if len(inRange) != 2*tt.want {
+				t.Errorf("GetForecastsInRangeForLocation() = %d forecasts, want two hours from each run (%d)", len(inRange), 2*tt.want)
+			}
+		})
+	}
+
+	// The coordinate query mixes the two locations saved at the same coordinates
+	evolution, err := GetForecastEvolution(41.2565, -95.9345, start, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evolution) != 2 {
+		t.Errorf("GetForecastEvolution() = %d forecasts, want both locations' runs", len(evolution))
+	}
+}
+
+func TestForecastPeriodQuantities(t *testing.T) {
+	start := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	period := func(number int, pop, dewpoint *float64, dewpointUnit string, humidity *float64) ForecastPeriod {
+		return ForecastPeriod{
+			Number:                     number,
+			StartTime:                  start.Add(time.Duration(number) * time.Hour).Format(time.RFC3339),
+			EndTime:                    start.Add(time.Duration(number+1) * time.Hour).Format(time.RFC3339),
+			Temperature:                50,
+			TemperatureUnit:            "F",
+			ProbabilityOfPrecipitation: QuantitativeValue{Value: pop, UnitCode: "wmoUnit:percent"},
+			Dewpoint:                   QuantitativeValue{Value: dewpoint, UnitCode: dewpointUnit},
+			RelativeHumidity:           QuantitativeValue{Value: humidity, UnitCode: "wmoUnit:percent"},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		period       ForecastPeriod
+		wantPop      *float64
+		wantDewpoint *float64
+		wantHumidity *float64
+	}{
+		{
+			name:         "celsius dewpoint",
+			period:       period(1, floatPtr(40), floatPtr(2.5), "wmoUnit:degC", floatPtr(65)),
+			wantPop:      floatPtr(40),
+			wantDewpoint: floatPtr(2.5),
+			wantHumidity: floatPtr(65),
+		},
+		{
+			name:         "fahrenheit dewpoint",
+			period:       period(2, floatPtr(0), floatPtr(41), "wmoUnit:degF", floatPtr(80)),
+			wantPop:      floatPtr(0),
+			wantDewpoint: floatPtr(5),
+			wantHumidity: floatPtr(80),
+		},
+		{
+			name:   "missing values",
+			period: period(3, nil, nil, "wmoUnit:degC", nil),
+		},
+	}
+
+	forecast := &ForecastResponse{}
+	for _, tt := range tests {
+		forecast.Properties.Periods = append(forecast.Properties.Periods, tt.period)
+	}
+
+	run := &ForecastRun{Latitude: 44.9778, Longitude: -93.2650, IsHourly: true, Provider: ProviderNWS}
+	if _, err := SaveForecastRun(run, forecast); err != nil {
+		t.Fatalf("SaveForecastRun() error = %v", err)
+	}
+
+	stored, err := GetLatestForecast(run.Latitude, run.Longitude, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != len(tests) {
+		t.Fatalf("stored %d periods, want %d", len(stored), len(tests))
+	}
+
+	equal := func(got, want *float64) bool {
+		if got == nil || want == nil {
+			return got == want
+		}
+		return math.Abs(*got-*want) < 1e-9
+	}
+	format := func(v *float64) string {
+		if v == nil {
+			return "nil"
+		}
+		return fmt.Sprint(*v)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stored[i]
+			if !equal(got.PrecipitationProbability, tt.wantPop) {
+				t.Errorf("PrecipitationProbability = %s, want %s", format(got.PrecipitationProbability), format(tt.wantPop))
+			}
+			if !equal(got.DewpointC, tt.wantDewpoint) {
+				t.Errorf("DewpointC = %s, want %s", format(got.DewpointC), format(tt.wantDewpoint))
+			}
+			if !equal(got.RelativeHumidity, tt.wantHumidity) {
+				t.Errorf("RelativeHumidity = %s, want %s", format(got.RelativeHumidity), format(tt.wantHumidity))
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatForecast(t *testing.T) {
+	tests := []struct {
+		name    string
+		hourly  bool
+		periods int
+		units   UnitSystem
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "daily us",
+			periods: 2,
+			units:   UnitsUS,
+			want: []string{
+				"Weather Forecast:\n==================\n\n📅 Today\n",
+				"🌡️  Temperature: 61°F\n",
+				"💨 Wind: 4 to 5 mph N\n",
+				"☔ Chance of precipitation: 30%\n",
+				"☁️  Conditions: Chance Rain Showers\n",
+				"📝 Details: Chance Rain Showers, with a high near 61.",
+				"📅 Tonight\n🌡️  Temperature: 43°F\n",
+			},
+			notWant: []string{"📅 Monday", "💧"},
+		},
+		{
+			name:    "daily si",
+			periods: 1,
+			units:   UnitsSI,
+			want:    []string{"🌡️  Temperature: 16°C\n", "💨 Wind: 2 m/s N\n"},
+			notWant: []string{"°F", "📅 Tonight"},
+		},
+		{
+			name:    "daily metric",
+			periods: 1,
+			units:   UnitsMetric,
+			want:    []string{"🌡️  Temperature: 16°C\n", "💨 Wind: 6 to 8 km/h N\n"},
+		},
+		{
+			name:    "hourly us",
+			hourly:  true,
+			periods: 1,
+			units:   UnitsUS,
+			want: []string{
+				"🌡️  Temperature: 59°F\n",
+				"💨 Wind: 4 mph N\n",
+				"💧 Dewpoint: 36.2°F, humidity: 43%\n",
+				"☁️  Conditions: Slight Chance Rain Showers\n",
+			},
+			notWant: []string{"📝 Details"},
+		},
+		{
+			name:    "all periods",
+			hourly:  true,
+			periods: 0,
+			units:   UnitsUS,
+			want:    []string{"💨 Wind:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := replayForecast(t, tt.hourly)
+			got := forecast.FormatForecast(tt.periods, tt.units)
+
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("FormatForecast() missing %q in:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("FormatForecast() contains %q in:\n%s", notWant, got)
+				}
+			}
+
+			periods := tt.periods
+			if periods == 0 {
+				periods = len(forecast.Properties.Periods)
+			}
+			if count := strings.Count(got, "🌡️  Temperature:"); count != periods {
+				t.Errorf("FormatForecast() shows %d periods, want %d", count, periods)
+			}
+		})
+	}
+}